@@ -1,25 +1,79 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
 	"github.com/josevitorrodriguess/any-song/backend/internal/api"
+	"github.com/josevitorrodriguess/any-song/backend/internal/config"
+	"github.com/josevitorrodriguess/any-song/backend/internal/logging"
 	"github.com/josevitorrodriguess/any-song/backend/internal/storage/postgres"
+	"github.com/josevitorrodriguess/any-song/backend/internal/tracing"
 )
 
 func main() {
 	godotenv.Load()
-	app := fiber.New()
 
-	db := postgres.ConnectDatabase()
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("Falha ao carregar a configuração", "error", err)
+		os.Exit(1)
+	}
+	logging.Setup(cfg.Log)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		slog.Error("Falha ao iniciar o tracing", "error", err)
+		os.Exit(1)
+	}
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: api.ErrorHandler,
+	})
+
+	db, err := postgres.ConnectDatabase(cfg.Database)
+	if err != nil {
+		slog.Error("Falha ao iniciar o PostgreSQL", "error", err)
+		os.Exit(1)
+	}
 
-	api := api.InitApi(db, app)
+	api := api.InitApi(cfg, db, app)
 	api.Router = app
 
 	api.SetupRoutes()
 
-	if err := app.Listen(":8000"); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(fmt.Sprintf(":%d", cfg.Server.Port))
+	}()
+
+	select {
+	case err := <-listenErr:
 		panic("Failed to start server: " + err.Error())
+	case <-ctx.Done():
 	}
+	// Um segundo sinal volta ao comportamento padrão e encerra na hora.
+	stop()
 
+	if err := api.Shutdown(cfg.Server.ShutdownTimeout.Duration); err != nil {
+		slog.Error("Desligamento incompleto", "error", err)
+	}
+
+	// Envia os spans que ainda estão no buffer do exportador
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Error("Falha ao enviar os spans pendentes", "error", err)
+	}
+	slog.Info("Servidor desligado")
 }
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const exportTimeout = 10 * time.Minute
+
+// ExportMeHandler devolve um ZIP com export.json e os áudios do usuário. Os
+// dados do banco são lidos antes de começar a resposta, para que uma falha
+// ainda possa virar um 500.
+func (api *API) ExportMeHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	export, err := api.AccountService.Export(c.UserContext(), user.UID)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"any-song-export-%s.zip\"", export.ExportedAt.Format("20060102-150405")))
+	// O corpo é escrito depois que o handler retorna, quando c já não vale
+	reqCtx := c.UserContext()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, done := api.jobContext(reqCtx, exportTimeout)
+		defer done()
+
+		if err := api.AccountService.WriteExportZip(ctx, export, w); err != nil {
+			slog.ErrorContext(ctx, "Falha ao gerar export da conta", "user_uid", user.UID, "error", err)
+		}
+		w.Flush()
+	})
+	return nil
+}
+
+func (api *API) GetDeletionHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	deletion, err := api.AccountService.GetDeletion(c.UserContext(), user.UID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(deletion)
+}
+
+// ScheduleDeletionHandler agenda a exclusão da conta. Até a data agendada o
+// usuário continua entrando normalmente e pode cancelar.
+func (api *API) ScheduleDeletionHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	deletion, err := api.AccountService.ScheduleDeletion(c.UserContext(), user.UID)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(deletion)
+}
+
+func (api *API) CancelDeletionHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	if err := api.AccountService.CancelDeletion(c.UserContext(), user.UID); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"message": "Exclusão da conta cancelada",
+	})
+}
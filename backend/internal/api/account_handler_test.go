@@ -0,0 +1,83 @@
+package api_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/josevitorrodriguess/any-song/backend/internal/api"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apitest"
+	"github.com/josevitorrodriguess/any-song/backend/internal/repository"
+	"github.com/josevitorrodriguess/any-song/backend/internal/service"
+)
+
+// O MP3 baixado precisa entrar no export da conta e sair com a exclusão.
+func TestDownloadedAudioFollowsTheAccount(t *testing.T) {
+	h := apitest.New(t)
+	token := signedIn(h, "alice")
+	audio := []byte("ID3 garota de ipanema")
+	h.Scripts.Handle(api.ScriptDownload, func(cmd *exec.Cmd) ([]byte, error) {
+		dir := apitest.Env(cmd, "OUTPUT_DIR")
+		if err := os.WriteFile(filepath.Join(dir, "Garota de Ipanema.mp3"), audio, 0o644); err != nil {
+			return nil, err
+		}
+		return []byte("Duração: 320 segundos\n"), nil
+	})
+
+	resp := h.Do(apitest.Request{Method: http.MethodPost, Path: "/download-song", Token: token, Body: map[string]any{"query": "garota de ipanema"}})
+	if resp.Status != http.StatusOK || !bytes.Equal(resp.Body, audio) {
+		t.Fatalf("download: status %d, corpo %q", resp.Status, resp.Body)
+	}
+
+	object := service.UserObjectPrefix("alice") + "downloads/Garota de Ipanema.mp3"
+	resp = h.Do(apitest.Request{Method: http.MethodGet, Path: "/me/export", Token: token})
+	if resp.Status != http.StatusOK {
+		t.Fatalf("export: status %d, corpo %s", resp.Status, resp.Body)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(resp.Body), int64(len(resp.Body)))
+	if err != nil {
+		t.Fatalf("export não é um ZIP: %v", err)
+	}
+	var export service.AccountExport
+	if err := json.Unmarshal(readZipEntry(t, archive, "export.json"), &export); err != nil {
+		t.Fatalf("export.json: %v", err)
+	}
+	if !slices.Contains(export.AudioFiles, object) {
+		t.Errorf("audio_files = %v, esperado %s", export.AudioFiles, object)
+	}
+	if got := readZipEntry(t, archive, "audio/downloads/Garota de Ipanema.mp3"); !bytes.Equal(got, audio) {
+		t.Errorf("áudio no ZIP = %q", got)
+	}
+
+	if err := h.API.AccountService.Purge(t.Context(), "alice"); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if names, _ := h.Storage.ListObjects(t.Context(), service.UserObjectPrefix("alice")); len(names) != 0 {
+		t.Errorf("objetos que sobraram no bucket: %v", names)
+	}
+	if _, err := h.Store.Users().FindByFirebaseUID(t.Context(), "alice"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("usuário depois do Purge: %v", err)
+	}
+}
+
+func readZipEntry(t *testing.T, archive *zip.Reader, name string) []byte {
+	t.Helper()
+	file, err := archive.Open(name)
+	if err != nil {
+		t.Fatalf("entrada %s no ZIP: %v", name, err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatalf("ler %s do ZIP: %v", name, err)
+	}
+	return data
+}
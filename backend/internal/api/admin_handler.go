@@ -0,0 +1,98 @@
+package api
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/josevitorrodriguess/any-song/backend/internal/service"
+)
+
+type GrantRoleRequest struct {
+	Role string `json:"role" validate:"required,max=50"`
+}
+
+// AuditLogQuery são os filtros do audit log; from e to usam RFC 3339.
+type AuditLogQuery struct {
+	ActorUID   string `query:"actor" validate:"max=128"`
+	Action     string `query:"action" validate:"max=100"`
+	TargetType string `query:"target_type" validate:"max=100"`
+	TargetID   string `query:"target_id" validate:"max=128"`
+	From       string `query:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To         string `query:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+func (api *API) ListRolesHandler(c *fiber.Ctx) error {
+	roles, err := api.RBACService.ListRoles(c.UserContext())
+	if err != nil {
+		return err
+	}
+	return c.JSON(roles)
+}
+
+func (api *API) ListUserRolesHandler(c *fiber.Ctx) error {
+	userRoles, err := api.RBACService.ListUserRoles(c.UserContext(), c.Params("firebaseUID"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(userRoles)
+}
+
+func (api *API) GrantRoleHandler(c *fiber.Ctx) error {
+	admin, _ := GetUserFromContext(c)
+
+	var req GrantRoleRequest
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
+	}
+
+	if err := api.RBACService.GrantRole(c.UserContext(), c.Params("firebaseUID"), req.Role, admin.UID); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Papel concedido com sucesso",
+	})
+}
+
+func (api *API) RevokeRoleHandler(c *fiber.Ctx) error {
+	if err := api.RBACService.RevokeRole(c.UserContext(), c.Params("firebaseUID"), c.Params("role")); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"message": "Papel revogado com sucesso",
+	})
+}
+
+// ListAuditLogHandler filtra o audit log por ?actor=, ?action=, ?target_type=,
+// ?target_id= e pelo intervalo ?from= / ?to= (RFC 3339).
+func (api *API) ListAuditLogHandler(c *fiber.Ctx) error {
+	var query AuditLogQuery
+	if err := bindQuery(c, &query); err != nil {
+		return validationFailed(err)
+	}
+
+	filter := service.AuditFilter{
+		ActorUID:   query.ActorUID,
+		Action:     query.Action,
+		TargetType: query.TargetType,
+		TargetID:   query.TargetID,
+	}
+	// from e to já passaram pela validação de formato
+	if query.From != "" {
+		from, _ := time.Parse(time.RFC3339, query.From)
+		filter.From = &from
+	}
+	if query.To != "" {
+		to, _ := time.Parse(time.RFC3339, query.To)
+		filter.To = &to
+	}
+
+	page, pageSize, err := pagination(c)
+	if err != nil {
+		return validationFailed(err)
+	}
+	entries, err := api.AuditService.Query(c.UserContext(), filter, page, pageSize)
+	if err != nil {
+		return err
+	}
+	return c.JSON(entries)
+}
@@ -0,0 +1,164 @@
+package api_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/josevitorrodriguess/any-song/backend/internal/apitest"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+)
+
+func userRoleNames(t *testing.T, h *apitest.Harness, userUID string) []string {
+	t.Helper()
+	userRoles, err := h.Store.Roles().UserRoles(t.Context(), userUID)
+	if err != nil {
+		t.Fatalf("listar papéis de %s: %v", userUID, err)
+	}
+	names := []string{}
+	for _, userRole := range userRoles {
+		names = append(names, userRole.RoleName)
+	}
+	return names
+}
+
+func TestAdminHandlers(t *testing.T) {
+	runHandlerCases(t, []handlerCase{
+		{
+			name: "lista os papéis",
+			setup: func(h *apitest.Harness) apitest.Request {
+				return apitest.Request{Method: http.MethodGet, Path: "/admin/roles", Token: signedIn(h, "admin", models.RoleAdmin)}
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				var roles []models.Role
+				resp.JSON(t, &roles)
+				if len(roles) != len(models.DefaultRoles) {
+					t.Errorf("papéis = %+v", roles)
+				}
+			},
+		},
+		{
+			name: "curador não gerencia papéis",
+			setup: func(h *apitest.Harness) apitest.Request {
+				return apitest.Request{Method: http.MethodGet, Path: "/admin/roles", Token: signedIn(h, "curator", models.RoleCurator)}
+			},
+			status: http.StatusForbidden,
+			code:   apperror.CodeForbidden,
+		},
+		{
+			name: "concede papel",
+			setup: func(h *apitest.Harness) apitest.Request {
+				h.SeedUser(models.User{FirebaseUID: "bob"})
+				return apitest.Request{
+					Method: http.MethodPost,
+					Path:   "/admin/users/bob/roles",
+					Token:  signedIn(h, "admin", models.RoleAdmin),
+					Body:   map[string]string{"role": models.RoleCurator},
+				}
+			},
+			status: http.StatusCreated,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				if roles := userRoleNames(t, h, "bob"); !reflect.DeepEqual(roles, []string{models.RoleCurator}) {
+					t.Errorf("papéis de bob = %v", roles)
+				}
+				if actions := auditActions(t, h); !reflect.DeepEqual(actions, []string{models.AuditRoleGrant}) {
+					t.Errorf("audit log = %v", actions)
+				}
+			},
+		},
+		{
+			name: "papel concedido vale na hora",
+			setup: func(h *apitest.Harness) apitest.Request {
+				bob := h.Token(h.SeedUser(models.User{FirebaseUID: "bob"}))
+				// Põe as permissões de bob no cache antes da concessão
+				h.Do(apitest.Request{Method: http.MethodPost, Path: "/artist/create", Token: bob, Body: map[string]string{"name": "Anitta"}})
+				h.Do(apitest.Request{
+					Method: http.MethodPost,
+					Path:   "/admin/users/bob/roles",
+					Token:  signedIn(h, "admin", models.RoleAdmin),
+					Body:   map[string]string{"role": models.RoleCurator},
+				})
+				return apitest.Request{Method: http.MethodPost, Path: "/artist/create", Token: bob, Body: map[string]string{"name": "Anitta"}}
+			},
+			status: http.StatusCreated,
+		},
+		{
+			name: "concede papel inexistente",
+			setup: func(h *apitest.Harness) apitest.Request {
+				h.SeedUser(models.User{FirebaseUID: "bob"})
+				return apitest.Request{
+					Method: http.MethodPost,
+					Path:   "/admin/users/bob/roles",
+					Token:  signedIn(h, "admin", models.RoleAdmin),
+					Body:   map[string]string{"role": "superuser"},
+				}
+			},
+			status: http.StatusNotFound,
+			code:   apperror.CodeRoleNotFound,
+		},
+		{
+			name: "concede papel a usuário inexistente",
+			setup: func(h *apitest.Harness) apitest.Request {
+				return apitest.Request{
+					Method: http.MethodPost,
+					Path:   "/admin/users/ghost/roles",
+					Token:  signedIn(h, "admin", models.RoleAdmin),
+					Body:   map[string]string{"role": models.RoleCurator},
+				}
+			},
+			status: http.StatusNotFound,
+			code:   apperror.CodeUserNotFound,
+		},
+		{
+			name: "revoga papel",
+			setup: func(h *apitest.Harness) apitest.Request {
+				h.SeedUser(models.User{FirebaseUID: "bob"})
+				h.GrantRole("bob", models.RoleCurator)
+				return apitest.Request{Method: http.MethodDelete, Path: "/admin/users/bob/roles/curator", Token: signedIn(h, "admin", models.RoleAdmin)}
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				if roles := userRoleNames(t, h, "bob"); len(roles) != 0 {
+					t.Errorf("papéis de bob = %v", roles)
+				}
+			},
+		},
+		{
+			name: "desativar conta derruba os tokens do usuário",
+			setup: func(h *apitest.Harness) apitest.Request {
+				bob := h.Token(h.SeedUser(models.User{FirebaseUID: "bob"}))
+				h.Do(apitest.Request{
+					Method: http.MethodPatch,
+					Path:   "/admin/users/bob",
+					Token:  signedIn(h, "admin", models.RoleAdmin),
+					Body:   map[string]bool{"is_active": false},
+				})
+				return apitest.Request{Method: http.MethodGet, Path: "/me/", Token: bob}
+			},
+			status: http.StatusForbidden,
+			code:   apperror.CodeAccountDisabled,
+		},
+		{
+			name: "audit log filtrado por ação",
+			setup: func(h *apitest.Harness) apitest.Request {
+				token := signedIn(h, "admin", models.RoleAdmin)
+				h.SeedArtist("Anitta")
+				h.Do(apitest.Request{Method: http.MethodGet, Path: "/admin/roles", Token: signedIn(h, "bob")})
+				return apitest.Request{Method: http.MethodGet, Path: "/admin/audit?action=" + models.AuditPermissionDeny, Token: token}
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				var page struct {
+					Items []models.AuditLog `json:"items"`
+					Total int64             `json:"total"`
+				}
+				resp.JSON(t, &page)
+				if page.Total != 1 || len(page.Items) != 1 || page.Items[0].ActorUID != "bob" {
+					t.Errorf("página = %+v", page)
+				}
+			},
+		},
+	})
+}
@@ -2,12 +2,19 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 
 	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/storage"
 	firebase "firebase.google.com/go/v4"
-	"firebase.google.com/go/v4/auth"
+	goredis "github.com/go-redis/redis/v8"
 	"github.com/gofiber/fiber/v2"
+	"github.com/josevitorrodriguess/any-song/backend/internal/auth"
 	"github.com/josevitorrodriguess/any-song/backend/internal/config"
+	"github.com/josevitorrodriguess/any-song/backend/internal/metrics"
+	"github.com/josevitorrodriguess/any-song/backend/internal/repository"
 	"github.com/josevitorrodriguess/any-song/backend/internal/service"
 	"github.com/josevitorrodriguess/any-song/backend/internal/storage/gcs"
 	"github.com/josevitorrodriguess/any-song/backend/internal/storage/redis"
@@ -15,50 +22,210 @@ import (
 )
 
 type API struct {
-	Firebase      *firebase.App
-	Firestore     *firestore.Client
-	Auth          *auth.Client
-	UserService   *service.UserService
-	ArtistService *service.ArtistService
-	GCSService    *service.GoogleCloudStorageService
-	CacheService  *service.CacheService
-	Router        *fiber.App
+	Firebase              *firebase.App
+	Firestore             *firestore.Client
+	Verifier              auth.TokenVerifier
+	LocalIssuer           *auth.LocalIssuer
+	UserService           *service.UserService
+	ArtistService         *service.ArtistService
+	PlaylistService       *service.PlaylistService
+	PartyService          *service.PartyService
+	FavoriteService       *service.FavoriteService
+	HistoryService        *service.HistoryService
+	RecommendationService *service.RecommendationService
+	DuetService           *service.DuetService
+	RBACService           *service.RBACService
+	AuthSessionService    *service.AuthSessionService
+	RateLimitService      *service.RateLimitService
+	APIKeyService         *service.APIKeyService
+	AuditService          *service.AuditService
+	AccountService        *service.AccountService
+	Config                *config.Config
+	Capabilities          Capabilities
+	RateLimitConfig       *config.RateLimitConfig
+	Storage               service.ObjectStorage
+	CacheService          *service.CacheService
+	Scripts               ScriptRunner
+	Router                *fiber.App
+	// DB e Redis ficam aqui para o Shutdown fechar as conexões.
+	DB    *gorm.DB
+	Redis *goredis.Client
+
+	lifecycle *lifecycle
+	readiness *readinessCache
 }
 
-func InitApi(db *gorm.DB, router *fiber.App) *API {
-	app, err := config.GetFireBaseApp()
-	if err != nil {
-		panic("Failed to initialize Firebase app: " + err.Error())
+// InitApi monta os serviços. Só o Postgres é obrigatório: autenticação,
+// Firestore, GCS e Redis que falharem (ou estiverem desligados na
+// configuração) ficam registrados em Capabilities e as rotas que dependem
+// deles respondem 503.
+func InitApi(cfg *config.Config, db *gorm.DB, router *fiber.App) *API {
+	capabilities := make(Capabilities)
+
+	var (
+		app             *firebase.App
+		firestoreClient *firestore.Client
+		verifier        auth.TokenVerifier
+		localIssuer     *auth.LocalIssuer
+	)
+	if cfg.Auth.Provider == config.AuthProviderLocal {
+		// Modo offline: sem Firebase, tokens emitidos e validados localmente.
+		issuer, err := auth.NewLocalIssuer([]byte(cfg.Auth.LocalSigningKey.Value()), cfg.Auth.LocalTokenTTL.Duration)
+		if err == nil {
+			localIssuer, verifier = issuer, issuer
+		}
+		capabilities.record(CapabilityAuth, err)
+		capabilities.disable(CapabilityFirestore, "não usado com AUTH_PROVIDER=local")
+	} else {
+		var err error
+		app, verifier, err = initFirebaseAuth(cfg.Firebase)
+		capabilities.record(CapabilityAuth, err)
+
+		switch {
+		case !cfg.Firebase.FirestoreEnabled:
+			capabilities.disable(CapabilityFirestore, "desligado com FIRESTORE_ENABLED=false")
+		case app == nil:
+			capabilities.record(CapabilityFirestore, errors.New("Firebase não inicializado"))
+		default:
+			firestoreClient, err = app.Firestore(context.Background())
+			capabilities.record(CapabilityFirestore, err)
+		}
 	}
 
-	firestoreClient, err := app.Firestore(context.Background())
-	if err != nil {
-		panic("Failed to initialize Firestore client: " + err.Error())
+	// Interface nil (e não um ponteiro nil) quando o GCS não sobe
+	var objectStorage service.ObjectStorage
+	if cfg.GCS.Enabled {
+		gcsClient, err := initStorage(cfg.GCS)
+		if err == nil {
+			objectStorage = service.NewGoogleCloudStorageService(gcsClient, cfg.GCS.BucketName)
+		}
+		capabilities.record(CapabilityStorage, err)
+	} else {
+		capabilities.disable(CapabilityStorage, "desligado com GCS_ENABLED=false")
 	}
 
-	authClient, err := app.Auth(context.Background())
-	if err != nil {
-		panic("Failed to initialize Firebase Auth client: " + err.Error())
+	var redisClient *goredis.Client
+	if cfg.Redis.Enabled {
+		var err error
+		redisClient, err = redis.ConnectRedis(cfg.Redis)
+		capabilities.record(CapabilityCache, err)
+	} else {
+		capabilities.disable(CapabilityCache, "desligado com REDIS_ENABLED=false")
 	}
-	gcsClient, err := gcs.ConnectGoogleCloudStorage()
-	if err != nil {
-		panic("Failed to connect to Google Cloud Storage: " + err.Error())
 
+	for name, status := range capabilities {
+		if !status.Available {
+			slog.Warn("Subsistema indisponível", "capability", name, "reason", status.Reason)
+		}
 	}
-	redisClient := redis.ConnectRedis()
+
+	if sqlDB, err := db.DB(); err == nil {
+		if err := metrics.RegisterDB(sqlDB, cfg.Database.Name); err != nil {
+			slog.Warn("Falha ao registrar métricas do Postgres", "error", err)
+		}
+	}
+
+	api := New(cfg, router, Deps{
+		DB:           db,
+		Redis:        redisClient,
+		Firebase:     app,
+		Firestore:    firestoreClient,
+		Verifier:     verifier,
+		LocalIssuer:  localIssuer,
+		Storage:      objectStorage,
+		Capabilities: capabilities,
+	})
+	if firestoreClient != nil {
+		if err := api.RBACService.BootstrapAdminsFromFirestore(context.Background(), firestoreClient); err != nil {
+			slog.Warn("Falha no bootstrap de admins a partir do Firestore", "error", err)
+		}
+	}
+	api.lifecycle.goWorker(api.HistoryService.Run)
+	api.lifecycle.goWorker(api.RecommendationService.Run)
+	api.lifecycle.goWorker(api.AccountService.Run)
+	return api
+}
+
+// Deps são as conexões e clientes já abertos de que a API precisa. Store e
+// Scripts são opcionais: sem eles, a API usa o Postgres de DB e executa os
+// scripts Python de verdade.
+type Deps struct {
+	DB           *gorm.DB
+	Store        repository.Store
+	Redis        *goredis.Client
+	Firebase     *firebase.App
+	Firestore    *firestore.Client
+	Verifier     auth.TokenVerifier
+	LocalIssuer  *auth.LocalIssuer
+	Storage      service.ObjectStorage
+	Scripts      ScriptRunner
+	Capabilities Capabilities
+}
+
+// New monta os serviços sobre deps sem conectar em nada nem iniciar os
+// workers em segundo plano, que ficam a cargo de InitApi.
+func New(cfg *config.Config, router *fiber.App, deps Deps) *API {
+	store := deps.Store
+	if store == nil {
+		store = repository.NewGormStore(deps.DB)
+	}
+	scripts := deps.Scripts
+	if scripts == nil {
+		scripts = execScriptRunner{}
+	}
+	db, redisClient := deps.DB, deps.Redis
+
 	cacheService := service.NewCacheService(redisClient)
-	userService := service.NewUserService(db, cacheService)
-	artistService := service.NewArtistService(db)
-	gcsService := service.NewGoogleCloudStorageService(gcsClient)
+	authSessionService := service.NewAuthSessionService(store, cacheService, deps.Verifier)
 
 	return &API{
-		Firebase:      app,
-		Firestore:     firestoreClient,
-		Auth:          authClient,
-		UserService:   userService,
-		ArtistService: artistService,
-		GCSService:    gcsService,
-		CacheService:  cacheService,
-		Router:        router,
+		Firebase:              deps.Firebase,
+		Firestore:             deps.Firestore,
+		Verifier:              deps.Verifier,
+		LocalIssuer:           deps.LocalIssuer,
+		UserService:           service.NewUserService(store, cacheService),
+		ArtistService:         service.NewArtistService(store),
+		PlaylistService:       service.NewPlaylistService(db),
+		PartyService:          service.NewPartyService(db, redisClient),
+		FavoriteService:       service.NewFavoriteService(db),
+		HistoryService:        service.NewHistoryService(db),
+		RecommendationService: service.NewRecommendationService(db, cacheService),
+		DuetService:           service.NewDuetService(db),
+		RBACService:           service.NewRBACService(store, cacheService),
+		AuthSessionService:    authSessionService,
+		RateLimitService:      service.NewRateLimitService(redisClient),
+		APIKeyService:         service.NewAPIKeyService(db, cacheService),
+		AuditService:          service.NewAuditService(store),
+		AccountService:        service.NewAccountService(store, redisClient, deps.Storage, deps.Verifier, cfg.Account.DeletionGracePeriod.Duration),
+		Config:                cfg,
+		Capabilities:          deps.Capabilities,
+		RateLimitConfig:       &cfg.RateLimit,
+		Storage:               deps.Storage,
+		CacheService:          cacheService,
+		Scripts:               scripts,
+		Router:                router,
+		DB:                    db,
+		Redis:                 redisClient,
+		lifecycle:             newLifecycle(),
+		readiness:             &readinessCache{},
+	}
+}
+
+func initFirebaseAuth(cfg config.FirebaseConfig) (*firebase.App, auth.TokenVerifier, error) {
+	app, err := config.GetFireBaseApp(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	authClient, err := app.Auth(context.Background())
+	if err != nil {
+		return app, nil, fmt.Errorf("erro ao iniciar o Firebase Auth: %w", err)
+	}
+	return app, auth.NewFirebaseVerifier(authClient), nil
+}
+
+func initStorage(cfg config.GCSConfig) (*storage.Client, error) {
+	if cfg.BucketName == "" {
+		return nil, errors.New("GCS_BUCKET_NAME não configurado")
 	}
+	return gcs.ConnectGoogleCloudStorage(cfg)
 }
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"github.com/josevitorrodriguess/any-song/backend/internal/service"
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"notblank,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,unique,dive,apikeyscope"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" validate:"omitnil,gt"`
+}
+
+// CreateAPIKeyResponse é a única resposta que traz a chave em texto; depois
+// disso só o prefixo fica visível.
+type CreateAPIKeyResponse struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+type APIKeyParams struct {
+	ID string `params:"id" validate:"uuid"`
+}
+
+func (api *API) ListMyAPIKeysHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+	return api.listAPIKeys(c, user.UID)
+}
+
+func (api *API) CreateMyAPIKeyHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+	return api.createAPIKey(c, user.UID, user.UID)
+}
+
+func (api *API) RevokeMyAPIKeyHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+	return api.revokeAPIKey(c, user.UID)
+}
+
+func (api *API) AdminListAPIKeysHandler(c *fiber.Ctx) error {
+	return api.listAPIKeys(c, c.Params("firebaseUID"))
+}
+
+func (api *API) AdminCreateAPIKeyHandler(c *fiber.Ctx) error {
+	admin, _ := GetUserFromContext(c)
+	return api.createAPIKey(c, c.Params("firebaseUID"), admin.UID)
+}
+
+func (api *API) AdminRevokeAPIKeyHandler(c *fiber.Ctx) error {
+	return api.revokeAPIKey(c, c.Params("firebaseUID"))
+}
+
+func (api *API) listAPIKeys(c *fiber.Ctx, userUID string) error {
+	keys, err := api.APIKeyService.ListKeys(c.UserContext(), userUID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"api_keys": keys,
+	})
+}
+
+func (api *API) createAPIKey(c *fiber.Ctx, userUID, createdBy string) error {
+	var req CreateAPIKeyRequest
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
+	}
+
+	key, plaintext, err := api.APIKeyService.CreateKey(c.UserContext(), userUID, strings.TrimSpace(req.Name), req.Scopes, req.ExpiresAt, createdBy)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAPIKeyScope) {
+			return apperror.Wrap(err, apperror.CodeInvalidAPIKeyScope).WithDetails(fiber.Map{
+				"scopes": models.APIKeyScopes,
+			})
+		}
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(CreateAPIKeyResponse{APIKey: *key, Key: plaintext})
+}
+
+func (api *API) revokeAPIKey(c *fiber.Ctx, userUID string) error {
+	var params APIKeyParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+
+	if err := api.APIKeyService.RevokeKey(c.UserContext(), userUID, uuid.MustParse(params.ID)); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"message": "API key revogada com sucesso",
+	})
+}
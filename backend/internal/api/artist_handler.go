@@ -3,99 +3,95 @@ package api
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
 	"github.com/josevitorrodriguess/any-song/backend/internal/models"
 )
 
+type CreateArtistRequest struct {
+	Name string `json:"name" validate:"notblank,max=200"`
+}
+
+type UpdateArtistRequest struct {
+	ID   string `json:"id" validate:"required,uuid"`
+	Name string `json:"name" validate:"notblank,max=200"`
+}
+
+type ArtistParams struct {
+	ID string `params:"id" validate:"uuid"`
+}
+
+type SearchArtistsQuery struct {
+	Name string `query:"name" validate:"max=200"`
+}
+
 func (api *API) CreateArtistHandler(c *fiber.Ctx) error {
-	var artist models.Artist
-	if err := c.BodyParser(&artist); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Dados inválidos",
-		})
+	var req CreateArtistRequest
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
 	}
-	if err := api.ArtistService.CreateArtist(&artist); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Erro ao criar artista",
-		})
+	artist := models.Artist{Name: req.Name}
+	if err := api.ArtistService.CreateArtist(c.UserContext(), &artist); err != nil {
+		return err
 	}
 	return c.Status(fiber.StatusCreated).JSON(artist)
 }
 
 func (api *API) SearchArtistsHandler(c *fiber.Ctx) error {
-	searchTerm := c.Query("name")
+	var query SearchArtistsQuery
+	if err := bindQuery(c, &query); err != nil {
+		return validationFailed(err)
+	}
 
-	artists, err := api.ArtistService.SearchArtists(searchTerm, 10)
+	artists, err := api.ArtistService.SearchArtists(c.UserContext(), query.Name, 10)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Erro ao buscar artistas",
-		})
+		return err
 	}
 
 	return c.JSON(artists)
 }
 
 func (api *API) GetArtistByIDHandler(c *fiber.Ctx) error {
-	id := c.Params("id")
-	if id == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "ID é obrigatório",
-		})
+	var params ArtistParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
 	}
-	artist, err := api.ArtistService.GetArtistByID(id)
+	artist, err := api.ArtistService.GetArtistByID(c.UserContext(), params.ID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Erro ao buscar artista",
-		})
+		return err
 	}
 	if artist == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Artista não encontrado",
-		})
+		return apperror.New(apperror.CodeArtistNotFound)
 	}
 	return c.JSON(artist)
 }
 
 func (api *API) GetAllArtistsHandler(c *fiber.Ctx) error {
-	artists, err := api.ArtistService.GetAllArtists()
+	artists, err := api.ArtistService.GetAllArtists(c.UserContext())
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Erro ao buscar artistas",
-		})
+		return err
 	}
 	return c.JSON(artists)
 }
 
 func (api *API) UpdateArtistHandler(c *fiber.Ctx) error {
-	var artist models.Artist
-	if err := c.BodyParser(&artist); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Dados inválidos",
-		})
-	}
-	if artist.ID == uuid.Nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "ID é obrigatório",
-		})
+	var req UpdateArtistRequest
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
 	}
-	if err := api.ArtistService.UpdateArtist(&artist); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Erro ao atualizar artista",
-		})
+	artist := models.Artist{ID: uuid.MustParse(req.ID), Name: req.Name}
+	if err := api.ArtistService.UpdateArtist(c.UserContext(), &artist); err != nil {
+		return err
 	}
 	return c.JSON(artist)
 }
 
 func (api *API) DeleteArtistHandler(c *fiber.Ctx) error {
-	id := c.Params("id")
-	if id == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "ID é obrigatório",
-		})
+	var params ArtistParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
 	}
-	if err := api.ArtistService.DeleteArtist(id); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Erro ao deletar artista",
-		})
+	if err := api.ArtistService.DeleteArtist(c.UserContext(), params.ID); err != nil {
+		return err
 	}
 	return c.JSON(fiber.Map{
 		"message": "Artista deletado com sucesso",
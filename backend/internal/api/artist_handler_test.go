@@ -0,0 +1,178 @@
+package api_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apitest"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+)
+
+func artistNames(t *testing.T, resp *apitest.Response) []string {
+	t.Helper()
+	var artists []models.Artist
+	resp.JSON(t, &artists)
+	names := make([]string, len(artists))
+	for i, artist := range artists {
+		names[i] = artist.Name
+	}
+	return names
+}
+
+func TestArtistHandlers(t *testing.T) {
+	runHandlerCases(t, []handlerCase{
+		{
+			name: "lista em ordem alfabética",
+			setup: func(h *apitest.Harness) apitest.Request {
+				h.SeedArtist("Zeca Pagodinho")
+				h.SeedArtist("Anitta")
+				return apitest.Request{Method: http.MethodGet, Path: "/artist/"}
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				if names := artistNames(t, resp); !reflect.DeepEqual(names, []string{"Anitta", "Zeca Pagodinho"}) {
+					t.Errorf("artistas = %v", names)
+				}
+			},
+		},
+		{
+			name: "busca ignora acentos, espaços e caixa",
+			setup: func(h *apitest.Harness) apitest.Request {
+				h.SeedArtist("João Gilberto")
+				h.SeedArtist("Gilberto Gil")
+				return apitest.Request{Method: http.MethodGet, Path: "/artist/search?name=Joao%20Gil"}
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				if names := artistNames(t, resp); !reflect.DeepEqual(names, []string{"João Gilberto"}) {
+					t.Errorf("artistas = %v", names)
+				}
+			},
+		},
+		{
+			name: "busca por id",
+			setup: func(h *apitest.Harness) apitest.Request {
+				artist := h.SeedArtist("Anitta")
+				return apitest.Request{Method: http.MethodGet, Path: "/artist/id/" + artist.ID.String()}
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "id inexistente",
+			setup: func(h *apitest.Harness) apitest.Request {
+				return apitest.Request{Method: http.MethodGet, Path: "/artist/id/" + uuid.NewString()}
+			},
+			status: http.StatusNotFound,
+			code:   apperror.CodeArtistNotFound,
+		},
+		{
+			name: "id que não é uuid",
+			setup: func(h *apitest.Harness) apitest.Request {
+				return apitest.Request{Method: http.MethodGet, Path: "/artist/id/123"}
+			},
+			status: http.StatusUnprocessableEntity,
+			code:   apperror.CodeValidationFailed,
+		},
+		{
+			name: "criar sem login",
+			setup: func(h *apitest.Harness) apitest.Request {
+				return apitest.Request{Method: http.MethodPost, Path: "/artist/create", Body: map[string]string{"name": "Anitta"}}
+			},
+			status: http.StatusUnauthorized,
+			code:   apperror.CodeUnauthorized,
+		},
+		{
+			name: "criar sem catalog:write",
+			setup: func(h *apitest.Harness) apitest.Request {
+				token := signedIn(h, "listener")
+				return apitest.Request{Method: http.MethodPost, Path: "/artist/create", Token: token, Body: map[string]string{"name": "Anitta"}}
+			},
+			status: http.StatusForbidden,
+			code:   apperror.CodeForbidden,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				if actions := auditActions(t, h); !reflect.DeepEqual(actions, []string{models.AuditPermissionDeny}) {
+					t.Errorf("audit log = %v", actions)
+				}
+			},
+		},
+		{
+			name: "curador cria artista",
+			setup: func(h *apitest.Harness) apitest.Request {
+				token := signedIn(h, "curator", models.RoleCurator)
+				return apitest.Request{Method: http.MethodPost, Path: "/artist/create", Token: token, Body: map[string]string{"name": "Anitta"}}
+			},
+			status: http.StatusCreated,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				var artist models.Artist
+				resp.JSON(t, &artist)
+				stored, err := h.Store.Artists().FindByID(t.Context(), artist.ID)
+				if err != nil || stored.Name != "Anitta" {
+					t.Errorf("artista gravado = %+v, %v", stored, err)
+				}
+				if actions := auditActions(t, h); !reflect.DeepEqual(actions, []string{models.AuditArtistCreate}) {
+					t.Errorf("audit log = %v", actions)
+				}
+			},
+		},
+		{
+			name: "criar com nome em branco",
+			setup: func(h *apitest.Harness) apitest.Request {
+				token := signedIn(h, "curator", models.RoleCurator)
+				return apitest.Request{Method: http.MethodPost, Path: "/artist/create", Token: token, Body: map[string]string{"name": "  "}}
+			},
+			status: http.StatusUnprocessableEntity,
+			code:   apperror.CodeValidationFailed,
+		},
+		{
+			name: "atualizar renomeia e refaz o nome normalizado",
+			setup: func(h *apitest.Harness) apitest.Request {
+				artist := h.SeedArtist("Anita")
+				token := signedIn(h, "curator", models.RoleCurator)
+				return apitest.Request{Method: http.MethodPut, Path: "/artist/update", Token: token, Body: map[string]string{
+					"id":   artist.ID.String(),
+					"name": "Anitta",
+				}}
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				found := h.Do(apitest.Request{Method: http.MethodGet, Path: "/artist/search?name=anitta"})
+				if names := artistNames(t, found); !reflect.DeepEqual(names, []string{"Anitta"}) {
+					t.Errorf("busca depois de renomear = %v", names)
+				}
+			},
+		},
+		{
+			name: "atualizar artista inexistente",
+			setup: func(h *apitest.Harness) apitest.Request {
+				token := signedIn(h, "curator", models.RoleCurator)
+				return apitest.Request{Method: http.MethodPut, Path: "/artist/update", Token: token, Body: map[string]string{
+					"id":   uuid.NewString(),
+					"name": "Anitta",
+				}}
+			},
+			status: http.StatusNotFound,
+			code:   apperror.CodeArtistNotFound,
+		},
+		{
+			name: "admin remove artista",
+			setup: func(h *apitest.Harness) apitest.Request {
+				artist := h.SeedArtist("Anitta")
+				token := signedIn(h, "admin", models.RoleAdmin)
+				return apitest.Request{Method: http.MethodDelete, Path: "/artist/delete/" + artist.ID.String(), Token: token}
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				if artists, _ := h.Store.Artists().List(t.Context()); len(artists) != 0 {
+					t.Errorf("artistas depois de remover = %v", artists)
+				}
+				want := []string{models.AuditArtistCreate, models.AuditArtistDelete}
+				if actions := auditActions(t, h); !reflect.DeepEqual(actions, want) {
+					t.Errorf("audit log = %v, esperado %v", actions, want)
+				}
+			},
+		},
+	})
+}
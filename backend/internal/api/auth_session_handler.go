@@ -0,0 +1,60 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+)
+
+type AuthSessionResponse struct {
+	models.AuthSession
+	Current bool `json:"current"`
+}
+
+func (api *API) ListAuthSessionsHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	sessions, err := api.AuthSessionService.ListSessions(c.UserContext(), user.UID)
+	if err != nil {
+		return err
+	}
+
+	response := make([]AuthSessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = AuthSessionResponse{
+			AuthSession: session,
+			Current:     session.AuthTime.Equal(user.AuthTime),
+		}
+	}
+	return c.JSON(fiber.Map{
+		"sessions": response,
+	})
+}
+
+func (api *API) RevokeAuthSessionHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var params SessionParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+
+	if err := api.AuthSessionService.RevokeSession(c.UserContext(), user.UID, uuid.MustParse(params.ID)); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"message": "Sessão revogada com sucesso",
+	})
+}
+
+// RevokeAllAuthSessionsHandler encerra todas as sessões, inclusive a atual.
+func (api *API) RevokeAllAuthSessionsHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	if err := api.AuthSessionService.RevokeAll(c.UserContext(), user.UID); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"message": "Todas as sessões foram revogadas",
+	})
+}
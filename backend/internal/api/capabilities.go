@@ -0,0 +1,76 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+)
+
+// Subsistemas opcionais. Sem eles a API continua no ar e só as rotas que
+// dependem de cada um respondem 503.
+const (
+	// CapabilityAuth é o verificador de tokens (Firebase Auth ou emissor local).
+	CapabilityAuth = "auth"
+	// CapabilityFirestore é usado só no bootstrap de admins.
+	CapabilityFirestore = "firestore"
+	// CapabilityStorage é o bucket do GCS com os áudios dos usuários.
+	CapabilityStorage = "storage"
+	// CapabilityCache é o Redis: cache, rate limit, cotas e salas de festa.
+	CapabilityCache = "cache"
+)
+
+type CapabilityStatus struct {
+	Enabled   bool   `json:"enabled"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Capabilities é preenchido uma vez no InitApi e só lido depois disso.
+type Capabilities map[string]CapabilityStatus
+
+// record marca o subsistema como disponível, ou indisponível com o motivo do
+// erro de inicialização.
+func (c Capabilities) record(name string, err error) {
+	if err != nil {
+		c[name] = CapabilityStatus{Enabled: true, Reason: err.Error()}
+		return
+	}
+	c[name] = CapabilityStatus{Enabled: true, Available: true}
+}
+
+func (c Capabilities) disable(name, reason string) {
+	c[name] = CapabilityStatus{Reason: reason}
+}
+
+func (c Capabilities) Available(name string) bool {
+	return c[name].Available
+}
+
+// Degraded indica se algum subsistema ligado na configuração não subiu.
+func (c Capabilities) Degraded() bool {
+	for _, status := range c {
+		if status.Enabled && !status.Available {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireCapability responde 503 quando a rota depende de um subsistema que
+// não subiu ou foi desligado na configuração.
+func (api *API) RequireCapability(names ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		for _, name := range names {
+			if !api.Capabilities.Available(name) {
+				return api.capabilityUnavailable(name)
+			}
+		}
+		return c.Next()
+	}
+}
+
+func (api *API) capabilityUnavailable(name string) error {
+	return apperror.New(apperror.CodeCapabilityDisabled).WithDetails(fiber.Map{
+		"capability": name,
+		"reason":     api.Capabilities[name].Reason,
+	})
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/josevitorrodriguess/any-song/backend/internal/auth"
+)
+
+// devRoutesEnabled diz se as rotas de desenvolvimento são registradas: só
+// com AUTH_PROVIDER=local e ENVIRONMENT=development ou test. Config.Validate
+// já recusa o modo local nos outros ambientes; a checagem se repete aqui
+// para o caso de a validação ser contornada.
+func (api *API) devRoutesEnabled() bool {
+	return api.LocalIssuer != nil && api.Config.IsDevelopment()
+}
+
+// IssueDevTokenHandler emite um ID token local. A rota só existe com
+// AUTH_PROVIDER=local em desenvolvimento; o token pode ser usado no /signin e
+// como Bearer.
+func (api *API) IssueDevTokenHandler(c *fiber.Ctx) error {
+	var identity auth.LocalIdentity
+	if err := bindBody(c, &identity); err != nil {
+		return validationFailed(err)
+	}
+
+	token, expiresAt, err := api.LocalIssuer.Issue(identity)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"id_token":   token,
+		"expires_at": expiresAt,
+	})
+}
@@ -0,0 +1,30 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/josevitorrodriguess/any-song/backend/internal/apitest"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+	"github.com/josevitorrodriguess/any-song/backend/internal/config"
+)
+
+func TestIssueDevTokenHandler(t *testing.T) {
+	for _, tc := range []struct {
+		environment string
+		status      int
+		code        apperror.Code
+	}{
+		{config.EnvironmentTest, http.StatusOK, ""},
+		{config.EnvironmentDevelopment, http.StatusOK, ""},
+		{"production", http.StatusNotFound, apperror.CodeRouteNotFound},
+	} {
+		t.Run(tc.environment, func(t *testing.T) {
+			h := apitest.New(t, func(cfg *config.Config) { cfg.Environment = tc.environment })
+			resp := h.Do(apitest.Request{Method: http.MethodPost, Path: "/dev/token", Body: map[string]any{"uid": "alice", "email": "alice@example.com"}})
+			if resp.Status != tc.status || resp.ErrorCode() != string(tc.code) {
+				t.Errorf("status = %d, code = %q; esperado %d, %q", resp.Status, resp.ErrorCode(), tc.status, tc.code)
+			}
+		})
+	}
+}
@@ -1,26 +1,40 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
-	"time"
+	"regexp"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+	"github.com/josevitorrodriguess/any-song/backend/internal/config"
+	"github.com/josevitorrodriguess/any-song/backend/internal/logging"
 )
 
+// downloadDurationPattern extrai a duração que o yt_downloader.py imprime
+// antes de baixar, usada para descontar da cota diária. O yt-dlp pode mandar
+// a duração fracionada ("213.0") ou None.
+var downloadDurationPattern = regexp.MustCompile(`Duração: ([\d.]+) segundos`)
+
+// downloadBitrate é a taxa fixa do MP3 que o yt_downloader.py gera
+// (preferredquality), usada para estimar a duração pelo tamanho do arquivo.
+const downloadBitrate = 192_000
+
 // DownloadRequest represents the download request structure
 type DownloadRequest struct {
-	Query string `json:"query" binding:"required"`
+	Query string `json:"query" validate:"notblank,max=200"`
 }
 
 // SearchRequest represents the search request structure
 type SearchRequest struct {
-	Query      string `json:"query" binding:"required"`
-	MaxResults int    `json:"max_results,omitempty"`
+	Query      string `json:"query" validate:"notblank,max=200"`
+	MaxResults int    `json:"max_results,omitempty" validate:"gte=0,lte=20"`
 }
 
 // SearchResult represents a single search result
@@ -37,130 +51,130 @@ type SearchResult struct {
 // DownloadSongHandler handles song download requests from YouTube
 func (api *API) DownloadSongHandler(c *fiber.Ctx) error {
 	// Check authentication
-	_, exists := GetUserFromContext(c)
+	user, exists := GetUserFromContext(c)
 	if !exists {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Usuário não encontrado",
-		})
+		return apperror.New(apperror.CodeUnauthorized)
 	}
 
 	var req DownloadRequest
 	
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Query é obrigatória",
-		})
-	}
-
-	if req.Query == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Query não pode estar vazia",
-		})
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
 	}
 
 	// Create temp directory for downloads
-	tempDir := fmt.Sprintf("/tmp/anysong-downloads/%d", time.Now().Unix())
-	if err := os.MkdirAll(tempDir, 0755); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Erro ao criar diretório temporário",
-		})
+	tempDir, err := createDownloadDir()
+	if err != nil {
+		return err
 	}
+	// Removed after the response is sent, or right away on shutdown
+	defer api.removeTempDirLater(tempDir)
 
-	log.Printf("Downloading song with query: %s to %s", req.Query, tempDir)
+	ctx, done := api.jobContext(c.UserContext(), 0)
+	defer done()
+
+	slog.InfoContext(ctx, "Baixando música", "query", req.Query, "dir", tempDir)
 
 	// Call the Python script
-	cmd := exec.Command("python3", "./utils/yt_downloader.py")
+	cmd := command(ctx, "python3", "./utils/yt_downloader.py")
 	
 	// Set environment variables for the script
-	cmd.Env = append(os.Environ(),
+	cmd.Env = append(cmd.Env,
 		fmt.Sprintf("SONG_QUERY=%s", req.Query),
 		fmt.Sprintf("OUTPUT_DIR=%s", tempDir),
 	)
 
-	output, err := cmd.CombinedOutput()
+	output, err := api.Scripts.Run(ctx, ScriptDownload, cmd)
 	if err != nil {
-		log.Printf("Download script failed: %v", err)
-		log.Printf("Script output: %s", string(output))
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Erro ao baixar música",
-			"detail": string(output),
-		})
+		slog.ErrorContext(ctx, "Script de download falhou", "error", err, "output", logging.Tail(output, scriptOutputLogLimit))
+		return apperror.Wrap(err, apperror.CodeDownloadFailed)
 	}
 
-	log.Printf("Download script output: %s", string(output))
+	slog.DebugContext(ctx, "Saída do script de download", "output", logging.Tail(output, scriptOutputLogLimit))
 
 	// Find the downloaded file
 	files, err := filepath.Glob(filepath.Join(tempDir, "*.mp3"))
 	if err != nil || len(files) == 0 {
-		log.Printf("No MP3 files found in directory: %s", tempDir)
 		// List all files in directory for debugging
+		var names []string
 		dirFiles, _ := os.ReadDir(tempDir)
-		log.Printf("Files in directory:")
 		for _, file := range dirFiles {
-			log.Printf("  - %s", file.Name())
+			names = append(names, file.Name())
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Nenhum arquivo foi baixado",
-		})
+		slog.ErrorContext(ctx, "Nenhum MP3 no diretório do download", "dir", tempDir, "files", names)
+		return apperror.Wrap(err, apperror.CodeDownloadFailed)
 	}
 
 	filePath := files[0]
 	
 	// Check if file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Arquivo não foi criado",
-		})
+		return err
 	}
 
 	// Get file info
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Erro ao ler arquivo",
-		})
+		return err
 	}
 
+	api.consumeQuota(c, config.QuotaDownloadMinutes, downloadMinutes(ctx, output, fileInfo.Size()))
+
+	api.saveDownload(ctx, user.UID, filePath)
+
 	// Set headers for download
 	fileName := filepath.Base(filePath)
 	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
 	c.Set("Content-Type", "audio/mpeg")
 	c.Set("Content-Length", fmt.Sprintf("%d", fileInfo.Size()))
 
-	// Send file and clean up after
-	defer func() {
-		go func() {
-			time.Sleep(5 * time.Second) // Wait a bit before cleanup
-			os.RemoveAll(tempDir)
-			log.Printf("Cleaned up temp directory: %s", tempDir)
-		}()
-	}()
-
 	return c.SendFile(filePath)
 }
 
+// downloadMinutes devolve a duração do download em minutos. Se o script não
+// informar uma duração válida, ela é estimada pelo tamanho do MP3, para que o
+// download não saia de graça.
+func downloadMinutes(ctx context.Context, output []byte, size int64) float64 {
+	if match := downloadDurationPattern.FindSubmatch(output); match != nil {
+		seconds, err := strconv.ParseFloat(string(match[1]), 64)
+		if err == nil && seconds > 0 {
+			return seconds / 60
+		}
+	}
+	minutes := float64(size*8) / downloadBitrate / 60
+	slog.WarnContext(ctx, "Script de download não informou a duração; estimando pelo tamanho do arquivo", "size", size, "minutes", minutes)
+	return minutes
+}
+
+// saveDownload guarda uma cópia do MP3 na pasta do usuário no bucket, para
+// que ela entre no export e saia com a exclusão da conta. Falhar aqui não
+// impede o download.
+func (api *API) saveDownload(ctx context.Context, userUID, filePath string) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		slog.WarnContext(ctx, "Erro ao abrir o MP3 para guardar no bucket", "error", err)
+		return
+	}
+	defer file.Close()
+
+	name := path.Join("downloads", filepath.Base(filePath))
+	if err := api.AccountService.SaveAudio(ctx, userUID, name, file); err != nil {
+		slog.WarnContext(ctx, "Erro ao guardar o MP3 no bucket", "object", name, "error", err)
+	}
+}
+
 // SearchSongHandler handles song search requests from YouTube
 func (api *API) SearchSongHandler(c *fiber.Ctx) error {
 	// Check authentication
 	_, exists := GetUserFromContext(c)
 	if !exists {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Usuário não encontrado",
-		})
+		return apperror.New(apperror.CodeUnauthorized)
 	}
 
 	var req SearchRequest
 	
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Query é obrigatória",
-		})
-	}
-
-	if req.Query == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Query não pode estar vazia",
-		})
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
 	}
 
 	// Set default max results if not provided
@@ -168,36 +182,33 @@ func (api *API) SearchSongHandler(c *fiber.Ctx) error {
 		req.MaxResults = 3
 	}
 
-	log.Printf("Searching songs with query: %s", req.Query)
+	ctx, done := api.jobContext(c.UserContext(), 0)
+	defer done()
+
+	slog.InfoContext(ctx, "Buscando músicas", "query", req.Query)
 
 	// Call the Python script for search only
-	cmd := exec.Command("python3", "./utils/yt_downloader.py", "--search-only")
+	cmd := command(ctx, "python3", "./utils/yt_downloader.py", "--search-only")
 	
 	// Set environment variables for the script
-	cmd.Env = append(os.Environ(),
+	cmd.Env = append(cmd.Env,
 		fmt.Sprintf("SONG_QUERY=%s", req.Query),
 		fmt.Sprintf("MAX_RESULTS=%d", req.MaxResults),
 	)
 
-	output, err := cmd.CombinedOutput()
+	output, err := api.Scripts.Run(ctx, ScriptSearch, cmd)
 	if err != nil {
-		log.Printf("Search script failed: %v", err)
-		log.Printf("Script output: %s", string(output))
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Erro ao buscar música",
-			"detail": string(output),
-		})
+		slog.ErrorContext(ctx, "Script de busca falhou", "error", err, "output", logging.Tail(output, scriptOutputLogLimit))
+		return apperror.Wrap(err, apperror.CodeSearchFailed)
 	}
 
-	log.Printf("Search script output: %s", string(output))
+	slog.DebugContext(ctx, "Saída do script de busca", "output", logging.Tail(output, scriptOutputLogLimit))
 
 	// Parse the JSON output from the Python script
 	var results []SearchResult
 	if err := json.Unmarshal(output, &results); err != nil {
-		log.Printf("Error parsing search results: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Erro ao processar resultados da busca",
-		})
+		slog.ErrorContext(ctx, "Resultado da busca inválido", "error", err)
+		return apperror.Wrap(err, apperror.CodeSearchFailed)
 	}
 
 	return c.JSON(fiber.Map{
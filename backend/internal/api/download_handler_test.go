@@ -0,0 +1,225 @@
+package api_test
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/josevitorrodriguess/any-song/backend/internal/api"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apitest"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+	"github.com/josevitorrodriguess/any-song/backend/internal/config"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+)
+
+func searchRequest(h *apitest.Harness, body map[string]any) apitest.Request {
+	return apitest.Request{Method: http.MethodPost, Path: "/search-song", Token: signedIn(h, "alice"), Body: body}
+}
+
+func TestSearchSongHandler(t *testing.T) {
+	runHandlerCases(t, []handlerCase{
+		{
+			name: "repassa a busca ao script e devolve os resultados",
+			setup: func(h *apitest.Harness) apitest.Request {
+				h.Scripts.Respond(api.ScriptSearch, `[{"title":"Garota de Ipanema","artist":"Tom Jobim","duration":320}]`)
+				return searchRequest(h, map[string]any{"query": "garota de ipanema"})
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				var body struct {
+					Success bool               `json:"success"`
+					Results []api.SearchResult `json:"results"`
+				}
+				resp.JSON(t, &body)
+				if !body.Success || len(body.Results) != 1 || body.Results[0].Title != "Garota de Ipanema" {
+					t.Errorf("resposta = %+v", body)
+				}
+				calls := h.Scripts.Calls(api.ScriptSearch)
+				if len(calls) != 1 {
+					t.Fatalf("chamadas ao script = %d, esperado 1", len(calls))
+				}
+				if query := apitest.Env(calls[0], "SONG_QUERY"); query != "garota de ipanema" {
+					t.Errorf("SONG_QUERY = %q", query)
+				}
+				if maxResults := apitest.Env(calls[0], "MAX_RESULTS"); maxResults != "3" {
+					t.Errorf("MAX_RESULTS = %q, esperado o padrão 3", maxResults)
+				}
+			},
+		},
+		{
+			name: "max_results do pedido vai para o script",
+			setup: func(h *apitest.Harness) apitest.Request {
+				h.Scripts.Respond(api.ScriptSearch, `[]`)
+				return searchRequest(h, map[string]any{"query": "bossa nova", "max_results": 10})
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				if maxResults := apitest.Env(h.Scripts.Calls(api.ScriptSearch)[0], "MAX_RESULTS"); maxResults != "10" {
+					t.Errorf("MAX_RESULTS = %q", maxResults)
+				}
+			},
+		},
+		{
+			name: "script falha",
+			setup: func(h *apitest.Harness) apitest.Request {
+				h.Scripts.Handle(api.ScriptSearch, func(cmd *exec.Cmd) ([]byte, error) {
+					return []byte("Traceback: yt-dlp quebrou"), errors.New("exit status 1")
+				})
+				return searchRequest(h, map[string]any{"query": "bossa nova"})
+			},
+			status: http.StatusInternalServerError,
+			code:   apperror.CodeSearchFailed,
+		},
+		{
+			name: "script devolve algo que não é JSON",
+			setup: func(h *apitest.Harness) apitest.Request {
+				h.Scripts.Respond(api.ScriptSearch, "nada encontrado")
+				return searchRequest(h, map[string]any{"query": "bossa nova"})
+			},
+			status: http.StatusInternalServerError,
+			code:   apperror.CodeSearchFailed,
+		},
+		{
+			name: "busca em branco não chama o script",
+			setup: func(h *apitest.Harness) apitest.Request {
+				return searchRequest(h, map[string]any{"query": "  "})
+			},
+			status: http.StatusUnprocessableEntity,
+			code:   apperror.CodeValidationFailed,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				if calls := h.Scripts.Calls(api.ScriptSearch); len(calls) != 0 {
+					t.Errorf("chamadas ao script = %d, esperado 0", len(calls))
+				}
+			},
+		},
+		{
+			name: "busca sem login",
+			setup: func(h *apitest.Harness) apitest.Request {
+				return apitest.Request{Method: http.MethodPost, Path: "/search-song", Body: map[string]any{"query": "bossa nova"}}
+			},
+			status: http.StatusUnauthorized,
+			code:   apperror.CodeUnauthorized,
+		},
+	})
+}
+
+// fakeDownload faz o papel do yt_downloader.py: grava um MP3 de size bytes e
+// imprime output.
+func fakeDownload(output string, size int) apitest.ScriptFunc {
+	return func(cmd *exec.Cmd) ([]byte, error) {
+		path := filepath.Join(apitest.Env(cmd, "OUTPUT_DIR"), "musica.mp3")
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			return nil, err
+		}
+		return []byte(output), nil
+	}
+}
+
+// expectDownloadMinutes confere quanto o download descontou da cota.
+func expectDownloadMinutes(want float64) func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+	return func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+		used, err := h.API.RateLimitService.QuotaUsage(t.Context(), "alice", config.QuotaDownloadMinutes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(used-want) > 1e-9 {
+			t.Errorf("cota consumida = %v minutos, esperado %v", used, want)
+		}
+	}
+}
+
+func TestDownloadSongHandler(t *testing.T) {
+	// 1.440.000 bytes a 192 kbps são 60 segundos
+	const oneMinuteOfMP3 = 1_440_000
+
+	runHandlerCases(t, []handlerCase{
+		{
+			name: "desconta a duração informada pelo script",
+			setup: func(h *apitest.Harness) apitest.Request {
+				h.Scripts.Handle(api.ScriptDownload, fakeDownload("⏱️ Duração: 213 segundos\n", 10))
+				return apitest.Request{Method: http.MethodPost, Path: "/download-song", Token: signedIn(h, "alice"), Body: map[string]any{"query": "bossa nova"}}
+			},
+			status: http.StatusOK,
+			check:  expectDownloadMinutes(213.0 / 60),
+		},
+		{
+			name: "duração fracionada",
+			setup: func(h *apitest.Harness) apitest.Request {
+				h.Scripts.Handle(api.ScriptDownload, fakeDownload("⏱️ Duração: 213.5 segundos\n", 10))
+				return apitest.Request{Method: http.MethodPost, Path: "/download-song", Token: signedIn(h, "alice"), Body: map[string]any{"query": "bossa nova"}}
+			},
+			status: http.StatusOK,
+			check:  expectDownloadMinutes(213.5 / 60),
+		},
+		{
+			name: "duração None é estimada pelo tamanho do arquivo",
+			setup: func(h *apitest.Harness) apitest.Request {
+				h.Scripts.Handle(api.ScriptDownload, fakeDownload("⏱️ Duração: None segundos\n", oneMinuteOfMP3))
+				return apitest.Request{Method: http.MethodPost, Path: "/download-song", Token: signedIn(h, "alice"), Body: map[string]any{"query": "bossa nova"}}
+			},
+			status: http.StatusOK,
+			check:  expectDownloadMinutes(1),
+		},
+		{
+			name: "sem linha de duração também estima pelo tamanho",
+			setup: func(h *apitest.Harness) apitest.Request {
+				h.Scripts.Handle(api.ScriptDownload, fakeDownload("🚀 Iniciando download...\n", 2*oneMinuteOfMP3))
+				return apitest.Request{Method: http.MethodPost, Path: "/download-song", Token: signedIn(h, "alice"), Body: map[string]any{"query": "bossa nova"}}
+			},
+			status: http.StatusOK,
+			check:  expectDownloadMinutes(2),
+		},
+	})
+}
+
+// withDownloadQuota limita a cota de download do papel user.
+func withDownloadQuota(minutes float64) apitest.Option {
+	return func(cfg *config.Config) {
+		cfg.RateLimit.Quotas[models.RoleUser][config.QuotaDownloadMinutes] = minutes
+	}
+}
+
+func TestDownloadQuotaIsReservedWhileRunning(t *testing.T) {
+	// A cota cabe exatamente numa reserva
+	h := apitest.New(t, withDownloadQuota(5))
+	download := apitest.Request{Method: http.MethodPost, Path: "/download-song", Token: signedIn(h, "alice"), Body: map[string]any{"query": "bossa nova"}}
+
+	concurrent := make(chan *apitest.Response, 1)
+	first := fakeDownload("⏱️ Duração: 60 segundos\n", 10)
+	h.Scripts.Handle(api.ScriptDownload, func(cmd *exec.Cmd) ([]byte, error) {
+		// Outro download do mesmo usuário enquanto este ainda roda
+		if len(h.Scripts.Calls(api.ScriptDownload)) == 1 {
+			concurrent <- h.Do(download)
+		}
+		return first(cmd)
+	})
+
+	if resp := h.Do(download); resp.Status != http.StatusOK {
+		t.Fatalf("primeiro download: status %d, corpo %s", resp.Status, resp.Body)
+	}
+	if resp := <-concurrent; resp.ErrorCode() != string(apperror.CodeQuotaExceeded) {
+		t.Errorf("download simultâneo: status %d, code %q; esperado %s", resp.Status, resp.ErrorCode(), apperror.CodeQuotaExceeded)
+	}
+	if calls := h.Scripts.Calls(api.ScriptDownload); len(calls) != 1 {
+		t.Errorf("chamadas ao script = %d, esperado 1", len(calls))
+	}
+	// Terminado o primeiro, só o consumo real fica na cota
+	expectDownloadMinutes(1)(t, h, nil)
+}
+
+func TestDownloadQuotaReservationIsReleasedOnFailure(t *testing.T) {
+	h := apitest.New(t)
+	h.Scripts.Handle(api.ScriptDownload, func(cmd *exec.Cmd) ([]byte, error) {
+		return []byte("ERROR: vídeo indisponível"), errors.New("exit status 1")
+	})
+
+	resp := h.Do(apitest.Request{Method: http.MethodPost, Path: "/download-song", Token: signedIn(h, "alice"), Body: map[string]any{"query": "bossa nova"}})
+	if resp.ErrorCode() != string(apperror.CodeDownloadFailed) {
+		t.Fatalf("status %d, code %q", resp.Status, resp.ErrorCode())
+	}
+	expectDownloadMinutes(0)(t, h, nil)
+}
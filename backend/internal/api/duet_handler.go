@@ -0,0 +1,122 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+)
+
+type SetLyricPartsRequest struct {
+	Parts []models.LyricPart `json:"parts" validate:"max=500"`
+}
+
+type StartDuetRequest struct {
+	PartnerUID string `json:"partner_uid" validate:"required,max=128"`
+	SongID     string `json:"song_id,omitempty" validate:"omitempty,uuid"`
+}
+
+type RecordPartScoreRequest struct {
+	Part  string  `json:"part" validate:"required,duetpart"`
+	Score float64 `json:"score" validate:"gte=0,lte=100"`
+}
+
+type SongParams struct {
+	ID string `params:"id" validate:"uuid"`
+}
+
+func (api *API) GetLyricPartsHandler(c *fiber.Ctx) error {
+	var params SongParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+	songID := uuid.MustParse(params.ID)
+
+	parts, err := api.DuetService.GetParts(c.UserContext(), songID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"song_id": songID,
+		"parts":   parts,
+	})
+}
+
+func (api *API) SetLyricPartsHandler(c *fiber.Ctx) error {
+	var params SongParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+	songID := uuid.MustParse(params.ID)
+
+	var req SetLyricPartsRequest
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
+	}
+
+	parts, err := api.DuetService.SetParts(c.UserContext(), songID, req.Parts)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"song_id": songID,
+		"parts":   parts,
+	})
+}
+
+func (api *API) StartDuetSessionHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var req StartDuetRequest
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
+	}
+
+	var songID *uuid.UUID
+	if req.SongID != "" {
+		parsed := uuid.MustParse(req.SongID)
+		songID = &parsed
+	}
+
+	session, err := api.DuetService.StartDuetSession(c.UserContext(), user.UID, req.PartnerUID, songID)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(session)
+}
+
+func (api *API) GetSessionHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var params SessionParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+	sessionID := uuid.MustParse(params.ID)
+
+	session, err := api.HistoryService.GetSession(c.UserContext(), user.UID, sessionID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(session)
+}
+
+func (api *API) RecordPartScoreHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var params SessionParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+	sessionID := uuid.MustParse(params.ID)
+
+	var req RecordPartScoreRequest
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
+	}
+
+	session, err := api.DuetService.RecordPartScore(c.UserContext(), sessionID, user.UID, req.Part, req.Score)
+	if err != nil {
+		return err
+	}
+	return c.JSON(session)
+}
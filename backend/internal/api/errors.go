@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+	"github.com/josevitorrodriguess/any-song/backend/internal/logging"
+	"github.com/josevitorrodriguess/any-song/backend/internal/service"
+)
+
+// ErrorResponse é o corpo de toda resposta de erro. Error traz a mensagem no
+// idioma do Accept-Language; Code é o que o cliente deve usar para decidir o
+// que fazer.
+type ErrorResponse struct {
+	Error     string        `json:"error"`
+	Code      apperror.Code `json:"code"`
+	Details   any           `json:"details,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+}
+
+// serviceErrors traduz os erros dos serviços para códigos da API. Handlers
+// podem devolver o erro do serviço como veio; o que não estiver aqui vira
+// internal_error.
+var serviceErrors = []struct {
+	err  error
+	code apperror.Code
+}{
+	{service.ErrUserNotFound, apperror.CodeUserNotFound},
+	{service.ErrUserInactive, apperror.CodeAccountDisabled},
+	{service.ErrTokenRevoked, apperror.CodeSessionRevoked},
+	{service.ErrInvalidAPIKey, apperror.CodeInvalidAPIKey},
+	{service.ErrRoleNotFound, apperror.CodeRoleNotFound},
+	{service.ErrArtistNotFound, apperror.CodeArtistNotFound},
+	{service.ErrSongNotFound, apperror.CodeSongNotFound},
+	{service.ErrAuthSessionNotFound, apperror.CodeAuthSessionNotFound},
+	{service.ErrAPIKeyNotFound, apperror.CodeAPIKeyNotFound},
+	{service.ErrInvalidAPIKeyScope, apperror.CodeInvalidAPIKeyScope},
+	{service.ErrDeletionNotScheduled, apperror.CodeDeletionNotScheduled},
+	{service.ErrPurgeUnavailable, apperror.CodePurgeUnavailable},
+	{service.ErrPlaylistNotFound, apperror.CodePlaylistNotFound},
+	{service.ErrPlaylistItemNotFound, apperror.CodePlaylistItemNotFound},
+	{service.ErrPlaylistForbidden, apperror.CodePlaylistForbidden},
+	{service.ErrPlaylistVersionConflict, apperror.CodePlaylistVersionConflict},
+	{service.ErrInvalidPlaylistOrder, apperror.CodeInvalidPlaylistOrder},
+	{service.ErrInvalidVisibility, apperror.CodeInvalidVisibility},
+	{service.ErrSessionNotFound, apperror.CodeSessionNotFound},
+	{service.ErrSessionAlreadyEnded, apperror.CodeSessionAlreadyEnded},
+	{service.ErrHistoryBusy, apperror.CodeHistoryBusy},
+	{service.ErrPartyRoomNotFound, apperror.CodePartyRoomNotFound},
+	{service.ErrPartyNotHost, apperror.CodePartyNotHost},
+	{service.ErrPartyEmptyQueue, apperror.CodePartyEmptyQueue},
+	{service.ErrPartyNothingToPlay, apperror.CodePartyNothingToPlay},
+	{service.ErrPartyRoomBusy, apperror.CodePartyRoomBusy},
+	{service.ErrInvalidLyricPart, apperror.CodeInvalidLyricPart},
+	{service.ErrPartnerNotFound, apperror.CodePartnerNotFound},
+	{service.ErrNotSessionMember, apperror.CodeNotSessionMember},
+	{service.ErrPartNotInSession, apperror.CodePartNotInSession},
+	{service.ErrSessionNotDuet, apperror.CodeSessionNotDuet},
+	{service.ErrDuetWithYourself, apperror.CodeDuetWithYourself},
+}
+
+// ErrorHandler é o ErrorHandler do Fiber: todo erro devolvido por handlers e
+// middlewares sai no formato de ErrorResponse. Erros 5xx são logados com a
+// causa, que nunca vai para o cliente.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	appErr := resolveError(err)
+	lang := apperror.NegotiateLanguage(c.Get(fiber.HeaderAcceptLanguage))
+
+	if appErr.Status >= fiber.StatusInternalServerError {
+		slog.ErrorContext(c.UserContext(), "Erro ao processar requisição",
+			"method", c.Method(),
+			"path", c.Path(),
+			"code", appErr.Code,
+			"error", err,
+		)
+	}
+
+	details := appErr.Details
+	if localizer, ok := details.(apperror.Localizer); ok {
+		details = localizer.Localize(lang)
+	}
+
+	c.Set(fiber.HeaderContentLanguage, string(lang))
+	return c.Status(appErr.Status).JSON(ErrorResponse{
+		Error:     apperror.Message(appErr.Code, lang),
+		Code:      appErr.Code,
+		Details:   details,
+		RequestID: logging.RequestID(c.UserContext()),
+	})
+}
+
+// resolveError acha o *apperror.Error que representa err: o próprio, o
+// equivalente a um *fiber.Error, o de um erro conhecido dos serviços ou, por
+// fim, internal_error.
+func resolveError(err error) *apperror.Error {
+	if appErr, ok := apperror.From(err); ok {
+		return appErr
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return apperror.FromStatus(fiberErr.Code)
+	}
+	for _, known := range serviceErrors {
+		if errors.Is(err, known.err) {
+			return apperror.Wrap(err, known.code)
+		}
+	}
+	return apperror.Internal(err)
+}
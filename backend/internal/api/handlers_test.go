@@ -0,0 +1,63 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/josevitorrodriguess/any-song/backend/internal/apitest"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"github.com/josevitorrodriguess/any-song/backend/internal/repository"
+)
+
+// handlerCase é um caso dos testes de handler: setup prepara uma API nova e
+// devolve a requisição; check roda depois das asserções de status e código.
+type handlerCase struct {
+	name   string
+	setup  func(h *apitest.Harness) apitest.Request
+	status int
+	code   apperror.Code
+	check  func(t *testing.T, h *apitest.Harness, resp *apitest.Response)
+}
+
+func runHandlerCases(t *testing.T, cases []handlerCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := apitest.New(t)
+			resp := h.Do(tc.setup(h))
+			if resp.Status != tc.status {
+				t.Fatalf("status = %d, esperado %d; corpo: %s", resp.Status, tc.status, resp.Body)
+			}
+			if code := resp.ErrorCode(); code != string(tc.code) {
+				t.Fatalf("code = %q, esperado %q; corpo: %s", code, tc.code, resp.Body)
+			}
+			if tc.check != nil {
+				tc.check(t, h, resp)
+			}
+		})
+	}
+}
+
+// signedIn cria o usuário com os papéis dados e devolve o token dele.
+func signedIn(h *apitest.Harness, uid string, roles ...string) string {
+	user := h.SeedUser(models.User{FirebaseUID: uid})
+	for _, role := range roles {
+		h.GrantRole(uid, role)
+	}
+	return h.Token(user)
+}
+
+// auditActions lista as ações gravadas no audit log, da mais antiga para a
+// mais recente.
+func auditActions(t *testing.T, h *apitest.Harness) []string {
+	t.Helper()
+	entries, _, err := h.Store.Audit().Query(t.Context(), repository.AuditFilter{}, 0, 0)
+	if err != nil {
+		t.Fatalf("consultar audit log: %v", err)
+	}
+	actions := make([]string, len(entries))
+	for i, entry := range entries {
+		actions[len(entries)-1-i] = entry.Action
+	}
+	return actions
+}
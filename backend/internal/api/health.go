@@ -0,0 +1,256 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/josevitorrodriguess/any-song/backend/internal/storage/postgres"
+)
+
+const (
+	// readinessCacheTTL evita que cada probe do orquestrador bata de novo no
+	// Postgres, no Redis e no GCS.
+	readinessCacheTTL = 5 * time.Second
+	// readinessCheckTimeout limita cada verificação; elas rodam em paralelo.
+	readinessCheckTimeout = 3 * time.Second
+	// firebaseKeysURL é de onde o Firebase Auth baixa as chaves públicas para
+	// validar os tokens.
+	firebaseKeysURL = "https://www.googleapis.com/robot/v1/metadata/x509/securetoken@system.gserviceaccount.com"
+)
+
+// pythonModules são os módulos importados pelos scripts chamados nas rotas de
+// busca, download, letra e transcrição.
+var pythonModules = []string{"yt_dlp", "musicxmatch_api", "faster_whisper"}
+
+const (
+	CheckOK       = "ok"
+	CheckDown     = "down"
+	CheckDisabled = "disabled"
+)
+
+type CheckResult struct {
+	Status string `json:"status"`
+	// Required indica se a falha tira a instância do balanceamento. Só o
+	// Postgres é obrigatório; o resto deixa a API em modo degradado.
+	Required  bool    `json:"required"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type ReadinessReport struct {
+	// Status é "ok", "degraded" (algum opcional fora) ou "unavailable".
+	Status    string                 `json:"status"`
+	CheckedAt time.Time              `json:"checked_at"`
+	Checks    map[string]CheckResult `json:"checks"`
+}
+
+// readinessCache guarda o último relatório. O mutex também serializa as
+// verificações: probes simultâneos esperam a rodada em andamento.
+type readinessCache struct {
+	mu     sync.Mutex
+	report *ReadinessReport
+}
+
+type readinessCheck struct {
+	name     string
+	required bool
+	// capability, quando preenchida, pula a verificação se o subsistema foi
+	// desligado ou não subiu.
+	capability string
+	// disabled, quando preenchido, é o motivo de a verificação não se aplicar.
+	disabled string
+	run      func(ctx context.Context) error
+}
+
+// HealthHandler responde 200 mesmo em modo degradado e lista quais
+// subsistemas estão de pé.
+func (api *API) HealthHandler(c *fiber.Ctx) error {
+	status := "ok"
+	if api.Capabilities.Degraded() {
+		status = "degraded"
+	}
+	return c.JSON(fiber.Map{
+		"status":       status,
+		"capabilities": api.Capabilities,
+	})
+}
+
+// LivezHandler só diz que o processo está respondendo; não consulta
+// dependências, para o orquestrador não reiniciar a API por causa delas.
+func (api *API) LivezHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// ReadyzHandler verifica as dependências e responde 503 quando uma
+// obrigatória está fora. O resultado fica em cache por readinessCacheTTL.
+func (api *API) ReadyzHandler(c *fiber.Ctx) error {
+	// O relatório é compartilhado entre probes, então não depende do
+	// cancelamento desta requisição.
+	report := api.readinessReport(api.lifecycle.ctx)
+	status := fiber.StatusOK
+	if report.Status == "unavailable" {
+		status = fiber.StatusServiceUnavailable
+	}
+	return c.Status(status).JSON(report)
+}
+
+func (api *API) readinessReport(ctx context.Context) *ReadinessReport {
+	api.readiness.mu.Lock()
+	defer api.readiness.mu.Unlock()
+
+	if cached := api.readiness.report; cached != nil && time.Since(cached.CheckedAt) < readinessCacheTTL {
+		return cached
+	}
+
+	checks := api.readinessChecks()
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check readinessCheck) {
+			defer wg.Done()
+			results[i] = api.runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := &ReadinessReport{
+		Status:    "ok",
+		CheckedAt: time.Now(),
+		Checks:    make(map[string]CheckResult, len(checks)),
+	}
+	for i, check := range checks {
+		result := results[i]
+		report.Checks[check.name] = result
+		if result.Status != CheckDown {
+			continue
+		}
+		if result.Required {
+			report.Status = "unavailable"
+		} else if report.Status == "ok" {
+			report.Status = "degraded"
+		}
+	}
+	api.readiness.report = report
+	return report
+}
+
+func (api *API) runCheck(ctx context.Context, check readinessCheck) CheckResult {
+	result := CheckResult{Required: check.required}
+	if check.disabled != "" {
+		result.Status = CheckDisabled
+		result.Error = check.disabled
+		return result
+	}
+	if check.capability != "" && !api.Capabilities.Available(check.capability) {
+		capability := api.Capabilities[check.capability]
+		result.Status = CheckDown
+		if !capability.Enabled {
+			result.Status = CheckDisabled
+		}
+		result.Error = capability.Reason
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.run(ctx)
+	result.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("sem resposta em %s", readinessCheckTimeout)
+		}
+		result.Status = CheckDown
+		result.Error = err.Error()
+		return result
+	}
+	result.Status = CheckOK
+	return result
+}
+
+func (api *API) readinessChecks() []readinessCheck {
+	firebaseDisabled := ""
+	if api.LocalIssuer != nil {
+		firebaseDisabled = "não usado com AUTH_PROVIDER=local"
+	}
+
+	return []readinessCheck{
+		{
+			name:     "postgres",
+			required: true,
+			run: func(ctx context.Context) error {
+				return postgres.TestConnection(ctx, api.DB)
+			},
+		},
+		{
+			name:       "redis",
+			capability: CapabilityCache,
+			run: func(ctx context.Context) error {
+				return api.Redis.Ping(ctx).Err()
+			},
+		},
+		{
+			name:       "storage",
+			capability: CapabilityStorage,
+			run: func(ctx context.Context) error {
+				return api.Storage.Check(ctx)
+			},
+		},
+		{
+			name:       "firebase",
+			capability: CapabilityAuth,
+			disabled:   firebaseDisabled,
+			run:        checkFirebase,
+		},
+		{
+			name: "python",
+			run:  checkPython,
+		},
+	}
+}
+
+// checkFirebase confere se as chaves públicas do Firebase Auth estão
+// acessíveis.
+func checkFirebase(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, firebaseKeysURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("resposta inesperada: %s", resp.Status)
+	}
+	return nil
+}
+
+// checkPython confere o interpretador e os módulos dos scripts sem
+// importá-los (o faster_whisper demora para carregar).
+func checkPython(ctx context.Context) error {
+	script := `import importlib.util, sys
+missing = [m for m in sys.argv[1:] if importlib.util.find_spec(m) is None]
+print(", ".join(missing))
+sys.exit(1 if missing else 0)`
+	args := append([]string{"-c", script}, pythonModules...)
+	output, err := exec.CommandContext(ctx, "python3", args...).Output()
+	if err == nil {
+		return nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return fmt.Errorf("módulos ausentes: %s", strings.TrimSpace(string(output)))
+	}
+	return err
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+)
+
+const defaultPageSize = 20
+
+type RecordPlayRequest struct {
+	SongID          string     `json:"song_id" validate:"required,uuid"`
+	SessionID       string     `json:"session_id,omitempty" validate:"omitempty,uuid"`
+	DurationSeconds int        `json:"duration_seconds" validate:"gte=0,lte=86400"`
+	Score           *float64   `json:"score,omitempty" validate:"omitnil,gte=0,lte=100"`
+	PlayedAt        *time.Time `json:"played_at,omitempty"`
+}
+
+type FavoriteParams struct {
+	SongID string `params:"songId" validate:"uuid"`
+}
+
+type SessionParams struct {
+	ID string `params:"id" validate:"uuid"`
+}
+
+// PageQuery é a paginação comum às listagens (?page= e ?page_size=, no
+// máximo 100 itens por página).
+type PageQuery struct {
+	Page     int `query:"page" validate:"gte=0"`
+	PageSize int `query:"page_size" validate:"gte=0,lte=100"`
+}
+
+func (api *API) AddFavoriteHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var params FavoriteParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+	songID := uuid.MustParse(params.SongID)
+
+	if err := api.FavoriteService.AddFavorite(c.UserContext(), user.UID, songID); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Música favoritada com sucesso",
+	})
+}
+
+func (api *API) RemoveFavoriteHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var params FavoriteParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+	songID := uuid.MustParse(params.SongID)
+
+	if err := api.FavoriteService.RemoveFavorite(c.UserContext(), user.UID, songID); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"message": "Favorito removido com sucesso",
+	})
+}
+
+func (api *API) ListFavoritesHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+	page, pageSize, err := pagination(c)
+	if err != nil {
+		return validationFailed(err)
+	}
+
+	favorites, err := api.FavoriteService.ListFavorites(c.UserContext(), user.UID, page, pageSize)
+	if err != nil {
+		return err
+	}
+	return c.JSON(favorites)
+}
+
+// RecordPlayHandler só enfileira o play; a gravação acontece em lote, por
+// isso a resposta é 202.
+func (api *API) RecordPlayHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var req RecordPlayRequest
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
+	}
+
+	event := models.PlayEvent{
+		UserUID:         user.UID,
+		SongID:          uuid.MustParse(req.SongID),
+		DurationSeconds: req.DurationSeconds,
+		Score:           req.Score,
+	}
+	if req.SessionID != "" {
+		sessionID := uuid.MustParse(req.SessionID)
+		event.SessionID = &sessionID
+	}
+	if req.PlayedAt != nil && req.PlayedAt.Before(time.Now()) {
+		event.PlayedAt = req.PlayedAt.UTC()
+	}
+
+	if err := api.HistoryService.RecordPlay(c.UserContext(), event); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Play registrado",
+	})
+}
+
+func (api *API) ListPlayHistoryHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+	page, pageSize, err := pagination(c)
+	if err != nil {
+		return validationFailed(err)
+	}
+
+	plays, err := api.HistoryService.ListPlays(c.UserContext(), user.UID, page, pageSize)
+	if err != nil {
+		return err
+	}
+	return c.JSON(plays)
+}
+
+func (api *API) RecentlyPlayedHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+	page, pageSize, err := pagination(c)
+	if err != nil {
+		return validationFailed(err)
+	}
+
+	recent, err := api.HistoryService.RecentlyPlayed(c.UserContext(), user.UID, page, pageSize)
+	if err != nil {
+		return err
+	}
+	return c.JSON(recent)
+}
+
+func (api *API) StartSessionHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	session, err := api.HistoryService.StartSession(c.UserContext(), user.UID)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(session)
+}
+
+func (api *API) EndSessionHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var params SessionParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+	sessionID := uuid.MustParse(params.ID)
+
+	session, err := api.HistoryService.EndSession(c.UserContext(), user.UID, sessionID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(session)
+}
+
+func (api *API) ListSessionsHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+	page, pageSize, err := pagination(c)
+	if err != nil {
+		return validationFailed(err)
+	}
+
+	sessions, err := api.HistoryService.ListSessions(c.UserContext(), user.UID, page, pageSize)
+	if err != nil {
+		return err
+	}
+	return c.JSON(sessions)
+}
+
+// pagination lê ?page= e ?page_size=. Valores ausentes (ou zero) viram a
+// primeira página com defaultPageSize itens.
+func pagination(c *fiber.Ctx) (int, int, error) {
+	var query PageQuery
+	if err := bindQuery(c, &query); err != nil {
+		return 0, 0, err
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = defaultPageSize
+	}
+	return query.Page, query.PageSize, nil
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestIDPattern limita o X-Request-ID aceito do cliente; fora disso um
+// novo ID é gerado, para o valor não quebrar os logs.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// scriptOutputLogLimit é quanto da saída de um script vai para o log.
+const scriptOutputLogLimit = 2048
+
+// RequestIDMiddleware reaproveita o X-Request-ID recebido (ou gera um),
+// devolve no header da resposta e coloca no contexto da requisição: os logs
+// dos serviços e os subprocessos recebem o mesmo ID.
+func (api *API) RequestIDMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(fiber.HeaderXRequestID)
+		if !requestIDPattern.MatchString(id) {
+			id = uuid.NewString()
+		}
+		c.Set(fiber.HeaderXRequestID, id)
+		c.Locals("requestid", id)
+		trace.SpanFromContext(c.UserContext()).SetAttributes(attribute.String("request.id", id))
+		c.SetUserContext(logging.WithRequestID(c.UserContext(), id))
+		return c.Next()
+	}
+}
+
+// AccessLogMiddleware registra uma linha por requisição. Probes e /metrics só
+// aparecem em debug.
+func (api *API) AccessLogMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+		status := responseStatus(c, err)
+
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case c.Path() == "/livez" || c.Path() == "/readyz" || c.Path() == "/health" || c.Path() == "/metrics":
+			level = slog.LevelDebug
+		}
+		slog.LogAttrs(c.UserContext(), level, "Requisição HTTP",
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", c.IP()),
+		)
+		return err
+	}
+}
+
+// responseStatus é o status que o cliente vai receber. Quando o handler
+// devolve erro, quem escreve a resposta é o ErrorHandler, depois dos
+// middlewares.
+func responseStatus(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	return resolveError(err).Status
+}
@@ -4,17 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os/exec"
+	"log/slog"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+	"github.com/josevitorrodriguess/any-song/backend/internal/logging"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
 )
 
 type LyricsRequest struct {
-	MusicName string `json:"music_name" validate:"required"`
-	Timeout   int    `json:"timeout,omitempty"` // em segundos
+	MusicName string `json:"music_name" validate:"notblank,max=200"`
+	SongID    string `json:"song_id,omitempty" validate:"omitempty,uuid"` // se informado, inclui as partes do dueto
+	Timeout   int    `json:"timeout,omitempty" validate:"gte=0,lte=120"`  // em segundos
 }
 
 type LyricsResponse struct {
@@ -25,22 +29,19 @@ type LyricsResponse struct {
 	Success   bool   `json:"success"`
 	Error     string `json:"error,omitempty"`
 	Duration  string `json:"duration"`
+
+	Parts []models.LyricPart `json:"parts,omitempty"`
 }
 
 func (api *API) CatchLyricsHandler(c *fiber.Ctx) error {
 	var req LyricsRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
 	}
 
-	// Validar se o nome da música foi fornecido
-	if strings.TrimSpace(req.MusicName) == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "music_name is required",
-		})
+	var songID uuid.UUID
+	if req.SongID != "" {
+		songID = uuid.MustParse(req.SongID)
 	}
 
 	// Definir timeout (padrão: 30 segundos)
@@ -49,25 +50,30 @@ func (api *API) CatchLyricsHandler(c *fiber.Ctx) error {
 		timeout = time.Duration(req.Timeout) * time.Second
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	ctx, done := api.jobContext(c.UserContext(), timeout)
+	defer done()
 
 	start := time.Now()
 
 	// Executar o script Python
 	result, err := api.executeCatchLyricsScript(ctx, req.MusicName)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error":    "Failed to execute lyrics script",
-			"details":  err.Error(),
-			"duration": time.Since(start).String(),
-		})
+		return apperror.Wrap(err, apperror.CodeLyricsFailed)
 	}
 
 	result.Duration = time.Since(start).String()
 	
 	if !result.Success {
-		return c.Status(500).JSON(result)
+		slog.ErrorContext(ctx, "Script de letras falhou", "music_name", req.MusicName, "error", logging.Tail([]byte(result.Error), scriptOutputLogLimit))
+		return apperror.New(apperror.CodeLyricsFailed)
+	}
+
+	if songID != uuid.Nil {
+		parts, err := api.DuetService.GetParts(c.UserContext(), songID)
+		if err != nil {
+			return err
+		}
+		result.Parts = parts
 	}
 
 	return c.JSON(result)
@@ -119,9 +125,9 @@ except Exception as e:
 `, filepath.Join("backend", "utils"), musicName, musicName)
 
 	// Executar o código Python
-	cmd := exec.CommandContext(ctx, "python3", "-c", pythonCode)
+	cmd := command(ctx, "python3", "-c", pythonCode)
 	
-	output, err := cmd.CombinedOutput()
+	output, err := api.Scripts.Run(ctx, ScriptLyrics, cmd)
 	
 	if err != nil {
 		return &LyricsResponse{
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/josevitorrodriguess/any-song/backend/internal/metrics"
+	"github.com/josevitorrodriguess/any-song/backend/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Nomes dos scripts, passados ao ScriptRunner e usados no label "script" das
+// métricas de subprocesso.
+const (
+	ScriptDownload      = "yt_downloader"
+	ScriptSearch        = "yt_downloader_search"
+	ScriptLyrics        = "catch_lyrics"
+	ScriptTranscription = "cochichando"
+)
+
+// MetricsMiddleware conta as requisições e mede a latência pelo padrão da
+// rota. Caminhos sem rota entram todos como "unmatched".
+func (api *API) MetricsMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := responseStatus(c, err)
+		route := c.Route().Path
+		// Sem rota, c.Route() é o último middleware que rodou
+		if routeNotFound(err) {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTP(c.Method(), route, status, time.Since(start))
+		return err
+	}
+}
+
+// routeNotFound diz se err é o 404 que o Fiber devolve quando nenhuma rota
+// casa com o caminho. Um 404 de handler (playlist não encontrada, por
+// exemplo) vem como *apperror.Error.
+func routeNotFound(err error) bool {
+	var fiberErr *fiber.Error
+	return errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusNotFound
+}
+
+// MetricsHandler expõe as métricas no formato do Prometheus.
+func (api *API) MetricsHandler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.Handler())
+}
+
+// runScript executa cmd (criado com command a partir de ctx) dentro de um
+// span, repassa o trace ao script por TRACEPARENT/TRACESTATE e registra
+// duração e status de saída com o nome do script.
+func runScript(ctx context.Context, script string, cmd *exec.Cmd) ([]byte, error) {
+	spanCtx, span := tracing.Tracer().Start(ctx, "subprocess "+script,
+		trace.WithAttributes(attribute.String("subprocess.script", script)),
+	)
+	defer span.End()
+	cmd.Env = append(cmd.Env, tracing.Environ(spanCtx)...)
+
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+	status := exitStatus(ctx, err)
+	metrics.ObserveSubprocess(script, status, time.Since(start))
+
+	span.SetAttributes(attribute.String("subprocess.exit_status", status))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, status)
+	}
+	return output, err
+}
+
+func exitStatus(ctx context.Context, err error) string {
+	switch {
+	case err == nil:
+		return "0"
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "timeout"
+	case ctx.Err() != nil:
+		return "canceled"
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+		return strconv.Itoa(exitErr.ExitCode())
+	}
+	return "error"
+}
@@ -2,56 +2,215 @@ package api
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+	"github.com/josevitorrodriguess/any-song/backend/internal/auth"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"github.com/josevitorrodriguess/any-song/backend/internal/service"
+)
+
+const (
+	AuthMethodToken  = "token"
+	AuthMethodAPIKey = "api_key"
 )
 
 type UserInfo struct {
 	UID   string `json:"uid"`
 	Email string `json:"email"`
 	Name  string `json:"name"`
+	// AuthMethod diz se o principal veio de um ID token ou de uma API key.
+	AuthMethod string `json:"auth_method"`
+	// Scopes só é preenchido para API keys; tokens têm todos os escopos.
+	Scopes []string `json:"scopes,omitempty"`
+	// AuthTime identifica a sessão de login do token.
+	AuthTime time.Time `json:"-"`
+}
+
+// HasScope diz se o principal pode usar rotas do escopo informado.
+func (u UserInfo) HasScope(scope string) bool {
+	if u.AuthMethod != AuthMethodAPIKey {
+		return true
+	}
+	for _, s := range u.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
 }
 
+// AuthMiddleware aceita um ID token (Firebase ou local) ou uma API key, no
+// header X-API-Key ou como Bearer, e coloca o mesmo UserInfo no contexto.
 func (api *API) AuthMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		if !api.Capabilities.Available(CapabilityAuth) {
+			return api.capabilityUnavailable(CapabilityAuth)
+		}
+		if apiKey := c.Get("X-API-Key"); apiKey != "" {
+			return api.authenticateAPIKey(c, apiKey)
+		}
+
 		// Extrair token do header Authorization
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Token de autorização necessário",
-			})
+			return apperror.New(apperror.CodeUnauthorized)
 		}
 
 		// Remover "Bearer " do início do token
 		token := strings.Replace(authHeader, "Bearer ", "", 1)
 		if token == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Token inválido",
-			})
+			return apperror.New(apperror.CodeInvalidToken)
+		}
+		if service.IsAPIKey(token) {
+			return api.authenticateAPIKey(c, token)
 		}
 
-		// Verificar token com Firebase
-		decodedToken, err := api.Auth.VerifyIDToken(context.Background(), token)
+		decodedToken, userInfo, err := api.verifyToken(c.UserContext(), token)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Token inválido ou expirado",
-			})
+			return tokenError(err)
 		}
 
-		userInfo := UserInfo{
-			UID: decodedToken.UID,
+		if err := api.AuthSessionService.Touch(c.UserContext(), decodedToken, c.Get(fiber.HeaderUserAgent), c.IP()); err != nil {
+			slog.WarnContext(c.UserContext(), "Falha ao registrar sessão do usuário", "user_uid", userInfo.UID, "error", err)
 		}
-		if email, ok := decodedToken.Claims["email"].(string); ok {
-			userInfo.Email = email
+
+		api.setPrincipal(c, userInfo)
+
+		return c.Next()
+	}
+}
+
+func (api *API) authenticateAPIKey(c *fiber.Ctx, plaintext string) error {
+	key, err := api.APIKeyService.Authenticate(c.UserContext(), plaintext)
+	if err != nil {
+		return tokenError(err)
+	}
+	if err := api.AuthSessionService.CheckUser(c.UserContext(), key.UserUID); err != nil {
+		return tokenError(err)
+	}
+
+	user, err := api.UserService.GetUserByFirebaseUID(c.UserContext(), key.UserUID)
+	if errors.Is(err, service.ErrUserNotFound) {
+		return apperror.Wrap(err, apperror.CodeInvalidAPIKey)
+	}
+	if err != nil {
+		return err
+	}
+
+	api.setPrincipal(c, UserInfo{
+		UID:        user.FirebaseUID,
+		Email:      user.Email,
+		Name:       user.Name,
+		AuthMethod: AuthMethodAPIKey,
+		Scopes:     key.Scopes,
+	})
+	return c.Next()
+}
+
+// setPrincipal guarda o usuário autenticado no fiber.Ctx e, para o audit log,
+// também no contexto repassado aos serviços.
+func (api *API) setPrincipal(c *fiber.Ctx, user UserInfo) {
+	c.Locals("user", user)
+	c.SetUserContext(service.WithAuditActor(c.UserContext(), service.AuditActor{
+		UID:       user.UID,
+		RequestID: requestID(c),
+		IPAddress: c.IP(),
+	}))
+}
+
+// requestID devolve o ID gerado pelo middleware requestid.
+func requestID(c *fiber.Ctx) string {
+	id, _ := c.Locals("requestid").(string)
+	return id
+}
+
+// verifyToken valida o ID token com o verificador configurado (Firebase ou
+// local), recusa tokens revogados ou de contas desativadas e extrai o usuário
+// autenticado.
+func (api *API) verifyToken(ctx context.Context, token string) (*auth.Token, UserInfo, error) {
+	decodedToken, err := api.Verifier.VerifyIDToken(ctx, token)
+	if err != nil {
+		return nil, UserInfo{}, apperror.Wrap(err, apperror.CodeInvalidToken)
+	}
+	if err := api.AuthSessionService.CheckToken(ctx, decodedToken); err != nil {
+		return nil, UserInfo{}, err
+	}
+
+	return decodedToken, UserInfo{
+		UID:        decodedToken.UID,
+		Email:      decodedToken.Email(),
+		Name:       decodedToken.Name(),
+		AuthMethod: AuthMethodToken,
+		AuthTime:   decodedToken.AuthTime,
+	}, nil
+}
+
+// tokenError separa conta desativada, sessão revogada e API key inválida. O
+// resto (provedor ou Postgres fora do ar) volta como veio e vira erro interno,
+// para não dizer ao cliente que o token dele é ruim.
+func tokenError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrUserInactive):
+		return apperror.Wrap(err, apperror.CodeAccountDisabled)
+	case errors.Is(err, service.ErrTokenRevoked):
+		return apperror.Wrap(err, apperror.CodeSessionRevoked)
+	case errors.Is(err, service.ErrInvalidAPIKey):
+		return apperror.Wrap(err, apperror.CodeInvalidAPIKey)
+	}
+	return err
+}
+
+// RequireScope bloqueia API keys sem o escopo da rota. Tokens passam sempre.
+func (api *API) RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := GetUserFromContext(c)
+		if !ok || !user.HasScope(scope) {
+			return apperror.New(apperror.CodeAPIKeyScope)
 		}
-		if name, ok := decodedToken.Claims["name"].(string); ok {
-			userInfo.Name = name
+		return c.Next()
+	}
+}
+
+// RequireInteractive recusa API keys em rotas que mexem na própria conta ou
+// em outros usuários.
+func (api *API) RequireInteractive() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := GetUserFromContext(c)
+		if !ok || user.AuthMethod != AuthMethodToken {
+			return apperror.New(apperror.CodeAPIKeyNotAllowed)
 		}
+		return c.Next()
+	}
+}
 
-		c.Locals("user", userInfo)
+// RequirePermission bloqueia a rota para quem não tem a permissão em nenhum
+// dos seus papéis. Deve vir depois do AuthMiddleware.
+func (api *API) RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := GetUserFromContext(c)
+		if !ok {
+			return apperror.New(apperror.CodeUnauthorized)
+		}
+
+		allowed, err := api.RBACService.HasPermission(c.UserContext(), user.UID, permission)
+		if err != nil {
+			slog.ErrorContext(c.UserContext(), "Falha ao verificar permissão do usuário", "permission", permission, "user_uid", user.UID, "error", err)
+			return apperror.Internal(err)
+		}
+		if !allowed {
+			if err := api.AuditService.Record(c.UserContext(), models.AuditPermissionDeny, "permission", permission); err != nil {
+				slog.WarnContext(c.UserContext(), "Falha ao registrar permissão negada no audit log", "error", err)
+			}
+			return apperror.New(apperror.CodeForbidden)
+		}
 
 		return c.Next()
 	}
@@ -61,71 +220,169 @@ func (api *API) AdminRequiredMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		user, ok := GetUserFromContext(c)
 		if !ok {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Acesso negado. Falha na autenticação."})
+			return apperror.New(apperror.CodeUnauthorized)
 		}
 
-		adminListKey := "groups:admins:members"
-		var adminUIDs []string
-
-		// 1. Tenta buscar no CACHE
-		found, err := api.CacheService.Get(adminListKey, &adminUIDs)
+		isAdmin, err := api.RBACService.HasRole(c.UserContext(), user.UID, models.RoleAdmin)
 		if err != nil {
-			log.Printf("AVISO: Erro no cache de admins: %v. Buscando no DB.", err)
+			slog.ErrorContext(c.UserContext(), "Falha ao verificar papéis do usuário", "user_uid", user.UID, "error", err)
+			return apperror.Internal(err)
 		}
-
-		// 2. CACHE HIT
-		if found {
-			for _, adminUID := range adminUIDs {
-				if adminUID == user.UID {
-					return c.Next()
-				}
+		if !isAdmin {
+			if err := api.AuditService.Record(c.UserContext(), models.AuditPermissionDeny, "role", models.RoleAdmin); err != nil {
+				slog.WarnContext(c.UserContext(), "Falha ao registrar permissão negada no audit log", "error", err)
 			}
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Acesso negado. Requer permissão de administrador."})
+			return apperror.New(apperror.CodeAdminRequired)
 		}
 
-		// 3. CACHE MISS, busca no FIRESTORE
-		doc, err := api.Firestore.Collection("groups").Doc("admins").Get(c.Context())
-		if err != nil {
-			log.Printf("ERRO: Falha ao buscar grupo de admins no Firestore: %v", err)
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Acesso negado."})
-		}
+		return c.Next()
+	}
+}
 
-		// --- BLOCO CORRIGIDO ---
-		data := doc.Data()
-		if data == nil {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Acesso negado. Grupo de admins não encontrado."})
+// RateLimit limita a rota numa janela deslizante, contando separadamente por
+// usuário e por IP. Deve vir depois do AuthMiddleware. Se o Redis falhar (ou
+// estiver fora em modo degradado) a requisição passa, para não derrubar a
+// rota junto.
+func (api *API) RateLimit(name string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !api.Capabilities.Available(CapabilityCache) {
+			return c.Next()
 		}
+		user, _ := GetUserFromContext(c)
+		cfg := api.RateLimitConfig
 
-		membersData, keyExists := data["members"]
-		if !keyExists {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Acesso negado. Grupo de admins mal configurado."})
+		limits := map[string]int{
+			fmt.Sprintf("%s:ip:%s", name, c.IP()): cfg.IPRequests,
+		}
+		if user.UID != "" {
+			limits[fmt.Sprintf("%s:uid:%s", name, user.UID)] = cfg.UserRequests
 		}
-		// --- FIM DO BLOCO CORRIGIDO ---
 
-		isUserAdmin := false
-		// Converte o `interface{}` para um slice de `interface{}`
-		if membersSlice, ok := membersData.([]interface{}); ok {
-			// Itera sobre o slice para extrair os UIDs como strings
-			for _, member := range membersSlice {
-				if uid, ok := member.(string); ok {
-					adminUIDs = append(adminUIDs, uid)
-					if uid == user.UID {
-						isUserAdmin = true
-					}
-				}
+		var strictest *service.RateLimitResult
+		for key, limit := range limits {
+			result, err := api.RateLimitService.Allow(c.UserContext(), key, limit, cfg.Window.Duration)
+			if err != nil {
+				slog.WarnContext(c.UserContext(), "Rate limit indisponível", "key", key, "error", err)
+				continue
+			}
+			if strictest == nil || !result.Allowed || result.Remaining < strictest.Remaining {
+				strictest = result
+			}
+			if !result.Allowed {
+				break
 			}
 		}
+		if strictest == nil {
+			return c.Next()
+		}
+
+		resetSeconds := int(math.Ceil(strictest.Reset.Seconds()))
+		c.Set("RateLimit-Limit", strconv.Itoa(strictest.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(strictest.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(resetSeconds))
+		if !strictest.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(resetSeconds))
+			return apperror.New(apperror.CodeRateLimited).WithDetails(fiber.Map{
+				"retry_after": resetSeconds,
+			})
+		}
+		return c.Next()
+	}
+}
+
+// quotaReservationMinutes é quanto RequireQuota separa da cota antes de o
+// trabalho rodar, mais ou menos uma música.
+const quotaReservationMinutes = 5
 
-		// 4. Salva a lista recém-buscada no CACHE
-		api.CacheService.Set(adminListKey, adminUIDs, 15*time.Minute)
+// quotaReservationLocal guarda a reserva feita por RequireQuota para o
+// consumeQuota do handler.
+const quotaReservationLocal = "quotaReservation"
 
-		if isUserAdmin {
+type pendingQuota struct {
+	quota       string
+	reservation *service.QuotaReservation
+	settled     bool
+}
+
+// RequireQuota recusa a requisição quando o usuário já esgotou a cota diária.
+// O consumo só é conhecido no fim do processamento, então a rota reserva uma
+// estimativa ao entrar e o handler a troca pelo consumo real com
+// consumeQuota; sem a reserva, requisições simultâneas passariam todas pela
+// checagem. Se o handler não registrar consumo (por exemplo, porque falhou),
+// a reserva é devolvida. Sem Redis as cotas não são aplicadas.
+func (api *API) RequireQuota(quota string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !api.Capabilities.Available(CapabilityCache) {
 			return c.Next()
 		}
+		user, _ := GetUserFromContext(c)
+
+		roles, err := api.RBACService.RolesForUser(c.UserContext(), user.UID)
+		if err != nil {
+			slog.ErrorContext(c.UserContext(), "Falha ao buscar papéis do usuário", "user_uid", user.UID, "error", err)
+			return apperror.Internal(err)
+		}
+		limit := api.RateLimitConfig.QuotaFor(roles, quota)
+		if limit == 0 {
+			return c.Next()
+		}
+
+		reservation, usage, err := api.RateLimitService.ReserveQuota(c.UserContext(), user.UID, quota, quotaReservationMinutes, limit)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "Cota indisponível", "quota", quota, "user_uid", user.UID, "error", err)
+			return c.Next()
+		}
+
+		c.Set("X-Quota-Limit", strconv.FormatFloat(limit, 'f', -1, 64))
+		c.Set("X-Quota-Remaining", strconv.FormatFloat(math.Max(limit-usage, 0), 'f', 2, 64))
+		if reservation == nil {
+			retryAfter := int(math.Ceil(time.Until(service.QuotaReset(time.Now())).Seconds()))
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+			return apperror.New(apperror.CodeQuotaExceeded).WithDetails(fiber.Map{
+				"quota":       quota,
+				"limit":       limit,
+				"retry_after": retryAfter,
+			})
+		}
 
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Acesso negado. Requer permissão de administrador."})
+		pending := &pendingQuota{quota: quota, reservation: reservation}
+		c.Locals(quotaReservationLocal, pending)
+		defer func() {
+			if !pending.settled {
+				api.settleQuota(c.UserContext(), user.UID, pending, 0)
+			}
+		}()
+		return c.Next()
 	}
 }
+
+// consumeQuota registra o consumo depois que o trabalho terminou, no lugar da
+// reserva de RequireQuota. Uma falha aqui não invalida a resposta já
+// produzida.
+func (api *API) consumeQuota(c *fiber.Ctx, quota string, amount float64) {
+	user, _ := GetUserFromContext(c)
+	if pending, ok := c.Locals(quotaReservationLocal).(*pendingQuota); ok && pending.quota == quota && !pending.settled {
+		api.settleQuota(c.UserContext(), user.UID, pending, amount)
+		return
+	}
+	if amount <= 0 || !api.Capabilities.Available(CapabilityCache) {
+		return
+	}
+	if err := api.RateLimitService.ConsumeQuota(c.UserContext(), user.UID, quota, amount); err != nil {
+		slog.WarnContext(c.UserContext(), "Falha ao registrar consumo de cota", "quota", quota, "user_uid", user.UID, "error", err)
+	}
+}
+
+// settleQuota troca a reserva pelo consumo real. Roda sem o cancelamento da
+// requisição: uma reserva esquecida contaria contra o usuário até o fim do
+// dia.
+func (api *API) settleQuota(ctx context.Context, userUID string, pending *pendingQuota, amount float64) {
+	pending.settled = true
+	if err := api.RateLimitService.SettleQuota(context.WithoutCancel(ctx), pending.reservation, math.Max(amount, 0)); err != nil {
+		slog.WarnContext(ctx, "Falha ao acertar a reserva de cota", "quota", pending.quota, "user_uid", userUID, "error", err)
+	}
+}
+
 func GetUserFromContext(c *fiber.Ctx) (UserInfo, bool) {
 	user := c.Locals("user")
 	if user == nil {
@@ -0,0 +1,999 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+	"github.com/josevitorrodriguess/any-song/backend/internal/auth"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"github.com/josevitorrodriguess/any-song/backend/internal/openapi"
+	"github.com/josevitorrodriguess/any-song/backend/internal/service"
+)
+
+// swaggerUIVersion fixa a versão do Swagger UI carregado pelo /docs.
+const swaggerUIVersion = "5.17.14"
+
+// authMode diz quem pode chamar a rota.
+type authMode int
+
+const (
+	authNone authMode = iota
+	// authAny aceita ID token ou API key.
+	authAny
+	// authInteractive só aceita ID token (RequireInteractive).
+	authInteractive
+)
+
+// apiRoute descreve uma rota de SetupRoutes no documento OpenAPI. Params,
+// Query e Body são valores dos mesmos structs que os handlers decodificam;
+// Response é o valor devolvido ou um *openapi.Schema escrito à mão.
+type apiRoute struct {
+	Method  string
+	Path    string
+	Tag     string
+	Summary string
+	// Description complementa o resumo; escopo e permissão são acrescentados
+	// automaticamente.
+	Description string
+
+	Auth       authMode
+	Scope      string
+	Permission string
+	// Capability é a dependência exigida por RequireCapability.
+	Capability  string
+	RateLimited bool
+	Quota       bool
+	// DevOnly marca rotas que só existem com AUTH_PROVIDER=local.
+	DevOnly bool
+
+	Params any
+	Query  []any
+	Body   any
+	// BodyOptional indica que o corpo pode ser omitido.
+	BodyOptional bool
+
+	// Status é o status de sucesso; 200 quando vazio.
+	Status   int
+	Response any
+	// ContentType do sucesso; application/json quando vazio.
+	ContentType string
+	// Extra documenta respostas que não seguem o envelope de erro, como o
+	// 503 do /readyz.
+	Extra map[int]any
+
+	// Errors são os códigos específicos da rota. Os de validação, autenticação,
+	// escopo, limite e internal_error entram sozinhos.
+	Errors []apperror.Code
+}
+
+// OpenAPIDocument descreve as rotas registradas em SetupRoutes.
+func (api *API) OpenAPIDocument() *openapi.Document {
+	gen := openapi.NewGenerator(map[string][]string{
+		"visibility":  {models.PlaylistPrivate, models.PlaylistUnlisted, models.PlaylistPublic},
+		"duetpart":    {models.DuetPartA, models.DuetPartB, models.DuetPartBoth},
+		"apikeyscope": models.APIKeyScopes,
+	})
+
+	errorSchema := gen.Schema(ErrorResponse{})
+	codes := apperror.Codes()
+	codeEnum := make([]string, len(codes))
+	for i, code := range codes {
+		codeEnum[i] = string(code)
+	}
+	gen.Schemas()["ErrorResponse"].Properties["code"].Enum = codeEnum
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:   "Any Song API",
+			Version: "1.0.0",
+			Description: "API do Any Song. Erros seguem o envelope ErrorResponse: `error` traz a " +
+				"mensagem no idioma do Accept-Language (pt-BR ou en) e `code` é o valor estável " +
+				"para tratar no cliente. Toda resposta traz o header X-Request-ID.",
+		},
+		Tags:  openAPITags,
+		Paths: map[string]*openapi.PathItem{},
+		Components: openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"bearerAuth": {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "JWT",
+					Description:  "ID token do Firebase (ou do emissor local com AUTH_PROVIDER=local). API keys também são aceitas como Bearer.",
+				},
+				"apiKeyAuth": {
+					Type:        "apiKey",
+					In:          "header",
+					Name:        "X-API-Key",
+					Description: "API key criada em /me/api-keys, limitada aos escopos escolhidos.",
+				},
+			},
+		},
+	}
+
+	for _, route := range openAPIRoutes(gen) {
+		if route.DevOnly && !api.devRoutesEnabled() {
+			continue
+		}
+		path := openAPIPath(route.Path)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &openapi.PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(route.Method)] = route.operation(gen, errorSchema)
+	}
+	doc.Components.Schemas = gen.Schemas()
+	return doc
+}
+
+// OpenAPIHandler serve o documento em JSON. Ele é montado na primeira
+// requisição e reaproveitado nas seguintes.
+func (api *API) OpenAPIHandler() fiber.Handler {
+	var (
+		once sync.Once
+		spec []byte
+		err  error
+	)
+	return func(c *fiber.Ctx) error {
+		once.Do(func() {
+			spec, err = json.Marshal(api.OpenAPIDocument())
+		})
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(spec)
+	}
+}
+
+// DocsHandler serve o Swagger UI apontando para /openapi.json.
+func (api *API) DocsHandler(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.SendString(docsPage)
+}
+
+var docsPage = fmt.Sprintf(`<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+  <meta charset="utf-8">
+  <title>Any Song API</title>
+  <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/swagger-ui-dist@%[1]s/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@%[1]s/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui", persistAuthorization: true });
+  </script>
+</body>
+</html>
+`, swaggerUIVersion)
+
+var pathParamPattern = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// openAPIPath converte o caminho do Fiber ("/playlist/:id/") no do documento
+// ("/playlist/{id}").
+func openAPIPath(path string) string {
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return pathParamPattern.ReplaceAllString(path, "{$1}")
+}
+
+func (route apiRoute) operation(gen *openapi.Generator, errorSchema *openapi.Schema) *openapi.Operation {
+	op := &openapi.Operation{
+		Tags:        []string{route.Tag},
+		Summary:     route.Summary,
+		Description: route.description(),
+		OperationID: operationID(route.Method, route.Path),
+		Responses:   map[string]*openapi.Response{},
+	}
+
+	switch route.Auth {
+	case authAny:
+		op.Security = []openapi.SecurityRequirement{{"bearerAuth": {}}, {"apiKeyAuth": {}}}
+	case authInteractive:
+		op.Security = []openapi.SecurityRequirement{{"bearerAuth": {}}}
+	}
+
+	op.Parameters = gen.Parameters(route.Params, "path")
+	// Parâmetros de rota sem struct próprio (lidos com c.Params)
+	for _, match := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+		if !hasParameter(op.Parameters, match[1]) {
+			op.Parameters = append(op.Parameters, openapi.Parameter{
+				Name:     match[1],
+				In:       "path",
+				Required: true,
+				Schema:   &openapi.Schema{Type: "string"},
+			})
+		}
+	}
+	for _, query := range route.Query {
+		op.Parameters = append(op.Parameters, gen.Parameters(query, "query")...)
+	}
+
+	if route.Body != nil {
+		op.RequestBody = &openapi.RequestBody{
+			Required: !route.BodyOptional,
+			Content:  openapi.JSON(gen.Schema(route.Body)),
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	op.Responses[strconv.Itoa(status)] = successResponse(gen, status, route.Response, route.ContentType)
+	for extraStatus, response := range route.Extra {
+		op.Responses[strconv.Itoa(extraStatus)] = successResponse(gen, extraStatus, response, "")
+	}
+
+	for errorStatus, codes := range route.errorCodes() {
+		names := make([]string, len(codes))
+		for i, code := range codes {
+			names[i] = "`" + string(code) + "`"
+		}
+		op.Responses[strconv.Itoa(errorStatus)] = &openapi.Response{
+			Description: http.StatusText(errorStatus) + ". Códigos: " + strings.Join(names, ", ") + ".",
+			Content:     openapi.JSON(errorSchema),
+		}
+	}
+	return op
+}
+
+func (route apiRoute) description() string {
+	var parts []string
+	if route.Description != "" {
+		parts = append(parts, route.Description)
+	}
+	if route.Scope != "" {
+		parts = append(parts, "Escopo exigido de API keys: `"+route.Scope+"`.")
+	}
+	if route.Permission != "" {
+		parts = append(parts, "Permissão exigida: `"+route.Permission+"`.")
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// errorCodes junta os códigos que a rota pode devolver, agrupados por status.
+func (route apiRoute) errorCodes() map[int][]apperror.Code {
+	codes := append([]apperror.Code{}, route.Errors...)
+	if route.Params != nil || len(route.Query) > 0 || route.Body != nil {
+		codes = append(codes, apperror.CodeBadRequest, apperror.CodeValidationFailed)
+	}
+	if route.Auth != authNone {
+		codes = append(codes, apperror.CodeUnauthorized, apperror.CodeInvalidToken,
+			apperror.CodeSessionRevoked, apperror.CodeAccountDisabled, apperror.CodeCapabilityDisabled)
+	}
+	switch route.Auth {
+	case authAny:
+		codes = append(codes, apperror.CodeInvalidAPIKey)
+	case authInteractive:
+		codes = append(codes, apperror.CodeAPIKeyNotAllowed)
+	}
+	if route.Scope != "" {
+		codes = append(codes, apperror.CodeAPIKeyScope)
+	}
+	if route.Permission != "" {
+		codes = append(codes, apperror.CodeForbidden)
+	}
+	if route.Capability != "" {
+		codes = append(codes, apperror.CodeCapabilityDisabled)
+	}
+	if route.RateLimited {
+		codes = append(codes, apperror.CodeRateLimited)
+	}
+	if route.Quota {
+		codes = append(codes, apperror.CodeQuotaExceeded)
+	}
+	codes = append(codes, apperror.CodeInternal)
+
+	byStatus := map[int][]apperror.Code{}
+	seen := map[apperror.Code]bool{}
+	for _, code := range codes {
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		byStatus[code.Status()] = append(byStatus[code.Status()], code)
+	}
+	for _, list := range byStatus {
+		sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	}
+	return byStatus
+}
+
+func successResponse(gen *openapi.Generator, status int, response any, contentType string) *openapi.Response {
+	out := &openapi.Response{Description: http.StatusText(status)}
+	if response == nil {
+		return out
+	}
+	schema, ok := response.(*openapi.Schema)
+	if !ok {
+		schema = gen.Schema(response)
+	}
+	if contentType == "" {
+		contentType = fiber.MIMEApplicationJSON
+	}
+	out.Content = map[string]openapi.MediaType{contentType: {Schema: schema}}
+	return out
+}
+
+func hasParameter(params []openapi.Parameter, name string) bool {
+	for _, param := range params {
+		if param.In == "path" && param.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// operationID deriva um identificador estável do método e do caminho, como
+// "postPlaylistIdItems".
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == ':' || r == '-' || r == '.' || r == '_'
+	}) {
+		b.WriteString(strings.ToUpper(segment[:1]) + segment[1:])
+	}
+	return b.String()
+}
+
+var openAPITags = []openapi.Tag{
+	{Name: "Autenticação", Description: "Login, logout e tokens de desenvolvimento."},
+	{Name: "Conta", Description: "Perfil, sessões, API keys, exportação e exclusão da própria conta."},
+	{Name: "Usuários"},
+	{Name: "Artistas"},
+	{Name: "Admin", Description: "Gestão de usuários, papéis e audit log."},
+	{Name: "Playlists"},
+	{Name: "Favoritos"},
+	{Name: "Histórico", Description: "Plays, sessões de karaokê e duetos."},
+	{Name: "Duetos", Description: "Divisão das letras em partes."},
+	{Name: "Recomendações"},
+	{Name: "Party", Description: "Salas de karaokê em grupo."},
+	{Name: "Mídia", Description: "Busca, download, letras e transcrição."},
+	{Name: "Sistema", Description: "Saúde, métricas e documentação."},
+}
+
+// openAPIRoutes espelha SetupRoutes. O teste de drift compara as duas listas.
+func openAPIRoutes(gen *openapi.Generator) []apiRoute {
+	message := gen.Register("MessageResponse", gen.Schema(struct {
+		Message string `json:"message"`
+	}{}))
+	apiKeys := gen.Register("APIKeyList", gen.Schema(struct {
+		APIKeys []models.APIKey `json:"api_keys"`
+	}{}))
+	lyricParts := gen.Register("LyricParts", gen.Schema(struct {
+		SongID string             `json:"song_id"`
+		Parts  []models.LyricPart `json:"parts"`
+	}{}))
+	status := gen.Register("StatusResponse", gen.Schema(struct {
+		Status string `json:"status"`
+	}{}))
+	binary := &openapi.Schema{Type: "string", Format: "binary"}
+	// Os comandos e eventos do WebSocket não passam por nenhuma rota HTTP
+	gen.Schema(PartyCommand{})
+	gen.Schema(models.PartyEvent{})
+
+	return []apiRoute{
+		// Autenticação
+		{
+			Method: fiber.MethodPost, Path: "/dev/token", Tag: "Autenticação",
+			Summary:     "Emite um ID token local",
+			Description: "Só existe com AUTH_PROVIDER=local e ENVIRONMENT=development ou test. O token serve no /signin e como Bearer.",
+			DevOnly:     true,
+			Body:        auth.LocalIdentity{},
+			Response: struct {
+				IDToken   string    `json:"id_token"`
+				ExpiresAt time.Time `json:"expires_at"`
+			}{},
+		},
+		{
+			Method: fiber.MethodPost, Path: "/signin", Tag: "Autenticação",
+			Summary:    "Valida o ID token e cria ou atualiza o usuário",
+			Capability: CapabilityAuth,
+			Body:       SignInRequest{},
+			Response: struct {
+				Valid bool `json:"valid"`
+				User  struct {
+					UID     string `json:"uid"`
+					Email   string `json:"email"`
+					Name    string `json:"name"`
+					Picture string `json:"picture"`
+				} `json:"user"`
+			}{},
+			Errors: []apperror.Code{apperror.CodeInvalidToken, apperror.CodeEmailMissing, apperror.CodeAccountDisabled},
+		},
+		{
+			Method: fiber.MethodPost, Path: "/logout", Tag: "Autenticação",
+			Summary: "Revoga a sessão atual", Auth: authInteractive,
+			Response: message,
+		},
+
+		// Conta
+		{
+			Method: fiber.MethodGet, Path: "/me/", Tag: "Conta",
+			Summary: "Perfil do usuário autenticado", Auth: authInteractive,
+			Response: models.User{},
+			Errors:   []apperror.Code{apperror.CodeUserNotFound},
+		},
+		{
+			Method: fiber.MethodPatch, Path: "/me/", Tag: "Conta",
+			Summary: "Atualiza o próprio perfil", Auth: authInteractive,
+			Body:     UpdateProfileRequest{},
+			Response: models.User{},
+			Errors:   []apperror.Code{apperror.CodeUserNotFound},
+		},
+		{
+			Method: fiber.MethodDelete, Path: "/me/", Tag: "Conta",
+			Summary:     "Agenda a exclusão da conta",
+			Description: "A conta é desativada na hora e removida ao fim do período de carência.",
+			Auth:        authInteractive,
+			Status:      http.StatusAccepted,
+			Response:    models.AccountDeletion{},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/me/export", Tag: "Conta",
+			Summary: "Exporta os dados da conta em ZIP", Auth: authInteractive,
+			Capability: CapabilityStorage, RateLimited: true,
+			Response: binary, ContentType: "application/zip",
+		},
+		{
+			Method: fiber.MethodGet, Path: "/me/deletion", Tag: "Conta",
+			Summary: "Exclusão agendada da conta", Auth: authInteractive,
+			Response: models.AccountDeletion{},
+			Errors:   []apperror.Code{apperror.CodeDeletionNotScheduled},
+		},
+		{
+			Method: fiber.MethodDelete, Path: "/me/deletion", Tag: "Conta",
+			Summary: "Cancela a exclusão agendada", Auth: authInteractive,
+			Response: message,
+			Errors:   []apperror.Code{apperror.CodeDeletionNotScheduled},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/me/sessions", Tag: "Conta",
+			Summary: "Lista as sessões de login", Auth: authInteractive,
+			Response: struct {
+				Sessions []AuthSessionResponse `json:"sessions"`
+			}{},
+		},
+		{
+			Method: fiber.MethodDelete, Path: "/me/sessions", Tag: "Conta",
+			Summary: "Revoga todas as sessões, inclusive a atual", Auth: authInteractive,
+			Response: message,
+		},
+		{
+			Method: fiber.MethodDelete, Path: "/me/sessions/:id", Tag: "Conta",
+			Summary: "Revoga uma sessão", Auth: authInteractive,
+			Params:   SessionParams{},
+			Response: message,
+			Errors:   []apperror.Code{apperror.CodeAuthSessionNotFound},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/me/api-keys", Tag: "Conta",
+			Summary: "Lista as próprias API keys", Auth: authInteractive,
+			Response: apiKeys,
+		},
+		{
+			Method: fiber.MethodPost, Path: "/me/api-keys", Tag: "Conta",
+			Summary:     "Cria uma API key",
+			Description: "A chave em texto puro só aparece nesta resposta.",
+			Auth:        authInteractive,
+			Body:        CreateAPIKeyRequest{},
+			Status:      http.StatusCreated,
+			Response:    CreateAPIKeyResponse{},
+			Errors:      []apperror.Code{apperror.CodeInvalidAPIKeyScope},
+		},
+		{
+			Method: fiber.MethodDelete, Path: "/me/api-keys/:id", Tag: "Conta",
+			Summary: "Revoga uma API key", Auth: authInteractive,
+			Params:   APIKeyParams{},
+			Response: message,
+			Errors:   []apperror.Code{apperror.CodeAPIKeyNotFound},
+		},
+
+		// Usuários
+		{
+			Method: fiber.MethodGet, Path: "/user/:username", Tag: "Usuários",
+			Summary: "Busca um usuário pelo e-mail", Auth: authAny,
+			Response: models.User{},
+			Errors:   []apperror.Code{apperror.CodeUserNotFound},
+		},
+
+		// Artistas
+		{
+			Method: fiber.MethodPost, Path: "/artist/create", Tag: "Artistas",
+			Summary: "Cadastra um artista", Auth: authAny,
+			Scope: models.ScopeCatalogWrite, Permission: models.PermissionCatalogWrite,
+			Body:     CreateArtistRequest{},
+			Status:   http.StatusCreated,
+			Response: models.Artist{},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/artist/search", Tag: "Artistas",
+			Summary:  "Busca artistas pelo nome",
+			Query:    []any{SearchArtistsQuery{}},
+			Response: []models.Artist{},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/artist/id/:id", Tag: "Artistas",
+			Summary:  "Busca um artista pelo ID",
+			Params:   ArtistParams{},
+			Response: models.Artist{},
+			Errors:   []apperror.Code{apperror.CodeArtistNotFound},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/artist/", Tag: "Artistas",
+			Summary:  "Lista os artistas",
+			Response: []models.Artist{},
+		},
+		{
+			Method: fiber.MethodPut, Path: "/artist/update", Tag: "Artistas",
+			Summary: "Atualiza um artista", Auth: authAny,
+			Scope: models.ScopeCatalogWrite, Permission: models.PermissionCatalogWrite,
+			Body:     UpdateArtistRequest{},
+			Response: models.Artist{},
+			Errors:   []apperror.Code{apperror.CodeArtistNotFound},
+		},
+		{
+			Method: fiber.MethodDelete, Path: "/artist/delete/:id", Tag: "Artistas",
+			Summary: "Remove um artista", Auth: authAny,
+			Scope: models.ScopeCatalogWrite, Permission: models.PermissionCatalogWrite,
+			Params:   ArtistParams{},
+			Response: message,
+			Errors:   []apperror.Code{apperror.CodeArtistNotFound},
+		},
+
+		// Admin
+		{
+			Method: fiber.MethodGet, Path: "/admin/users/:firebaseUID", Tag: "Admin",
+			Summary: "Busca um usuário", Auth: authInteractive,
+			Permission: models.PermissionUsersManage,
+			Response:   models.User{},
+			Errors:     []apperror.Code{apperror.CodeUserNotFound},
+		},
+		{
+			Method: fiber.MethodPatch, Path: "/admin/users/:firebaseUID", Tag: "Admin",
+			Summary: "Atualiza um usuário", Auth: authInteractive,
+			Permission: models.PermissionUsersManage,
+			Body:       AdminUpdateUserRequest{},
+			Response:   models.User{},
+			Errors:     []apperror.Code{apperror.CodeUserNotFound},
+		},
+		{
+			Method: fiber.MethodDelete, Path: "/admin/users/:firebaseUID", Tag: "Admin",
+			Summary:    "Remove um usuário imediatamente",
+			Auth:       authInteractive,
+			Permission: models.PermissionUsersManage,
+			Capability: CapabilityStorage,
+			Response:   message,
+			Errors:     []apperror.Code{apperror.CodeUserNotFound, apperror.CodePurgeUnavailable},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/admin/users/:firebaseUID/api-keys", Tag: "Admin",
+			Summary: "Lista as API keys de um usuário", Auth: authInteractive,
+			Permission: models.PermissionUsersManage,
+			Response:   apiKeys,
+		},
+		{
+			Method: fiber.MethodPost, Path: "/admin/users/:firebaseUID/api-keys", Tag: "Admin",
+			Summary: "Cria uma API key para um usuário", Auth: authInteractive,
+			Permission: models.PermissionUsersManage,
+			Body:       CreateAPIKeyRequest{},
+			Status:     http.StatusCreated,
+			Response:   CreateAPIKeyResponse{},
+			Errors:     []apperror.Code{apperror.CodeInvalidAPIKeyScope, apperror.CodeUserNotFound},
+		},
+		{
+			Method: fiber.MethodDelete, Path: "/admin/users/:firebaseUID/api-keys/:id", Tag: "Admin",
+			Summary: "Revoga uma API key de um usuário", Auth: authInteractive,
+			Permission: models.PermissionUsersManage,
+			Params:     APIKeyParams{},
+			Response:   message,
+			Errors:     []apperror.Code{apperror.CodeAPIKeyNotFound},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/admin/audit", Tag: "Admin",
+			Summary: "Consulta o audit log", Auth: authInteractive,
+			Permission: models.PermissionAuditRead,
+			Query:      []any{AuditLogQuery{}, PageQuery{}},
+			Response:   service.Page[models.AuditLog]{},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/admin/roles", Tag: "Admin",
+			Summary: "Lista os papéis e permissões", Auth: authInteractive,
+			Permission: models.PermissionRolesManage,
+			Response:   []models.Role{},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/admin/users/:firebaseUID/roles", Tag: "Admin",
+			Summary: "Lista os papéis de um usuário", Auth: authInteractive,
+			Permission: models.PermissionRolesManage,
+			Response:   []models.UserRole{},
+		},
+		{
+			Method: fiber.MethodPost, Path: "/admin/users/:firebaseUID/roles", Tag: "Admin",
+			Summary: "Concede um papel", Auth: authInteractive,
+			Permission: models.PermissionRolesManage,
+			Body:       GrantRoleRequest{},
+			Status:     http.StatusCreated,
+			Response:   message,
+			Errors:     []apperror.Code{apperror.CodeRoleNotFound, apperror.CodeUserNotFound},
+		},
+		{
+			Method: fiber.MethodDelete, Path: "/admin/users/:firebaseUID/roles/:role", Tag: "Admin",
+			Summary: "Revoga um papel", Auth: authInteractive,
+			Permission: models.PermissionRolesManage,
+			Response:   message,
+			Errors:     []apperror.Code{apperror.CodeRoleNotFound},
+		},
+
+		// Playlists
+		{
+			Method: fiber.MethodPost, Path: "/playlist/", Tag: "Playlists",
+			Summary: "Cria uma playlist", Auth: authAny, Scope: models.ScopePlaylists,
+			Body:     CreatePlaylistRequest{},
+			Status:   http.StatusCreated,
+			Response: models.Playlist{},
+			Errors:   []apperror.Code{apperror.CodeInvalidVisibility},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/playlist/", Tag: "Playlists",
+			Summary: "Lista as próprias playlists", Auth: authAny, Scope: models.ScopePlaylists,
+			Response: []models.Playlist{},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/playlist/public", Tag: "Playlists",
+			Summary: "Busca playlists públicas", Auth: authAny, Scope: models.ScopePlaylists,
+			Query:    []any{PublicPlaylistsQuery{}},
+			Response: []models.Playlist{},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/playlist/:id", Tag: "Playlists",
+			Summary: "Busca uma playlist com os itens", Auth: authAny, Scope: models.ScopePlaylists,
+			Params:   PlaylistParams{},
+			Response: models.Playlist{},
+			Errors:   []apperror.Code{apperror.CodePlaylistNotFound, apperror.CodePlaylistForbidden},
+		},
+		{
+			Method: fiber.MethodPatch, Path: "/playlist/:id", Tag: "Playlists",
+			Summary:     "Atualiza uma playlist",
+			Description: "`version` precisa ser a versão atual da playlist; se mudou, a resposta é 409.",
+			Auth:        authAny, Scope: models.ScopePlaylists,
+			Params:   PlaylistParams{},
+			Body:     UpdatePlaylistRequest{},
+			Response: models.Playlist{},
+			Errors: []apperror.Code{apperror.CodePlaylistNotFound, apperror.CodePlaylistForbidden,
+				apperror.CodePlaylistVersionConflict, apperror.CodeInvalidVisibility},
+		},
+		{
+			Method: fiber.MethodDelete, Path: "/playlist/:id", Tag: "Playlists",
+			Summary: "Remove uma playlist", Auth: authAny, Scope: models.ScopePlaylists,
+			Params:   PlaylistParams{},
+			Response: message,
+			Errors:   []apperror.Code{apperror.CodePlaylistNotFound, apperror.CodePlaylistForbidden},
+		},
+		{
+			Method: fiber.MethodPost, Path: "/playlist/:id/items", Tag: "Playlists",
+			Summary: "Adiciona uma música à playlist", Auth: authAny, Scope: models.ScopePlaylists,
+			Params:   PlaylistParams{},
+			Body:     AddPlaylistItemRequest{},
+			Status:   http.StatusCreated,
+			Response: models.Playlist{},
+			Errors: []apperror.Code{apperror.CodePlaylistNotFound, apperror.CodePlaylistForbidden,
+				apperror.CodePlaylistVersionConflict, apperror.CodeSongNotFound},
+		},
+		{
+			Method: fiber.MethodDelete, Path: "/playlist/:id/items/:itemId", Tag: "Playlists",
+			Summary: "Remove um item da playlist", Auth: authAny, Scope: models.ScopePlaylists,
+			Params:   PlaylistItemParams{},
+			Query:    []any{RemovePlaylistItemQuery{}},
+			Response: models.Playlist{},
+			Errors: []apperror.Code{apperror.CodePlaylistNotFound, apperror.CodePlaylistItemNotFound,
+				apperror.CodePlaylistForbidden, apperror.CodePlaylistVersionConflict},
+		},
+		{
+			Method: fiber.MethodPut, Path: "/playlist/:id/items/:itemId/position", Tag: "Playlists",
+			Summary: "Move um item para outra posição", Auth: authAny, Scope: models.ScopePlaylists,
+			Params:   PlaylistItemParams{},
+			Body:     MovePlaylistItemRequest{},
+			Response: models.Playlist{},
+			Errors: []apperror.Code{apperror.CodePlaylistNotFound, apperror.CodePlaylistItemNotFound,
+				apperror.CodePlaylistForbidden, apperror.CodePlaylistVersionConflict},
+		},
+		{
+			Method: fiber.MethodPut, Path: "/playlist/:id/order", Tag: "Playlists",
+			Summary:     "Reordena todos os itens",
+			Description: "`item_ids` precisa trazer todos os itens da playlist, cada um uma vez.",
+			Auth:        authAny, Scope: models.ScopePlaylists,
+			Params:   PlaylistParams{},
+			Body:     ReorderPlaylistRequest{},
+			Response: models.Playlist{},
+			Errors: []apperror.Code{apperror.CodePlaylistNotFound, apperror.CodePlaylistForbidden,
+				apperror.CodePlaylistVersionConflict, apperror.CodeInvalidPlaylistOrder},
+		},
+		{
+			Method: fiber.MethodPost, Path: "/playlist/:id/duplicate", Tag: "Playlists",
+			Summary: "Duplica uma playlist", Auth: authAny, Scope: models.ScopePlaylists,
+			Params:       PlaylistParams{},
+			Body:         DuplicatePlaylistRequest{},
+			BodyOptional: true,
+			Status:       http.StatusCreated,
+			Response:     models.Playlist{},
+			Errors:       []apperror.Code{apperror.CodePlaylistNotFound, apperror.CodePlaylistForbidden},
+		},
+
+		// Favoritos
+		{
+			Method: fiber.MethodGet, Path: "/favorites/", Tag: "Favoritos",
+			Summary: "Lista as músicas favoritas", Auth: authAny, Scope: models.ScopeHistory,
+			Query:    []any{PageQuery{}},
+			Response: service.Page[models.Favorite]{},
+		},
+		{
+			Method: fiber.MethodPut, Path: "/favorites/:songId", Tag: "Favoritos",
+			Summary: "Favorita uma música", Auth: authAny, Scope: models.ScopeHistory,
+			Params:   FavoriteParams{},
+			Status:   http.StatusCreated,
+			Response: message,
+			Errors:   []apperror.Code{apperror.CodeSongNotFound},
+		},
+		{
+			Method: fiber.MethodDelete, Path: "/favorites/:songId", Tag: "Favoritos",
+			Summary: "Remove uma música dos favoritos", Auth: authAny, Scope: models.ScopeHistory,
+			Params:   FavoriteParams{},
+			Response: message,
+		},
+
+		// Histórico
+		{
+			Method: fiber.MethodGet, Path: "/history/", Tag: "Histórico",
+			Summary: "Lista os plays", Auth: authAny, Scope: models.ScopeHistory,
+			Query:    []any{PageQuery{}},
+			Response: service.Page[models.PlayEvent]{},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/history/recent", Tag: "Histórico",
+			Summary: "Músicas tocadas recentemente", Auth: authAny, Scope: models.ScopeHistory,
+			Query:    []any{PageQuery{}},
+			Response: service.Page[service.RecentlyPlayedSong]{},
+		},
+		{
+			Method: fiber.MethodPost, Path: "/history/plays", Tag: "Histórico",
+			Summary:     "Registra um play",
+			Description: "O play é gravado de forma assíncrona.",
+			Auth:        authAny, Scope: models.ScopeHistory,
+			Body:     RecordPlayRequest{},
+			Status:   http.StatusAccepted,
+			Response: message,
+			Errors:   []apperror.Code{apperror.CodeSongNotFound, apperror.CodeSessionNotFound, apperror.CodeHistoryBusy},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/history/sessions", Tag: "Histórico",
+			Summary: "Lista as sessões de karaokê", Auth: authAny, Scope: models.ScopeHistory,
+			Query:    []any{PageQuery{}},
+			Response: service.Page[models.KaraokeSession]{},
+		},
+		{
+			Method: fiber.MethodPost, Path: "/history/sessions", Tag: "Histórico",
+			Summary: "Inicia uma sessão de karaokê", Auth: authAny, Scope: models.ScopeHistory,
+			Status:   http.StatusCreated,
+			Response: models.KaraokeSession{},
+		},
+		{
+			Method: fiber.MethodPost, Path: "/history/sessions/duet", Tag: "Histórico",
+			Summary: "Inicia uma sessão de dueto", Auth: authAny, Scope: models.ScopeHistory,
+			Body:     StartDuetRequest{},
+			Status:   http.StatusCreated,
+			Response: models.KaraokeSession{},
+			Errors: []apperror.Code{apperror.CodePartnerNotFound, apperror.CodeDuetWithYourself,
+				apperror.CodeSongNotFound},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/history/sessions/:id", Tag: "Histórico",
+			Summary: "Busca uma sessão", Auth: authAny, Scope: models.ScopeHistory,
+			Params:   SessionParams{},
+			Response: models.KaraokeSession{},
+			Errors:   []apperror.Code{apperror.CodeSessionNotFound},
+		},
+		{
+			Method: fiber.MethodPost, Path: "/history/sessions/:id/end", Tag: "Histórico",
+			Summary: "Encerra uma sessão", Auth: authAny, Scope: models.ScopeHistory,
+			Params:   SessionParams{},
+			Response: models.KaraokeSession{},
+			Errors:   []apperror.Code{apperror.CodeSessionNotFound, apperror.CodeSessionAlreadyEnded},
+		},
+		{
+			Method: fiber.MethodPut, Path: "/history/sessions/:id/scores", Tag: "Histórico",
+			Summary: "Registra a pontuação de uma parte do dueto", Auth: authAny, Scope: models.ScopeHistory,
+			Params:   SessionParams{},
+			Body:     RecordPartScoreRequest{},
+			Response: models.KaraokeSession{},
+			Errors: []apperror.Code{apperror.CodeSessionNotFound, apperror.CodeSessionAlreadyEnded,
+				apperror.CodeSessionNotDuet, apperror.CodeNotSessionMember, apperror.CodePartNotInSession},
+		},
+
+		// Duetos
+		{
+			Method: fiber.MethodGet, Path: "/songs/:id/parts", Tag: "Duetos",
+			Summary: "Partes da letra de uma música", Auth: authAny,
+			Params:   SongParams{},
+			Response: lyricParts,
+			Errors:   []apperror.Code{apperror.CodeSongNotFound},
+		},
+		{
+			Method: fiber.MethodPut, Path: "/songs/:id/parts", Tag: "Duetos",
+			Summary: "Define as partes da letra", Auth: authAny,
+			Scope: models.ScopeCatalogWrite, Permission: models.PermissionCatalogWrite,
+			Params:   SongParams{},
+			Body:     SetLyricPartsRequest{},
+			Response: lyricParts,
+			Errors:   []apperror.Code{apperror.CodeSongNotFound, apperror.CodeInvalidLyricPart},
+		},
+
+		// Recomendações
+		{
+			Method: fiber.MethodGet, Path: "/recommendations", Tag: "Recomendações",
+			Summary: "Recomendações de músicas", Auth: authAny, Scope: models.ScopeHistory,
+			Query: []any{RecommendationsQuery{}},
+			Response: struct {
+				Recommendations []service.Recommendation `json:"recommendations"`
+			}{},
+		},
+
+		// Party
+		{
+			Method: fiber.MethodGet, Path: "/party/:code/ws", Tag: "Party",
+			Summary: "Conexão WebSocket com a sala",
+			Description: "Upgrade para WebSocket. Navegadores mandam o ID token em `?token=`; outros clientes " +
+				"podem usar o header Authorization. O cliente envia PartyCommand e recebe PartyEvent; " +
+				"comandos recusados voltam como `{\"type\": \"error\", \"code\", \"error\"}`.",
+			Capability: CapabilityCache,
+			Status:     http.StatusSwitchingProtocols,
+			Errors: []apperror.Code{apperror.CodeUpgradeRequired, apperror.CodeUnauthorized,
+				apperror.CodeInvalidToken, apperror.CodeSessionRevoked, apperror.CodeAccountDisabled,
+				apperror.CodePartyRoomNotFound},
+		},
+		{
+			Method: fiber.MethodPost, Path: "/party/", Tag: "Party",
+			Summary: "Cria uma sala", Auth: authAny, Scope: models.ScopeParty,
+			Capability: CapabilityCache,
+			Status:     http.StatusCreated,
+			Response:   models.PartyRoom{},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/party/:code", Tag: "Party",
+			Summary: "Estado da sala", Auth: authAny, Scope: models.ScopeParty,
+			Capability: CapabilityCache,
+			Response:   models.PartyRoom{},
+			Errors:     []apperror.Code{apperror.CodePartyRoomNotFound},
+		},
+		{
+			Method: fiber.MethodPost, Path: "/party/:code/queue", Tag: "Party",
+			Summary: "Coloca uma música na fila", Auth: authAny, Scope: models.ScopeParty,
+			Capability: CapabilityCache,
+			Body:       EnqueuePartySongRequest{},
+			Response:   models.PartyRoom{},
+			Errors:     []apperror.Code{apperror.CodePartyRoomNotFound, apperror.CodeSongNotFound, apperror.CodePartyRoomBusy},
+		},
+		{
+			Method: fiber.MethodDelete, Path: "/party/:code", Tag: "Party",
+			Summary: "Encerra a sala", Auth: authAny, Scope: models.ScopeParty,
+			Capability: CapabilityCache,
+			Response:   message,
+			Errors:     []apperror.Code{apperror.CodePartyRoomNotFound, apperror.CodePartyNotHost},
+		},
+
+		// Mídia
+		{
+			Method: fiber.MethodPost, Path: "/download-song", Tag: "Mídia",
+			Summary: "Baixa o áudio de uma música", Auth: authAny, Scope: models.ScopeMedia,
+			RateLimited: true, Quota: true,
+			Body:     DownloadRequest{},
+			Response: binary, ContentType: "audio/mpeg",
+			Errors: []apperror.Code{apperror.CodeDownloadFailed},
+		},
+		{
+			Method: fiber.MethodPost, Path: "/search-song", Tag: "Mídia",
+			Summary: "Busca músicas no YouTube", Auth: authAny, Scope: models.ScopeMedia,
+			RateLimited: true,
+			Body:        SearchRequest{},
+			Response: struct {
+				Success bool           `json:"success"`
+				Results []SearchResult `json:"results"`
+			}{},
+			Errors: []apperror.Code{apperror.CodeSearchFailed},
+		},
+		{
+			Method: fiber.MethodPost, Path: "/lyrics/fetch", Tag: "Mídia",
+			Summary:     "Busca a letra de uma música",
+			Description: "Com `song_id`, a resposta inclui as partes do dueto.",
+			Auth:        authAny, Scope: models.ScopeMedia,
+			RateLimited: true,
+			Body:        LyricsRequest{},
+			Response:    LyricsResponse{},
+			Errors:      []apperror.Code{apperror.CodeLyricsFailed},
+		},
+		{
+			Method: fiber.MethodPost, Path: "/transcribe", Tag: "Mídia",
+			Summary: "Transcreve um áudio com Whisper", Auth: authAny, Scope: models.ScopeMedia,
+			RateLimited: true, Quota: true,
+			Body:     TranscriptionRequest{},
+			Response: TranscriptionResponse{},
+			Errors:   []apperror.Code{apperror.CodeTranscriptionFailed},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/audio-files", Tag: "Mídia",
+			Summary: "Lista os áudios baixados", Auth: authAny, Scope: models.ScopeMedia,
+			Response: struct {
+				AudioFiles []string `json:"audio_files"`
+				Count      int      `json:"count"`
+				Directory  string   `json:"directory"`
+			}{},
+		},
+
+		// Sistema
+		{
+			Method: fiber.MethodGet, Path: "/debug/config", Tag: "Sistema",
+			Summary: "Configuração efetiva, com os segredos mascarados", Auth: authInteractive,
+			Response: &openapi.Schema{Type: "object", AdditionalProperties: &openapi.Schema{}},
+			Errors:   []apperror.Code{apperror.CodeAdminRequired},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/protected", Tag: "Sistema",
+			Summary: "Rota de teste da autenticação", Auth: authAny,
+			Response: struct {
+				Message string   `json:"message"`
+				User    UserInfo `json:"user"`
+			}{},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/health", Tag: "Sistema",
+			Summary: "Estado das dependências opcionais",
+			Response: struct {
+				Status       string       `json:"status"`
+				Capabilities Capabilities `json:"capabilities"`
+			}{},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/livez", Tag: "Sistema",
+			Summary:  "Liveness probe",
+			Response: status,
+		},
+		{
+			Method: fiber.MethodGet, Path: "/readyz", Tag: "Sistema",
+			Summary:  "Readiness probe",
+			Response: ReadinessReport{},
+			Extra:    map[int]any{http.StatusServiceUnavailable: ReadinessReport{}},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/metrics", Tag: "Sistema",
+			Summary:     "Métricas no formato do Prometheus",
+			Response:    &openapi.Schema{Type: "string"},
+			ContentType: "text/plain",
+		},
+		{
+			Method: fiber.MethodGet, Path: "/openapi.json", Tag: "Sistema",
+			Summary:  "Este documento",
+			Response: &openapi.Schema{Type: "object", AdditionalProperties: &openapi.Schema{}},
+		},
+		{
+			Method: fiber.MethodGet, Path: "/docs", Tag: "Sistema",
+			Summary:     "Documentação interativa (Swagger UI)",
+			Response:    &openapi.Schema{Type: "string"},
+			ContentType: fiber.MIMETextHTML,
+		},
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/josevitorrodriguess/any-song/backend/internal/auth"
+	"github.com/josevitorrodriguess/any-song/backend/internal/config"
+	"github.com/josevitorrodriguess/any-song/backend/internal/openapi"
+)
+
+// newRoutesOnlyAPI registra as rotas sem conectar em nada: só os handlers
+// são montados, nenhum é chamado.
+func newRoutesOnlyAPI(t *testing.T, withLocalIssuer bool) *API {
+	t.Helper()
+	api := &API{
+		Config: &config.Config{
+			Environment: config.EnvironmentTest,
+			Server:      config.ServerConfig{CORSOrigins: []string{"http://localhost:3000"}},
+		},
+		Router:       fiber.New(),
+		Capabilities: Capabilities{},
+	}
+	if withLocalIssuer {
+		api.LocalIssuer = &auth.LocalIssuer{}
+	}
+	api.SetupRoutes()
+	return api
+}
+
+// registeredRoutes lista "MÉTODO /caminho" de cada rota do Fiber, no formato
+// dos caminhos do documento.
+func registeredRoutes(app *fiber.App) map[string]bool {
+	routes := map[string]bool{}
+	for _, route := range app.GetRoutes(true) {
+		// O Fiber registra HEAD junto com cada GET
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		routes[route.Method+" "+openAPIPath(route.Path)] = true
+	}
+	return routes
+}
+
+func documentedRoutes(doc *openapi.Document) map[string]bool {
+	routes := map[string]bool{}
+	for path, item := range doc.Paths {
+		for method := range *item {
+			routes[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+	return routes
+}
+
+func missing(from, in map[string]bool) []string {
+	var out []string
+	for route := range from {
+		if !in[route] {
+			out = append(out, route)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	for _, withLocalIssuer := range []bool{true, false} {
+		api := newRoutesOnlyAPI(t, withLocalIssuer)
+		registered := registeredRoutes(api.Router)
+		documented := documentedRoutes(api.OpenAPIDocument())
+
+		if undocumented := missing(registered, documented); len(undocumented) > 0 {
+			t.Errorf("rotas sem documentação (local issuer: %v): %v", withLocalIssuer, undocumented)
+		}
+		if stale := missing(documented, registered); len(stale) > 0 {
+			t.Errorf("rotas documentadas que não existem (local issuer: %v): %v", withLocalIssuer, stale)
+		}
+	}
+}
+
+func TestOpenAPIPathParameters(t *testing.T) {
+	doc := newRoutesOnlyAPI(t, true).OpenAPIDocument()
+	for path, item := range doc.Paths {
+		for method, op := range *item {
+			for _, segment := range strings.Split(path, "/") {
+				if !strings.HasPrefix(segment, "{") {
+					continue
+				}
+				name := strings.Trim(segment, "{}")
+				if !hasParameter(op.Parameters, name) {
+					t.Errorf("%s %s: parâmetro %q não documentado", strings.ToUpper(method), path, name)
+				}
+			}
+		}
+	}
+}
+
+func TestOpenAPIReferencesResolve(t *testing.T) {
+	doc := newRoutesOnlyAPI(t, true).OpenAPIDocument()
+	body, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("serializar o documento: %v", err)
+	}
+
+	for _, part := range strings.Split(string(body), `"$ref":"#/components/schemas/`)[1:] {
+		name := part[:strings.Index(part, `"`)]
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("$ref para schema inexistente: %s", name)
+		}
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	api := newRoutesOnlyAPI(t, false)
+
+	resp, err := api.Router.Test(httpRequest(fiber.MethodGet, "/openapi.json"))
+	if err != nil {
+		t.Fatalf("GET /openapi.json: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /openapi.json: status %d", resp.StatusCode)
+	}
+	var doc openapi.Document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("decodificar /openapi.json: %v", err)
+	}
+	if doc.OpenAPI != openapi.Version {
+		t.Errorf("openapi = %q, esperado %q", doc.OpenAPI, openapi.Version)
+	}
+	if _, ok := doc.Paths["/dev/token"]; ok {
+		t.Error("/dev/token documentada sem AUTH_PROVIDER=local")
+	}
+}
+
+func httpRequest(method, target string) *http.Request {
+	req, _ := http.NewRequest(method, target, nil)
+	return req
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+	"github.com/josevitorrodriguess/any-song/backend/internal/logging"
+	"github.com/josevitorrodriguess/any-song/backend/internal/service"
+)
+
+type EnqueuePartySongRequest struct {
+	SongID string `json:"song_id" validate:"required,uuid"`
+}
+
+// PartyCommand é a mensagem enviada pelos clientes pelo WebSocket.
+// Type: enqueue, play, pause, skip ou seek.
+type PartyCommand struct {
+	Type       string `json:"type"`
+	SongID     string `json:"song_id,omitempty"`
+	PositionMs int64  `json:"position_ms,omitempty"`
+}
+
+func (api *API) CreatePartyRoomHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	room, err := api.PartyService.CreateRoom(c.UserContext(), user.UID)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(room)
+}
+
+func (api *API) GetPartyRoomHandler(c *fiber.Ctx) error {
+	room, err := api.PartyService.GetRoom(c.UserContext(), service.NormalizePartyCode(c.Params("code")))
+	if err != nil {
+		return err
+	}
+	return c.JSON(room)
+}
+
+func (api *API) EnqueuePartySongHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var req EnqueuePartySongRequest
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
+	}
+
+	room, err := api.PartyService.Enqueue(c.UserContext(), service.NormalizePartyCode(c.Params("code")), user.UID, uuid.MustParse(req.SongID))
+	if err != nil {
+		return err
+	}
+	return c.JSON(room)
+}
+
+func (api *API) ClosePartyRoomHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	if err := api.PartyService.CloseRoom(c.UserContext(), service.NormalizePartyCode(c.Params("code")), user.UID); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"message": "Sala encerrada com sucesso",
+	})
+}
+
+// PartyWebSocketUpgrade autentica o convidado antes do upgrade. Navegadores
+// não enviam headers customizados no handshake, então o token também é aceito
+// pela query string (?token=...).
+func (api *API) PartyWebSocketUpgrade() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !websocket.IsWebSocketUpgrade(c) {
+			return fiber.ErrUpgradeRequired
+		}
+		if !api.Capabilities.Available(CapabilityAuth) {
+			return api.capabilityUnavailable(CapabilityAuth)
+		}
+
+		token := c.Query("token")
+		if token == "" {
+			token = strings.Replace(c.Get("Authorization"), "Bearer ", "", 1)
+		}
+		if token == "" {
+			return apperror.New(apperror.CodeUnauthorized)
+		}
+
+		_, user, err := api.verifyToken(c.UserContext(), token)
+		if err != nil {
+			return tokenError(err)
+		}
+
+		code := service.NormalizePartyCode(c.Params("code"))
+		if _, err := api.PartyService.GetRoom(c.UserContext(), code); err != nil {
+			return err
+		}
+
+		c.Locals("user", user)
+		c.Locals("partyCode", code)
+		// O handshake é a única chance de ler o Accept-Language
+		c.Locals("language", apperror.NegotiateLanguage(c.Get(fiber.HeaderAcceptLanguage)))
+		return c.Next()
+	}
+}
+
+// PartyWebSocketHandler mantém a conexão de um cliente com a sala: repassa os
+// eventos publicados no Redis e executa os comandos recebidos.
+func (api *API) PartyWebSocketHandler(conn *websocket.Conn) {
+	user, _ := conn.Locals("user").(UserInfo)
+	code, _ := conn.Locals("partyCode").(string)
+	lang, _ := conn.Locals("language").(apperror.Language)
+
+	requestID, _ := conn.Locals("requestid").(string)
+
+	// Cancelado também no desligamento, o que fecha a conexão.
+	jobCtx, done := api.jobContext(logging.WithRequestID(context.Background(), requestID), 0)
+	defer done()
+	ctx, cancel := context.WithCancel(jobCtx)
+	defer cancel()
+
+	var writeMu sync.Mutex
+	send := func(v interface{}) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteJSON(v)
+	}
+
+	events, err := api.PartyService.Subscribe(ctx, code)
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao assinar eventos da sala", "party_code", code, "error", err)
+		send(partyErrorEvent(apperror.Internal(err), lang))
+		return
+	}
+
+	// A assinatura vem antes do Join para que o próprio evento de entrada,
+	// com o estado completo da sala, chegue a este cliente.
+	if _, err := api.PartyService.Join(ctx, code, user.UID, user.Name); err != nil {
+		send(partyErrorEvent(err, lang))
+		return
+	}
+	defer func() {
+		// ctx já pode estar cancelado aqui; só o request ID é aproveitado
+		leaveCtx := logging.WithRequestID(context.Background(), requestID)
+		if _, err := api.PartyService.Leave(leaveCtx, code, user.UID); err != nil && !errors.Is(err, service.ErrPartyRoomNotFound) {
+			slog.ErrorContext(leaveCtx, "Erro ao remover usuário da sala", "user_uid", user.UID, "party_code", code, "error", err)
+		}
+	}()
+
+	go func() {
+		for payload := range events {
+			writeMu.Lock()
+			err := conn.WriteMessage(websocket.TextMessage, payload)
+			writeMu.Unlock()
+			if err != nil {
+				cancel()
+				return
+			}
+		}
+		// O canal fecha quando ctx é cancelado; derruba a leitura também.
+		conn.Close()
+	}()
+
+	for {
+		var cmd PartyCommand
+		if err := conn.ReadJSON(&cmd); err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				send(partyErrorEvent(apperror.Wrap(err, apperror.CodeBadRequest), lang))
+				continue
+			}
+			return
+		}
+
+		if err := api.handlePartyCommand(ctx, code, user, cmd); err != nil {
+			event := partyErrorEvent(err, lang)
+			event["command"] = cmd.Type
+			send(event)
+		}
+	}
+}
+
+// handlePartyCommand executa um comando; o novo estado chega ao cliente pelo
+// evento publicado, não pela resposta.
+func (api *API) handlePartyCommand(ctx context.Context, code string, user UserInfo, cmd PartyCommand) error {
+	var err error
+	switch cmd.Type {
+	case "enqueue":
+		songID, parseErr := uuid.Parse(cmd.SongID)
+		if parseErr != nil {
+			return apperror.Wrap(parseErr, apperror.CodeBadRequest)
+		}
+		_, err = api.PartyService.Enqueue(ctx, code, user.UID, songID)
+	case "play":
+		_, err = api.PartyService.Play(ctx, code, user.UID)
+	case "pause":
+		_, err = api.PartyService.Pause(ctx, code, user.UID)
+	case "skip":
+		_, err = api.PartyService.Skip(ctx, code, user.UID)
+	case "seek":
+		_, err = api.PartyService.Seek(ctx, code, user.UID, cmd.PositionMs)
+	default:
+		return apperror.New(apperror.CodeBadRequest)
+	}
+
+	if err != nil && !isPartyClientError(err) {
+		slog.ErrorContext(ctx, "Erro ao executar comando na sala", "command", cmd.Type, "party_code", code, "error", err)
+		return apperror.Internal(err)
+	}
+	return err
+}
+
+// partyErrorEvent é a mensagem de erro do WebSocket, com o mesmo código e a
+// mesma mensagem localizada das respostas HTTP.
+func partyErrorEvent(err error, lang apperror.Language) fiber.Map {
+	appErr := resolveError(err)
+	return fiber.Map{
+		"type":  "error",
+		"code":  appErr.Code,
+		"error": apperror.Message(appErr.Code, lang),
+	}
+}
+
+func isPartyClientError(err error) bool {
+	return errors.Is(err, service.ErrPartyRoomNotFound) ||
+		errors.Is(err, service.ErrPartyNotHost) ||
+		errors.Is(err, service.ErrPartyEmptyQueue) ||
+		errors.Is(err, service.ErrPartyNothingToPlay) ||
+		errors.Is(err, service.ErrPartyRoomBusy) ||
+		errors.Is(err, service.ErrSongNotFound)
+}
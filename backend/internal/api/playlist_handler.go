@@ -0,0 +1,272 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"github.com/josevitorrodriguess/any-song/backend/internal/service"
+)
+
+type CreatePlaylistRequest struct {
+	Name        string `json:"name" validate:"notblank,max=100"`
+	Description string `json:"description" validate:"max=500"`
+	Visibility  string `json:"visibility" validate:"omitempty,visibility"`
+}
+
+// Version é a versão da playlist que o cliente tem em mãos; se outra
+// requisição alterou a playlist antes, a operação falha com 409.
+type UpdatePlaylistRequest struct {
+	Name        *string `json:"name" validate:"omitnil,notblank,max=100"`
+	Description *string `json:"description" validate:"omitnil,max=500"`
+	Visibility  *string `json:"visibility" validate:"omitnil,visibility"`
+	Version     int     `json:"version" validate:"gte=0"`
+}
+
+type AddPlaylistItemRequest struct {
+	SongID   string `json:"song_id" validate:"required,uuid"`
+	Position *int   `json:"position" validate:"omitnil,gte=0"`
+	Version  int    `json:"version" validate:"gte=0"`
+}
+
+type ReorderPlaylistRequest struct {
+	ItemIDs []string `json:"item_ids" validate:"required,unique,dive,uuid"`
+	Version int      `json:"version" validate:"gte=0"`
+}
+
+type MovePlaylistItemRequest struct {
+	Position int `json:"position" validate:"gte=0"`
+	Version  int `json:"version" validate:"gte=0"`
+}
+
+type DuplicatePlaylistRequest struct {
+	Name string `json:"name" validate:"max=100"`
+}
+
+type PlaylistParams struct {
+	ID string `params:"id" validate:"uuid"`
+}
+
+type PlaylistItemParams struct {
+	ID     string `params:"id" validate:"uuid"`
+	ItemID string `params:"itemId" validate:"uuid"`
+}
+
+type RemovePlaylistItemQuery struct {
+	Version int `query:"version" validate:"gte=0"`
+}
+
+type PublicPlaylistsQuery struct {
+	Name string `query:"name" validate:"max=100"`
+}
+
+func (api *API) CreatePlaylistHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var req CreatePlaylistRequest
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
+	}
+
+	playlist := models.Playlist{
+		OwnerUID:    user.UID,
+		Name:        req.Name,
+		Description: req.Description,
+		Visibility:  req.Visibility,
+	}
+	if err := api.PlaylistService.CreatePlaylist(c.UserContext(), &playlist); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(playlist)
+}
+
+func (api *API) ListMyPlaylistsHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	playlists, err := api.PlaylistService.ListUserPlaylists(c.UserContext(), user.UID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(playlists)
+}
+
+func (api *API) ListPublicPlaylistsHandler(c *fiber.Ctx) error {
+	var query PublicPlaylistsQuery
+	if err := bindQuery(c, &query); err != nil {
+		return validationFailed(err)
+	}
+
+	playlists, err := api.PlaylistService.ListPublicPlaylists(c.UserContext(), query.Name, 20)
+	if err != nil {
+		return err
+	}
+	return c.JSON(playlists)
+}
+
+func (api *API) GetPlaylistHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var params PlaylistParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+	id := uuid.MustParse(params.ID)
+
+	playlist, err := api.PlaylistService.GetPlaylist(c.UserContext(), id, user.UID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(playlist)
+}
+
+func (api *API) UpdatePlaylistHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var params PlaylistParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+	id := uuid.MustParse(params.ID)
+
+	var req UpdatePlaylistRequest
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
+	}
+
+	update := service.PlaylistUpdate{
+		Name:        req.Name,
+		Description: req.Description,
+		Visibility:  req.Visibility,
+	}
+	playlist, err := api.PlaylistService.UpdatePlaylist(c.UserContext(), id, user.UID, update, req.Version)
+	if err != nil {
+		return err
+	}
+	return c.JSON(playlist)
+}
+
+func (api *API) DeletePlaylistHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var params PlaylistParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+	id := uuid.MustParse(params.ID)
+
+	if err := api.PlaylistService.DeletePlaylist(c.UserContext(), id, user.UID); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"message": "Playlist deletada com sucesso",
+	})
+}
+
+func (api *API) AddPlaylistItemHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var params PlaylistParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+	id := uuid.MustParse(params.ID)
+
+	var req AddPlaylistItemRequest
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
+	}
+	playlist, err := api.PlaylistService.AddSong(c.UserContext(), id, user.UID, uuid.MustParse(req.SongID), req.Position, req.Version)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(playlist)
+}
+
+func (api *API) RemovePlaylistItemHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var params PlaylistItemParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+	id, itemID := uuid.MustParse(params.ID), uuid.MustParse(params.ItemID)
+
+	var query RemovePlaylistItemQuery
+	if err := bindQuery(c, &query); err != nil {
+		return validationFailed(err)
+	}
+
+	playlist, err := api.PlaylistService.RemoveItem(c.UserContext(), id, user.UID, itemID, query.Version)
+	if err != nil {
+		return err
+	}
+	return c.JSON(playlist)
+}
+
+func (api *API) ReorderPlaylistHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var params PlaylistParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+	id := uuid.MustParse(params.ID)
+
+	var req ReorderPlaylistRequest
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
+	}
+	itemIDs := make([]uuid.UUID, len(req.ItemIDs))
+	for i, raw := range req.ItemIDs {
+		itemIDs[i] = uuid.MustParse(raw)
+	}
+
+	playlist, err := api.PlaylistService.ReorderItems(c.UserContext(), id, user.UID, itemIDs, req.Version)
+	if err != nil {
+		return err
+	}
+	return c.JSON(playlist)
+}
+
+func (api *API) MovePlaylistItemHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var params PlaylistItemParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+	id, itemID := uuid.MustParse(params.ID), uuid.MustParse(params.ItemID)
+
+	var req MovePlaylistItemRequest
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
+	}
+
+	playlist, err := api.PlaylistService.MoveItem(c.UserContext(), id, user.UID, itemID, req.Position, req.Version)
+	if err != nil {
+		return err
+	}
+	return c.JSON(playlist)
+}
+
+func (api *API) DuplicatePlaylistHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var params PlaylistParams
+	if err := bindParams(c, &params); err != nil {
+		return validationFailed(err)
+	}
+	id := uuid.MustParse(params.ID)
+
+	var req DuplicatePlaylistRequest
+	if len(c.Body()) > 0 {
+		if err := bindBody(c, &req); err != nil {
+			return validationFailed(err)
+		}
+	}
+
+	playlist, err := api.PlaylistService.DuplicatePlaylist(c.UserContext(), id, user.UID, req.Name)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(playlist)
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+type RecommendationsQuery struct {
+	Limit int `query:"limit" validate:"gte=0,lte=50"`
+}
+
+func (api *API) GetRecommendationsHandler(c *fiber.Ctx) error {
+	user, _ := GetUserFromContext(c)
+
+	var query RecommendationsQuery
+	if err := bindQuery(c, &query); err != nil {
+		return validationFailed(err)
+	}
+	if query.Limit == 0 {
+		query.Limit = 20
+	}
+
+	recommendations, err := api.RecommendationService.GetRecommendations(c.UserContext(), user.UID, query.Limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"recommendations": recommendations,
+	})
+}
@@ -1,62 +1,160 @@
 package api
 
 import (
+	"strings"
+
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+	"github.com/josevitorrodriguess/any-song/backend/internal/config"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
 )
 
 func (api *API) SetupRoutes() {
+	// Primeiro, para medir também o tempo dos outros middlewares
+	api.Router.Use(api.MetricsMiddleware())
+	api.Router.Use(api.TracingMiddleware())
+
+	// Request ID usado nos logs, no audit log e nos subprocessos, devolvido no
+	// header X-Request-ID
+	api.Router.Use(api.RequestIDMiddleware())
+	api.Router.Use(api.AccessLogMiddleware())
+
 	// CORS middleware
 	api.Router.Use(cors.New(cors.Config{
-		AllowOrigins:     "http://localhost:3000",
+		AllowOrigins:     strings.Join(api.Config.Server.CORSOrigins, ","),
 		AllowMethods:     "GET,POST,HEAD,PUT,DELETE,PATCH,OPTIONS",
-		AllowHeaders:     "Origin,Content-Type,Accept,Authorization",
+		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-API-Key",
+		ExposeHeaders:    "X-Request-ID,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Retry-After,X-Quota-Limit,X-Quota-Remaining",
 		AllowCredentials: true,
 	}))
 
-	api.Router.Post("/signin", api.SignInHandler)
-	api.Router.Post("/logout", api.AuthMiddleware(), api.LogoutHandler)
+	if api.devRoutesEnabled() {
+		api.Router.Post("/dev/token", api.IssueDevTokenHandler)
+	}
+
+	api.Router.Post("/signin", api.RequireCapability(CapabilityAuth), api.SignInHandler)
+	api.Router.Post("/logout", api.AuthMiddleware(), api.RequireInteractive(), api.LogoutHandler)
+
+	meRoutes := api.Router.Group("/me", api.AuthMiddleware(), api.RequireInteractive())
+	meRoutes.Get("/", api.GetMeHandler)
+	meRoutes.Patch("/", api.UpdateMeHandler)
+	meRoutes.Delete("/", api.ScheduleDeletionHandler)
+	meRoutes.Get("/export", api.RequireCapability(CapabilityStorage), api.RateLimit("export"), api.ExportMeHandler)
+	meRoutes.Get("/deletion", api.GetDeletionHandler)
+	meRoutes.Delete("/deletion", api.CancelDeletionHandler)
+	meRoutes.Get("/sessions", api.ListAuthSessionsHandler)
+	meRoutes.Delete("/sessions", api.RevokeAllAuthSessionsHandler)
+	meRoutes.Delete("/sessions/:id", api.RevokeAuthSessionHandler)
+	meRoutes.Get("/api-keys", api.ListMyAPIKeysHandler)
+	meRoutes.Post("/api-keys", api.CreateMyAPIKeyHandler)
+	meRoutes.Delete("/api-keys/:id", api.RevokeMyAPIKeyHandler)
 
 	userRoutes := api.Router.Group("/user", api.AuthMiddleware())
 	userRoutes.Get("/:username", api.FindUserByNameHandler)
-	userRoutes.Put("/update", api.UpdateUserHandler)
-	userRoutes.Delete("/deleteAccount", api.DeleteUserHandler)
+
+	catalogWrite := []fiber.Handler{api.AuthMiddleware(), api.RequireScope(models.ScopeCatalogWrite), api.RequirePermission(models.PermissionCatalogWrite)}
 
 	artistRoutes := api.Router.Group("/artist")
-	artistRoutes.Post("/create", api.CreateArtistHandler)
+	artistRoutes.Post("/create", append(catalogWrite, api.CreateArtistHandler)...)
 	artistRoutes.Get("/search", api.SearchArtistsHandler)
 	artistRoutes.Get("/id/:id", api.GetArtistByIDHandler)
 	artistRoutes.Get("/", api.GetAllArtistsHandler)
-	artistRoutes.Put("/update", api.UpdateArtistHandler)
-	artistRoutes.Delete("/delete/:id", api.DeleteArtistHandler)
+	artistRoutes.Put("/update", append(catalogWrite, api.UpdateArtistHandler)...)
+	artistRoutes.Delete("/delete/:id", append(catalogWrite, api.DeleteArtistHandler)...)
+
+	adminRoutes := api.Router.Group("/admin", api.AuthMiddleware(), api.RequireInteractive())
+	adminRoutes.Get("/users/:firebaseUID", api.RequirePermission(models.PermissionUsersManage), api.AdminGetUserHandler)
+	adminRoutes.Patch("/users/:firebaseUID", api.RequirePermission(models.PermissionUsersManage), api.AdminUpdateUserHandler)
+	adminRoutes.Delete("/users/:firebaseUID", api.RequirePermission(models.PermissionUsersManage), api.RequireCapability(CapabilityStorage), api.AdminDeleteUserHandler)
+	adminRoutes.Get("/users/:firebaseUID/api-keys", api.RequirePermission(models.PermissionUsersManage), api.AdminListAPIKeysHandler)
+	adminRoutes.Post("/users/:firebaseUID/api-keys", api.RequirePermission(models.PermissionUsersManage), api.AdminCreateAPIKeyHandler)
+	adminRoutes.Delete("/users/:firebaseUID/api-keys/:id", api.RequirePermission(models.PermissionUsersManage), api.AdminRevokeAPIKeyHandler)
+	adminRoutes.Get("/audit", api.RequirePermission(models.PermissionAuditRead), api.ListAuditLogHandler)
+	adminRoutes.Get("/roles", api.RequirePermission(models.PermissionRolesManage), api.ListRolesHandler)
+	adminRoutes.Get("/users/:firebaseUID/roles", api.RequirePermission(models.PermissionRolesManage), api.ListUserRolesHandler)
+	adminRoutes.Post("/users/:firebaseUID/roles", api.RequirePermission(models.PermissionRolesManage), api.GrantRoleHandler)
+	adminRoutes.Delete("/users/:firebaseUID/roles/:role", api.RequirePermission(models.PermissionRolesManage), api.RevokeRoleHandler)
+
+	playlistRoutes := api.Router.Group("/playlist", api.AuthMiddleware(), api.RequireScope(models.ScopePlaylists))
+	playlistRoutes.Post("/", api.CreatePlaylistHandler)
+	playlistRoutes.Get("/", api.ListMyPlaylistsHandler)
+	playlistRoutes.Get("/public", api.ListPublicPlaylistsHandler)
+	playlistRoutes.Get("/:id", api.GetPlaylistHandler)
+	playlistRoutes.Patch("/:id", api.UpdatePlaylistHandler)
+	playlistRoutes.Delete("/:id", api.DeletePlaylistHandler)
+	playlistRoutes.Post("/:id/items", api.AddPlaylistItemHandler)
+	playlistRoutes.Delete("/:id/items/:itemId", api.RemovePlaylistItemHandler)
+	playlistRoutes.Put("/:id/items/:itemId/position", api.MovePlaylistItemHandler)
+	playlistRoutes.Put("/:id/order", api.ReorderPlaylistHandler)
+	playlistRoutes.Post("/:id/duplicate", api.DuplicatePlaylistHandler)
+
+	favoriteRoutes := api.Router.Group("/favorites", api.AuthMiddleware(), api.RequireScope(models.ScopeHistory))
+	favoriteRoutes.Get("/", api.ListFavoritesHandler)
+	favoriteRoutes.Put("/:songId", api.AddFavoriteHandler)
+	favoriteRoutes.Delete("/:songId", api.RemoveFavoriteHandler)
+
+	historyRoutes := api.Router.Group("/history", api.AuthMiddleware(), api.RequireScope(models.ScopeHistory))
+	historyRoutes.Get("/", api.ListPlayHistoryHandler)
+	historyRoutes.Get("/recent", api.RecentlyPlayedHandler)
+	historyRoutes.Post("/plays", api.RecordPlayHandler)
+	historyRoutes.Get("/sessions", api.ListSessionsHandler)
+	historyRoutes.Post("/sessions", api.StartSessionHandler)
+	historyRoutes.Post("/sessions/duet", api.StartDuetSessionHandler)
+	historyRoutes.Get("/sessions/:id", api.GetSessionHandler)
+	historyRoutes.Post("/sessions/:id/end", api.EndSessionHandler)
+	historyRoutes.Put("/sessions/:id/scores", api.RecordPartScoreHandler)
+
+	songRoutes := api.Router.Group("/songs", api.AuthMiddleware())
+	songRoutes.Get("/:id/parts", api.GetLyricPartsHandler)
+	songRoutes.Put("/:id/parts", api.RequireScope(models.ScopeCatalogWrite), api.RequirePermission(models.PermissionCatalogWrite), api.SetLyricPartsHandler)
+
+	api.Router.Get("/recommendations", api.AuthMiddleware(), api.RequireScope(models.ScopeHistory), api.GetRecommendationsHandler)
+
+	partyRoutes := api.Router.Group("/party", api.RequireCapability(CapabilityCache))
+	partyRoutes.Get("/:code/ws", api.PartyWebSocketUpgrade(), websocket.New(api.PartyWebSocketHandler))
+	partyRoutes.Post("/", api.AuthMiddleware(), api.RequireScope(models.ScopeParty), api.CreatePartyRoomHandler)
+	partyRoutes.Get("/:code", api.AuthMiddleware(), api.RequireScope(models.ScopeParty), api.GetPartyRoomHandler)
+	partyRoutes.Post("/:code/queue", api.AuthMiddleware(), api.RequireScope(models.ScopeParty), api.EnqueuePartySongHandler)
+	partyRoutes.Delete("/:code", api.AuthMiddleware(), api.RequireScope(models.ScopeParty), api.ClosePartyRoomHandler)
 
 	// Song download route
-	api.Router.Post("/download-song", api.AuthMiddleware(), api.DownloadSongHandler)
+	api.Router.Post("/download-song", api.AuthMiddleware(), api.RequireScope(models.ScopeMedia), api.RateLimit("download"), api.RequireQuota(config.QuotaDownloadMinutes), api.DownloadSongHandler)
 
 	// Song search route
-	api.Router.Post("/search-song", api.AuthMiddleware(), api.SearchSongHandler)
+	api.Router.Post("/search-song", api.AuthMiddleware(), api.RequireScope(models.ScopeMedia), api.RateLimit("search"), api.SearchSongHandler)
 
 	// Lyrics route - Nova rota adicionada
-	api.Router.Post("/lyrics/fetch", api.AuthMiddleware(), api.CatchLyricsHandler)
+	api.Router.Post("/lyrics/fetch", api.AuthMiddleware(), api.RequireScope(models.ScopeMedia), api.RateLimit("lyrics"), api.CatchLyricsHandler)
 
 	// Transcription routes 
-	api.Router.Post("/transcribe", api.AuthMiddleware(), api.TranscribeAudioHandler)
+	api.Router.Post("/transcribe", api.AuthMiddleware(), api.RequireScope(models.ScopeMedia), api.RateLimit("transcribe"), api.RequireQuota(config.QuotaTranscriptionMinutes), api.TranscribeAudioHandler)
 
 	// Audio files route
-	api.Router.Get("/audio-files", api.AuthMiddleware(), api.ListAudioFilesHandler)
+	api.Router.Get("/audio-files", api.AuthMiddleware(), api.RequireScope(models.ScopeMedia), api.ListAudioFilesHandler)
+
+	api.Router.Get("/debug/config", api.AuthMiddleware(), api.RequireInteractive(), api.AdminRequiredMiddleware(), api.DebugConfigHandler)
 
 	api.Router.Get("/protected", api.AuthMiddleware(), api.ProtectedHandler)
-	api.Router.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{"status": "ok"})
-	})
+	api.Router.Get("/health", api.HealthHandler)
+	api.Router.Get("/livez", api.LivezHandler)
+	api.Router.Get("/readyz", api.ReadyzHandler)
+	api.Router.Get("/metrics", api.MetricsHandler())
+	api.Router.Get("/openapi.json", api.OpenAPIHandler())
+	api.Router.Get("/docs", api.DocsHandler)
+}
+
+// DebugConfigHandler mostra a configuração efetiva, com os segredos
+// mascarados.
+func (api *API) DebugConfigHandler(c *fiber.Ctx) error {
+	return c.JSON(api.Config)
 }
 
 func (api *API) ProtectedHandler(c *fiber.Ctx) error {
 	user, exists := GetUserFromContext(c)
 	if !exists {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Usuário não encontrado",
-		})
+		return apperror.New(apperror.CodeUnauthorized)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
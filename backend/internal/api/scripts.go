@@ -0,0 +1,20 @@
+package api
+
+import (
+	"context"
+	"os/exec"
+)
+
+// ScriptRunner executa os scripts Python que falam com os provedores
+// (YouTube, letras, transcrição). cmd vem pronto de command, com argumentos e
+// variáveis de ambiente; os testes trocam a execução por um fake.
+type ScriptRunner interface {
+	Run(ctx context.Context, script string, cmd *exec.Cmd) ([]byte, error)
+}
+
+// execScriptRunner roda o processo de verdade, com métricas e tracing.
+type execScriptRunner struct{}
+
+func (execScriptRunner) Run(ctx context.Context, script string, cmd *exec.Cmd) ([]byte, error) {
+	return runScript(ctx, script, cmd)
+}
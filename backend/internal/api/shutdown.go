@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/josevitorrodriguess/any-song/backend/internal/logging"
+)
+
+const (
+	// shutdownCancelGrace é quanto o desligamento ainda espera depois de
+	// cancelar subprocessos e workers, antes de fechar as conexões.
+	shutdownCancelGrace = 10 * time.Second
+	// subprocessKillDelay é o tempo que um script tem para sair depois do
+	// SIGTERM antes de receber SIGKILL.
+	subprocessKillDelay = 5 * time.Second
+	// downloadsDir guarda um diretório temporário por download.
+	downloadsDir = "/tmp/anysong-downloads"
+	// tempDirCleanupDelay dá tempo para o SendFile terminar de ler o arquivo.
+	tempDirCleanupDelay = 5 * time.Second
+)
+
+// lifecycle acompanha o trabalho que não termina junto com a requisição:
+// subprocessos, workers e limpeza de diretórios temporários. ctx é cancelado
+// no desligamento, depois que o servidor HTTP parou de aceitar conexões.
+type lifecycle struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	tasks  sync.WaitGroup
+}
+
+func newLifecycle() *lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &lifecycle{ctx: ctx, cancel: cancel}
+}
+
+// goWorker roda run em uma goroutine com o contexto da API; o desligamento
+// espera run voltar antes de fechar o banco.
+func (l *lifecycle) goWorker(run func(ctx context.Context)) {
+	l.tasks.Add(1)
+	go func() {
+		defer l.tasks.Done()
+		run(l.ctx)
+	}()
+}
+
+// wait espera as tarefas acompanhadas por até timeout.
+func (l *lifecycle) wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		l.tasks.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// jobContext deriva o contexto de um subprocesso disparado por uma
+// requisição. Ele herda os valores de parent (o request ID), é cancelado no
+// timeout (0 = sem timeout) ou quando o prazo do desligamento acaba; done
+// precisa ser chamado quando o trabalho terminar.
+func (api *API) jobContext(parent context.Context, timeout time.Duration) (context.Context, func()) {
+	api.lifecycle.tasks.Add(1)
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
+	stop := context.AfterFunc(api.lifecycle.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+		api.lifecycle.tasks.Done()
+	}
+}
+
+// command monta um subprocesso ligado a ctx. No cancelamento o processo recebe
+// SIGTERM, para os scripts Python apagarem o que deixaram pela metade, e só
+// depois de subprocessKillDelay é morto. O ambiente é o do servidor mais
+// REQUEST_ID; quem precisar de outras variáveis acrescenta em cmd.Env.
+func command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = os.Environ()
+	if id := logging.RequestID(ctx); id != "" {
+		cmd.Env = append(cmd.Env, "REQUEST_ID="+id)
+	}
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	cmd.WaitDelay = subprocessKillDelay
+	return cmd
+}
+
+// createDownloadDir cria um diretório temporário exclusivo para um download.
+func createDownloadDir() (string, error) {
+	if err := os.MkdirAll(downloadsDir, 0755); err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(downloadsDir, "download-")
+}
+
+// removeTempDirLater apaga dir depois de tempDirCleanupDelay, ou logo que o
+// desligamento começar. O desligamento espera a remoção.
+func (api *API) removeTempDirLater(dir string) {
+	api.lifecycle.goWorker(func(ctx context.Context) {
+		select {
+		case <-time.After(tempDirCleanupDelay):
+		case <-ctx.Done():
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			slog.Warn("Falha ao remover diretório temporário", "dir", dir, "error", err)
+			return
+		}
+		slog.Debug("Diretório temporário removido", "dir", dir)
+	})
+}
+
+// Shutdown desliga a API em ordem: para de aceitar conexões e espera as
+// requisições em andamento até timeout; depois cancela o que sobrou
+// (subprocessos, workers, salas de festa), espera a limpeza e fecha Postgres,
+// Redis e os clientes de armazenamento, nessa ordem.
+func (api *API) Shutdown(timeout time.Duration) error {
+	var errs []error
+
+	slog.Info("Desligando: aguardando requisições em andamento", "timeout", timeout.String())
+	if err := api.Router.ShutdownWithTimeout(timeout); err != nil {
+		errs = append(errs, fmt.Errorf("servidor HTTP: %w", err))
+	}
+
+	api.lifecycle.cancel()
+	if !api.lifecycle.wait(shutdownCancelGrace) {
+		slog.Warn("Subprocessos e workers não terminaram; fechando conexões assim mesmo", "grace", shutdownCancelGrace.String())
+	}
+
+	if api.DB != nil {
+		if sqlDB, err := api.DB.DB(); err != nil {
+			errs = append(errs, fmt.Errorf("postgres: %w", err))
+		} else if err := sqlDB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("postgres: %w", err))
+		}
+	}
+	if api.Redis != nil {
+		if err := api.Redis.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("redis: %w", err))
+		}
+	}
+	if api.Storage != nil {
+		if err := api.Storage.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("gcs: %w", err))
+		}
+	}
+	if api.Firestore != nil {
+		if err := api.Firestore.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("firestore: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/josevitorrodriguess/any-song/backend/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingMiddleware abre o span da requisição, continuando o trace do
+// cliente quando ele manda traceparent. Os spans do GORM, do Redis, do GCS e
+// dos scripts ficam abaixo deste, via c.UserContext().
+func (api *API) TracingMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		carrier := propagation.HeaderCarrier(http.Header(c.GetReqHeaders()))
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), carrier)
+
+		ctx, span := tracing.Tracer().Start(ctx, "HTTP "+c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPath(c.Path()),
+				semconv.ClientAddress(c.IP()),
+			),
+		)
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		status := responseStatus(c, err)
+		// O nome do span usa o padrão da rota, conhecido só depois do roteamento
+		if !routeNotFound(err) {
+			span.SetName(c.Method() + " " + c.Route().Path)
+			span.SetAttributes(semconv.HTTPRoute(c.Route().Path))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		if err != nil {
+			span.RecordError(err)
+		}
+		return err
+	}
+}
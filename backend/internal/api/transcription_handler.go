@@ -4,18 +4,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os/exec"
+	"log/slog"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+	"github.com/josevitorrodriguess/any-song/backend/internal/config"
+	"github.com/josevitorrodriguess/any-song/backend/internal/logging"
+	"github.com/josevitorrodriguess/any-song/backend/internal/metrics"
+	"github.com/josevitorrodriguess/any-song/backend/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type TranscriptionRequest struct {
-	AudioPath string `json:"audio_path" validate:"required"`
-	ModelSize string `json:"model_size,omitempty"` // tiny, base, small, medium, large-v3
-	Timeout   int    `json:"timeout,omitempty"`    // em segundos
+	AudioPath string `json:"audio_path" validate:"notblank,max=1024"`
+	ModelSize string `json:"model_size,omitempty" validate:"omitempty,oneof=tiny base small medium large-v3 turbo"`
+	Timeout   int    `json:"timeout,omitempty" validate:"gte=0,lte=3600"` // em segundos
 }
 
 type TranscriptionResponse struct {
@@ -60,18 +65,8 @@ type TimingInfo struct {
 
 func (api *API) TranscribeAudioHandler(c *fiber.Ctx) error {
 	var req TranscriptionRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
-	}
-
-	// Validar se o caminho do áudio foi fornecido
-	if strings.TrimSpace(req.AudioPath) == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "audio_path is required",
-		})
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
 	}
 
 	// Definir timeout (padrão: 300 segundos = 5 minutos para transcrição de áudio)
@@ -80,51 +75,34 @@ func (api *API) TranscribeAudioHandler(c *fiber.Ctx) error {
 		timeout = time.Duration(req.Timeout) * time.Second
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	ctx, done := api.jobContext(c.UserContext(), timeout)
+	defer done()
 
 	start := time.Now()
 
 	// Executar o script Python
 	result, err := api.executeTranscriptionScript(ctx, req.AudioPath, req.ModelSize)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error":             "Failed to execute transcription script",
-			"details":           err.Error(),
-			"execution_duration": time.Since(start).String(),
-		})
+		return apperror.Wrap(err, apperror.CodeTranscriptionFailed)
 	}
 
 	result.ExecutionDuration = time.Since(start).String()
 
 	if !result.Success {
-		return c.Status(500).JSON(result)
+		slog.ErrorContext(ctx, "Script de transcrição falhou", "audio_path", req.AudioPath, "error", logging.Tail([]byte(result.Error), scriptOutputLogLimit))
+		return apperror.New(apperror.CodeTranscriptionFailed)
 	}
 
+	api.consumeQuota(c, config.QuotaTranscriptionMinutes, result.DurationMinutes)
+
 	return c.JSON(result)
 }
 
 func (api *API) executeTranscriptionScript(ctx context.Context, audioPath, modelSize string) (*TranscriptionResponse, error) {
-	// Validar se o modelo é válido
-	validModels := []string{"tiny", "base", "small", "medium", "large-v3", "turbo"}
+	// O modelo já foi validado no TranscriptionRequest
 	if modelSize == "" {
 		modelSize = "base" // padrão
 	}
-	
-	isValidModel := false
-	for _, model := range validModels {
-		if model == modelSize {
-			isValidModel = true
-			break
-		}
-	}
-	
-	if !isValidModel {
-		return &TranscriptionResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Invalid model size. Valid options: %s", strings.Join(validModels, ", ")),
-		}, nil
-	}
 
 	// Converter caminho relativo se necessário
 	if !filepath.IsAbs(audioPath) {
@@ -177,9 +155,9 @@ except Exception as e:
 `, filepath.Join("backend", "utils"), audioPath, modelSize)
 
 	// Executar o código Python
-	cmd := exec.CommandContext(ctx, "python3", "-c", pythonCode)
+	cmd := command(ctx, "python3", "-c", pythonCode)
 	
-	output, err := cmd.CombinedOutput()
+	output, err := api.Scripts.Run(ctx, ScriptTranscription, cmd)
 	
 	if err != nil {
 		return &TranscriptionResponse{
@@ -197,6 +175,22 @@ except Exception as e:
 		}, nil
 	}
 
+	if result.Success {
+		metrics.ObserveWhisper(modelSize, result.Timing.ModelLoadTime, result.Timing.TranscribeTime, result.Timing.SpeedRatio)
+
+		// O script só informa as durações; os spans das etapas são
+		// reconstruídos a partir do fim da execução.
+		end := time.Now()
+		transcribeStart := end.Add(-secondsToDuration(result.Timing.TranscribeTime))
+		loadStart := transcribeStart.Add(-secondsToDuration(result.Timing.ModelLoadTime))
+		model := attribute.String("whisper.model", modelSize)
+		tracing.RecordSpan(ctx, "whisper model_load", loadStart, transcribeStart, model)
+		tracing.RecordSpan(ctx, "whisper transcribe", transcribeStart, end, model,
+			attribute.Float64("whisper.speed_ratio", result.Timing.SpeedRatio),
+			attribute.Float64("whisper.audio_duration_seconds", result.Duration),
+		)
+	}
+
 	return &result, nil
 }
 
@@ -206,10 +200,7 @@ func (api *API) ListAudioFilesHandler(c *fiber.Ctx) error {
 	
 	files, err := filepath.Glob(filepath.Join(audioDir, "*.mp3"))
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Failed to list audio files",
-			"details": err.Error(),
-		})
+		return err
 	}
 	
 	// Adicionar outros formatos
@@ -230,4 +221,8 @@ func (api *API) ListAudioFilesHandler(c *fiber.Ctx) error {
 		"count": len(fileNames),
 		"directory": audioDir,
 	})
-}
\ No newline at end of file
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -1,64 +1,71 @@
 package api
 
 import (
-	"context"
-	"fmt"
+	"errors"
+	"log/slog"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
 	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"github.com/josevitorrodriguess/any-song/backend/internal/service"
 )
 
 type SignInRequest struct {
-	IdToken string `json:"idToken"`
+	IdToken string `json:"idToken" validate:"required"`
+}
+
+// UpdateProfileRequest só traz os campos que o próprio usuário pode alterar.
+// profile_picture vazio remove a foto.
+type UpdateProfileRequest struct {
+	Name           *string `json:"name" validate:"omitnil,notblank,max=100"`
+	ProfilePicture *string `json:"profile_picture" validate:"omitnil,max=2048,eq=|http_url"`
+}
+
+// AdminUpdateUserRequest acrescenta a ativação da conta, que só um admin
+// pode alterar.
+type AdminUpdateUserRequest struct {
+	UpdateProfileRequest
+	IsActive *bool `json:"is_active"`
 }
 
 func (api *API) SignInHandler(c *fiber.Ctx) error {
 	var req SignInRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Dados inválidos",
-		})
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
 	}
 
-	if req.IdToken == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Token de autenticação é obrigatório",
-		})
-	}
-
-	decodedToken, err := api.Auth.VerifyIDToken(context.Background(), req.IdToken)
+	decodedToken, err := api.Verifier.VerifyIDToken(c.UserContext(), req.IdToken)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Token inválido",
-		})
+		return apperror.Wrap(err, apperror.CodeInvalidToken)
+	}
+	if err := api.AuthSessionService.CheckToken(c.UserContext(), decodedToken); err != nil {
+		return tokenError(err)
 	}
 
-	userEmail := decodedToken.Claims["email"].(string)
+	userEmail := decodedToken.Email()
+	if userEmail == "" {
+		return apperror.New(apperror.CodeEmailMissing)
+	}
 
 	// Primeiro, tenta buscar pelo Firebase UID
-	user, err := api.UserService.GetUserByFirebaseUID(decodedToken.UID)
-	if err != nil && err.Error() != fmt.Sprintf("usuário com Firebase UID '%s' não encontrado", decodedToken.UID) {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Erro ao verificar usuário por Firebase UID",
-		})
+	user, err := api.UserService.GetUserByFirebaseUID(c.UserContext(), decodedToken.UID)
+	if err != nil && !errors.Is(err, service.ErrUserNotFound) {
+		return err
 	}
 
 	// Se não encontrou pelo Firebase UID, tenta buscar pelo email
 	if user == nil {
-		user, err = api.UserService.GetUserByEmail(userEmail)
+		user, err = api.UserService.GetUserByEmail(c.UserContext(), userEmail)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Erro ao verificar usuário por email",
-			})
+			return err
 		}
 
 		// Se encontrou pelo email mas não tem Firebase UID, atualiza
 		if user != nil && user.FirebaseUID == "" {
 			user.FirebaseUID = decodedToken.UID
-			if err := api.UserService.UpdateUser(user); err != nil {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"error": "Erro ao atualizar Firebase UID do usuário",
-				})
+			if err := api.UserService.UpdateUser(c.UserContext(), user); err != nil {
+				return err
 			}
 		}
 	}
@@ -68,16 +75,28 @@ func (api *API) SignInHandler(c *fiber.Ctx) error {
 		newUser := &models.User{
 			FirebaseUID:    decodedToken.UID,
 			Email:          userEmail,
-			Name:           decodedToken.Claims["name"].(string),
-			ProfilePicture: decodedToken.Claims["picture"].(string),
+			Name:           decodedToken.Name(),
+			ProfilePicture: decodedToken.Picture(),
 			IsActive:       true,
 		}
 
-		if err := api.UserService.CreateUser(newUser); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Erro ao criar usuário",
-			})
+		if err := api.UserService.CreateUser(c.UserContext(), newUser); err != nil {
+			return err
+		}
+
+		// Quem está na lista de admins do Firestore antes de ter conta recebe
+		// o papel assim que a conta é criada.
+		if api.Firestore != nil {
+			if err := api.RBACService.BootstrapAdminIfListed(c.UserContext(), api.Firestore, decodedToken.UID); err != nil {
+				slog.WarnContext(c.UserContext(), "Falha no bootstrap de admins a partir do Firestore", "error", err)
+			}
 		}
+		// O estado em cache ainda diz que o usuário não tem conta.
+		api.AuthSessionService.Invalidate(c.UserContext(), decodedToken.UID)
+	}
+
+	if err := api.AuthSessionService.Touch(c.UserContext(), decodedToken, c.Get(fiber.HeaderUserAgent), c.IP()); err != nil {
+		slog.WarnContext(c.UserContext(), "Falha ao registrar sessão do usuário", "user_uid", decodedToken.UID, "error", err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -85,8 +104,8 @@ func (api *API) SignInHandler(c *fiber.Ctx) error {
 		"user": fiber.Map{
 			"uid":     decodedToken.UID,
 			"email":   userEmail,
-			"name":    decodedToken.Claims["name"],
-			"picture": decodedToken.Claims["picture"],
+			"name":    decodedToken.Name(),
+			"picture": decodedToken.Picture(),
 		},
 	})
 }
@@ -94,16 +113,12 @@ func (api *API) SignInHandler(c *fiber.Ctx) error {
 func (api *API) LogoutHandler(c *fiber.Ctx) error {
 	user, exists := GetUserFromContext(c)
 	if !exists {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Usuário não encontrado",
-		})
+		return apperror.New(apperror.CodeUnauthorized)
 	}
 
-	err := api.Auth.RevokeRefreshTokens(context.Background(), user.UID)
+	err := api.AuthSessionService.RevokeAll(c.UserContext(), user.UID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Erro ao fazer logout",
-		})
+		return err
 	}
 
 	return c.JSON(fiber.Map{
@@ -114,79 +129,81 @@ func (api *API) LogoutHandler(c *fiber.Ctx) error {
 func (api *API) FindUserByNameHandler(c *fiber.Ctx) error {
 	email := c.Params("username")
 	if email == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Nome é obrigatório",
-		})
+		return apperror.New(apperror.CodeBadRequest)
 	}
 
-	user, err := api.UserService.GetUserByEmail(email)
+	user, err := api.UserService.GetUserByEmail(c.UserContext(), email)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Erro ao buscar usuário",
-		})
+		return err
 	}
 
 	if user == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Usuário não encontrado",
-		})
+		return apperror.New(apperror.CodeUserNotFound)
 	}
 
 	return c.JSON(user)
 }
 
-func (api *API) UpdateUserHandler(c *fiber.Ctx) error {
-	var user models.User
-	if err := c.BodyParser(&user); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Dados inválidos",
-		})
-	}
+func (api *API) GetMeHandler(c *fiber.Ctx) error {
+	principal, _ := GetUserFromContext(c)
+	return api.getUser(c, principal.UID)
+}
 
-	if user.FirebaseUID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Firebase UID é obrigatório",
-		})
-	}
+func (api *API) UpdateMeHandler(c *fiber.Ctx) error {
+	principal, _ := GetUserFromContext(c)
 
-	existingUser, err := api.UserService.GetUserByFirebaseUID(user.FirebaseUID)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Erro ao buscar usuário",
-		})
+	var req UpdateProfileRequest
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
 	}
+	update := req.toUserUpdate()
+	return api.updateUser(c, principal.UID, update)
+}
 
-	if existingUser == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Usuário não encontrado",
-		})
-	}
+func (api *API) AdminGetUserHandler(c *fiber.Ctx) error {
+	return api.getUser(c, c.Params("firebaseUID"))
+}
 
-	if err := api.UserService.UpdateUser(&user); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err,
-		})
+func (api *API) AdminUpdateUserHandler(c *fiber.Ctx) error {
+	var req AdminUpdateUserRequest
+	if err := bindBody(c, &req); err != nil {
+		return validationFailed(err)
 	}
+	update := req.toUserUpdate()
+	update.IsActive = req.IsActive
+	return api.updateUser(c, c.Params("firebaseUID"), update)
+}
 
-	return c.JSON(user)
+// AdminDeleteUserHandler apaga a conta na hora, sem prazo de carência.
+func (api *API) AdminDeleteUserHandler(c *fiber.Ctx) error {
+	if err := api.AccountService.Purge(c.UserContext(), c.Params("firebaseUID")); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"message": "Usuário deletado com sucesso",
+	})
 }
 
-func (api *API) DeleteUserHandler(c *fiber.Ctx) error {
-	firebaseUID := c.Params("firebaseUID")
-	if firebaseUID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Firebase UID é obrigatório",
-		})
+func (api *API) getUser(c *fiber.Ctx, firebaseUID string) error {
+	user, err := api.UserService.GetUserByFirebaseUID(c.UserContext(), firebaseUID)
+	if err != nil {
+		return err
 	}
+	return c.JSON(user)
+}
 
-	err := api.UserService.DeleteUser(firebaseUID)
+func (api *API) updateUser(c *fiber.Ctx, firebaseUID string, update service.UserUpdate) error {
+	user, err := api.UserService.UpdateProfile(c.UserContext(), firebaseUID, update)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err,
-		})
+		return err
 	}
+	return c.JSON(user)
+}
 
-	return c.JSON(fiber.Map{
-		"message": "Usuário deletado com sucesso",
-	})
-}
\ No newline at end of file
+func (req UpdateProfileRequest) toUserUpdate() service.UserUpdate {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		req.Name = &name
+	}
+	return service.UserUpdate{Name: req.Name, ProfilePicture: req.ProfilePicture}
+}
@@ -0,0 +1,143 @@
+package api_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apitest"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+	"github.com/josevitorrodriguess/any-song/backend/internal/auth"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+)
+
+func TestUserHandlers(t *testing.T) {
+	var logoutToken string
+	runHandlerCases(t, []handlerCase{
+		{
+			name: "primeiro login cria a conta e a sessão",
+			setup: func(h *apitest.Harness) apitest.Request {
+				token, _, _ := h.Issuer.Issue(auth.LocalIdentity{UID: "new-user", Email: "new@example.com", Name: "Nova"})
+				return apitest.Request{Method: http.MethodPost, Path: "/signin", Body: map[string]string{"idToken": token}}
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				user, err := h.Store.Users().FindByFirebaseUID(t.Context(), "new-user")
+				if err != nil {
+					t.Fatalf("usuário não foi criado: %v", err)
+				}
+				if user.Email != "new@example.com" || user.Name != "Nova" || !user.IsActive {
+					t.Errorf("usuário criado = %+v", user)
+				}
+				sessions, _ := h.Store.AuthSessions().ListActive(t.Context(), "new-user", time.Time{})
+				if len(sessions) != 1 {
+					t.Errorf("sessões = %d, esperado 1", len(sessions))
+				}
+			},
+		},
+		{
+			name: "login com token inválido",
+			setup: func(h *apitest.Harness) apitest.Request {
+				return apitest.Request{Method: http.MethodPost, Path: "/signin", Body: map[string]string{"idToken": "nope"}}
+			},
+			status: http.StatusUnauthorized,
+			code:   apperror.CodeInvalidToken,
+		},
+		{
+			name: "me sem login",
+			setup: func(h *apitest.Harness) apitest.Request {
+				return apitest.Request{Method: http.MethodGet, Path: "/me/"}
+			},
+			status: http.StatusUnauthorized,
+			code:   apperror.CodeUnauthorized,
+		},
+		{
+			name: "me devolve o próprio usuário",
+			setup: func(h *apitest.Harness) apitest.Request {
+				return apitest.Request{Method: http.MethodGet, Path: "/me/", Token: signedIn(h, "alice")}
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				var user models.User
+				resp.JSON(t, &user)
+				if user.FirebaseUID != "alice" || user.Email != "alice@example.com" {
+					t.Errorf("usuário = %+v", user)
+				}
+			},
+		},
+		{
+			name: "conta desativada",
+			setup: func(h *apitest.Harness) apitest.Request {
+				token := signedIn(h, "alice")
+				h.SetActive("alice", false)
+				return apitest.Request{Method: http.MethodGet, Path: "/me/", Token: token}
+			},
+			status: http.StatusForbidden,
+			code:   apperror.CodeAccountDisabled,
+		},
+		{
+			name: "atualizar o perfil grava e audita",
+			setup: func(h *apitest.Harness) apitest.Request {
+				return apitest.Request{Method: http.MethodPatch, Path: "/me/", Token: signedIn(h, "alice"), Body: map[string]string{"name": "  Alice Souza "}}
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				user, _ := h.Store.Users().FindByFirebaseUID(t.Context(), "alice")
+				if user.Name != "Alice Souza" {
+					t.Errorf("nome = %q", user.Name)
+				}
+				if actions := auditActions(t, h); !reflect.DeepEqual(actions, []string{models.AuditUserUpdate}) {
+					t.Errorf("audit log = %v", actions)
+				}
+			},
+		},
+		{
+			name: "nome em branco",
+			setup: func(h *apitest.Harness) apitest.Request {
+				return apitest.Request{Method: http.MethodPatch, Path: "/me/", Token: signedIn(h, "alice"), Body: map[string]string{"name": " "}}
+			},
+			status: http.StatusUnprocessableEntity,
+			code:   apperror.CodeValidationFailed,
+		},
+		{
+			name: "busca de usuário por e-mail",
+			setup: func(h *apitest.Harness) apitest.Request {
+				h.SeedUser(models.User{FirebaseUID: "bob"})
+				return apitest.Request{Method: http.MethodGet, Path: "/user/bob@example.com", Token: signedIn(h, "alice")}
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "busca de usuário inexistente",
+			setup: func(h *apitest.Harness) apitest.Request {
+				return apitest.Request{Method: http.MethodGet, Path: "/user/ghost@example.com", Token: signedIn(h, "alice")}
+			},
+			status: http.StatusNotFound,
+			code:   apperror.CodeUserNotFound,
+		},
+		{
+			name: "logout revoga o token",
+			setup: func(h *apitest.Harness) apitest.Request {
+				logoutToken = signedIn(h, "alice")
+				return apitest.Request{Method: http.MethodPost, Path: "/logout", Token: logoutToken}
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, h *apitest.Harness, resp *apitest.Response) {
+				after := h.Do(apitest.Request{Method: http.MethodGet, Path: "/me/", Token: logoutToken})
+				if after.Status != http.StatusUnauthorized || after.ErrorCode() != string(apperror.CodeSessionRevoked) {
+					t.Errorf("depois do logout: %d %s", after.Status, after.Body)
+				}
+			},
+		},
+		{
+			name: "revogar sessão inexistente",
+			setup: func(h *apitest.Harness) apitest.Request {
+				return apitest.Request{Method: http.MethodDelete, Path: "/me/sessions/" + uuid.NewString(), Token: signedIn(h, "alice")}
+			},
+			status: http.StatusNotFound,
+			code:   apperror.CodeAuthSessionNotFound,
+		},
+	})
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/josevitorrodriguess/any-song/backend/internal/apperror"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+)
+
+// validate lê as tags `validate` dos structs de requisição. Os nomes dos
+// campos nos erros vêm das tags json, query ou params, para o cliente
+// reconhecer o campo que enviou (itens de listas saem como "scopes[1]").
+var validate = newValidator()
+
+// FieldError descreve uma regra violada por um campo da requisição.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError agrupa todos os campos inválidos de uma requisição. As
+// mensagens só são montadas na resposta, no idioma do cliente.
+type ValidationError struct {
+	errs validator.ValidationErrors
+}
+
+func (e *ValidationError) Error() string {
+	fields := e.Fields(apperror.DefaultLanguage)
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Fields descreve cada regra violada, com a mensagem em lang.
+func (e *ValidationError) Fields(lang apperror.Language) []FieldError {
+	fields := make([]FieldError, len(e.errs))
+	for i, fe := range e.errs {
+		fields[i] = FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fieldMessage(fe, lang),
+		}
+	}
+	return fields
+}
+
+// Localize monta os detalhes da resposta 422.
+func (e *ValidationError) Localize(lang apperror.Language) any {
+	return fiber.Map{"fields": e.Fields(lang)}
+}
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query", "params"} {
+			name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+
+	// Enums que já têm validação no pacote models
+	v.RegisterValidation("visibility", stringRule(models.IsValidPlaylistVisibility))
+	v.RegisterValidation("duetpart", stringRule(models.IsValidDuetPart))
+	v.RegisterValidation("apikeyscope", stringRule(models.IsValidAPIKeyScope))
+	return v
+}
+
+func stringRule(valid func(string) bool) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		return valid(fl.Field().String())
+	}
+}
+
+// bindBody decodifica o corpo da requisição em dest e valida as tags. Erros de
+// decodificação voltam como estão; regras violadas voltam como
+// *ValidationError.
+func bindBody(c *fiber.Ctx, dest interface{}) error {
+	if err := c.BodyParser(dest); err != nil {
+		return err
+	}
+	return validateStruct(dest)
+}
+
+// bindQuery faz o mesmo que bindBody com a query string (tags `query`).
+func bindQuery(c *fiber.Ctx, dest interface{}) error {
+	if err := c.QueryParser(dest); err != nil {
+		return err
+	}
+	return validateStruct(dest)
+}
+
+// bindParams faz o mesmo que bindBody com os parâmetros da rota (tags
+// `params`).
+func bindParams(c *fiber.Ctx, dest interface{}) error {
+	if err := c.ParamsParser(dest); err != nil {
+		return err
+	}
+	return validateStruct(dest)
+}
+
+func validateStruct(dest interface{}) error {
+	err := validate.Struct(dest)
+	if err == nil {
+		return nil
+	}
+
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	return &ValidationError{errs: errs}
+}
+
+// validationFailed vira 422 com a lista de campos inválidos, ou 400 quando
+// nem foi possível decodificar a requisição.
+func validationFailed(err error) error {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return apperror.Wrap(err, apperror.CodeValidationFailed).WithDetails(validationErr)
+	}
+	return apperror.Wrap(err, apperror.CodeBadRequest)
+}
+
+// fieldMessages são os modelos das mensagens por campo. O primeiro %s é o
+// nome do campo; o segundo, quando existe, o parâmetro da regra.
+var fieldMessages = map[apperror.Language]map[string]string{
+	apperror.PortugueseBR: {
+		"required":   "%s é obrigatório",
+		"min.string": "%s deve ter pelo menos %s caracteres",
+		"min.list":   "%s deve ter pelo menos %s itens",
+		"min":        "%s deve ser no mínimo %s",
+		"max.string": "%s deve ter no máximo %s caracteres",
+		"max.list":   "%s deve ter no máximo %s itens",
+		"max":        "%s deve ser no máximo %s",
+		"future":     "%s deve estar no futuro",
+		"gt":         "%s deve ser maior que %s",
+		"oneof":      "%s deve ser um de: %s",
+		"uuid":       "%s deve ser um UUID válido",
+		"email":      "%s deve ser um e-mail válido",
+		"url":        "%s deve ser uma URL válida",
+		"datetime":   "%s deve estar no formato RFC 3339",
+		"unique":     "%s não pode ter itens repetidos",
+		"invalid":    "%s é inválido",
+	},
+	apperror.English: {
+		"required":   "%s is required",
+		"min.string": "%s must be at least %s characters long",
+		"min.list":   "%s must have at least %s items",
+		"min":        "%s must be at least %s",
+		"max.string": "%s must be at most %s characters long",
+		"max.list":   "%s must have at most %s items",
+		"max":        "%s must be at most %s",
+		"future":     "%s must be in the future",
+		"gt":         "%s must be greater than %s",
+		"oneof":      "%s must be one of: %s",
+		"uuid":       "%s must be a valid UUID",
+		"email":      "%s must be a valid e-mail",
+		"url":        "%s must be a valid URL",
+		"datetime":   "%s must be in RFC 3339 format",
+		"unique":     "%s must not contain duplicate items",
+		"invalid":    "%s is invalid",
+	},
+}
+
+func fieldMessage(fe validator.FieldError, lang apperror.Language) string {
+	key, param := fieldMessageKey(fe)
+	template, ok := fieldMessages[lang][key]
+	if !ok {
+		template = fieldMessages[apperror.DefaultLanguage][key]
+	}
+	if param == "" {
+		return fmt.Sprintf(template, fe.Field())
+	}
+	return fmt.Sprintf(template, fe.Field(), param)
+}
+
+// fieldMessageKey escolhe o modelo da mensagem e o parâmetro que entra nele.
+func fieldMessageKey(fe validator.FieldError) (string, string) {
+	kind := fe.Kind()
+	if kind == reflect.Ptr {
+		kind = fe.Type().Elem().Kind()
+	}
+	sizeKey := func(rule string) string {
+		switch kind {
+		case reflect.String:
+			return rule + ".string"
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return rule + ".list"
+		}
+		return rule
+	}
+
+	switch fe.Tag() {
+	case "required", "notblank":
+		return "required", ""
+	case "min", "gte":
+		return sizeKey("min"), fe.Param()
+	case "max", "lte":
+		return sizeKey("max"), fe.Param()
+	case "gt":
+		if fe.Type() == reflect.TypeOf(time.Time{}) || fe.Type() == reflect.TypeOf(&time.Time{}) {
+			return "future", ""
+		}
+		return "gt", fe.Param()
+	case "oneof":
+		return "oneof", strings.Join(strings.Fields(fe.Param()), ", ")
+	case "uuid", "email", "datetime", "unique":
+		return fe.Tag(), ""
+	case "url", "http_url", "eq=|http_url":
+		return "url", ""
+	case "visibility":
+		return "oneof", strings.Join([]string{models.PlaylistPrivate, models.PlaylistUnlisted, models.PlaylistPublic}, ", ")
+	case "duetpart":
+		return "oneof", strings.Join([]string{models.DuetPartA, models.DuetPartB, models.DuetPartBoth}, ", ")
+	case "apikeyscope":
+		return "oneof", strings.Join(models.APIKeyScopes, ", ")
+	}
+	return "invalid", ""
+}
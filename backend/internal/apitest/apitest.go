@@ -0,0 +1,227 @@
+// Package apitest monta a API inteira, com as rotas e os middlewares de
+// produção, sobre fakes: repositórios em memória, Redis do miniredis, tokens
+// do emissor local, bucket em memória e scripts que não sobem processos.
+// Rotas de serviços que ainda usam o GORM direto (playlists, histórico,
+// party...) não funcionam aqui.
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/gofiber/fiber/v2"
+	"github.com/josevitorrodriguess/any-song/backend/internal/api"
+	"github.com/josevitorrodriguess/any-song/backend/internal/auth"
+	"github.com/josevitorrodriguess/any-song/backend/internal/config"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"github.com/josevitorrodriguess/any-song/backend/internal/repository"
+)
+
+const signingKey = "apitest-signing-key-0123456789abcdef"
+
+type Harness struct {
+	t       testing.TB
+	App     *fiber.App
+	API     *api.API
+	Config  *config.Config
+	Store   *repository.MemoryStore
+	Redis   *miniredis.Miniredis
+	Issuer  *auth.LocalIssuer
+	Storage *MemoryStorage
+	Scripts *FakeScripts
+}
+
+// Option ajusta a configuração antes de a API ser montada.
+type Option func(cfg *config.Config)
+
+// New sobe uma API nova, sem estado compartilhado com outras. O miniredis e o
+// cliente são fechados no fim do teste.
+func New(t testing.TB, opts ...Option) *Harness {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.Environment = config.EnvironmentTest
+	cfg.Auth.Provider = config.AuthProviderLocal
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	issuer, err := auth.NewLocalIssuer([]byte(signingKey), cfg.Auth.LocalTokenTTL.Duration)
+	if err != nil {
+		t.Fatalf("apitest: emissor local: %v", err)
+	}
+	mr := miniredis.RunT(t)
+	redisClient := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	// Os repositórios em memória guardam as strings como chegam; sem
+	// Immutable, as que vêm de c.Params apontam para o buffer da requisição,
+	// que o fasthttp reaproveita.
+	app := fiber.New(fiber.Config{ErrorHandler: api.ErrorHandler, Immutable: true})
+
+	h := &Harness{
+		t:       t,
+		App:     app,
+		Config:  cfg,
+		Store:   repository.NewMemoryStore(),
+		Redis:   mr,
+		Issuer:  issuer,
+		Storage: NewMemoryStorage(),
+		Scripts: NewFakeScripts(),
+	}
+	h.API = api.New(cfg, h.App, api.Deps{
+		Store:       h.Store,
+		Redis:       redisClient,
+		Verifier:    issuer,
+		LocalIssuer: issuer,
+		Storage:     h.Storage,
+		Scripts:     h.Scripts,
+		Capabilities: api.Capabilities{
+			api.CapabilityAuth:      {Enabled: true, Available: true},
+			api.CapabilityCache:     {Enabled: true, Available: true},
+			api.CapabilityStorage:   {Enabled: true, Available: true},
+			api.CapabilityFirestore: {Reason: "não usado com AUTH_PROVIDER=local"},
+		},
+	})
+	h.API.SetupRoutes()
+	return h
+}
+
+// SeedUser grava o usuário; e-mail e nome vazios são derivados do UID.
+func (h *Harness) SeedUser(user models.User) models.User {
+	h.t.Helper()
+	if user.Email == "" {
+		user.Email = user.FirebaseUID + "@example.com"
+	}
+	if user.Name == "" {
+		user.Name = user.FirebaseUID
+	}
+	if err := h.Store.Users().Create(h.t.Context(), &user); err != nil {
+		h.t.Fatalf("apitest: criar usuário %s: %v", user.FirebaseUID, err)
+	}
+	return user
+}
+
+// SetActive ativa ou desativa a conta direto no repositório, sem passar pelo
+// cache.
+func (h *Harness) SetActive(userUID string, active bool) {
+	h.t.Helper()
+	user := models.User{FirebaseUID: userUID}
+	if err := h.Store.Users().Update(h.t.Context(), &user, repository.UserUpdate{IsActive: &active}); err != nil {
+		h.t.Fatalf("apitest: alterar is_active de %s: %v", userUID, err)
+	}
+}
+
+func (h *Harness) GrantRole(userUID, role string) {
+	h.t.Helper()
+	userRole := models.UserRole{UserUID: userUID, RoleName: role, GrantedBy: "apitest"}
+	if _, err := h.Store.Roles().Grant(h.t.Context(), &userRole); err != nil {
+		h.t.Fatalf("apitest: conceder %s a %s: %v", role, userUID, err)
+	}
+}
+
+func (h *Harness) SeedArtist(name string) models.Artist {
+	h.t.Helper()
+	artist := models.Artist{Name: name}
+	if err := h.API.ArtistService.CreateArtist(h.t.Context(), &artist); err != nil {
+		h.t.Fatalf("apitest: criar artista %s: %v", name, err)
+	}
+	return artist
+}
+
+// Token emite um ID token para o usuário, como o provedor faria no login.
+func (h *Harness) Token(user models.User) string {
+	h.t.Helper()
+	token, _, err := h.Issuer.Issue(auth.LocalIdentity{
+		UID:     user.FirebaseUID,
+		Email:   user.Email,
+		Name:    user.Name,
+		Picture: user.ProfilePicture,
+	})
+	if err != nil {
+		h.t.Fatalf("apitest: emitir token: %v", err)
+	}
+	return token
+}
+
+// Request descreve uma chamada. Body é serializado em JSON, a não ser que já
+// seja []byte ou string.
+type Request struct {
+	Method  string
+	Path    string
+	Token   string
+	Body    any
+	Headers map[string]string
+}
+
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Do executa a requisição no app, sem rede.
+func (h *Harness) Do(req Request) *Response {
+	h.t.Helper()
+
+	var body io.Reader
+	switch b := req.Body.(type) {
+	case nil:
+	case []byte:
+		body = bytes.NewReader(b)
+	case string:
+		body = bytes.NewReader([]byte(b))
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			h.t.Fatalf("apitest: serializar corpo: %v", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	httpReq := httptest.NewRequest(req.Method, req.Path, body)
+	if body != nil {
+		httpReq.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	if req.Token != "" {
+		httpReq.Header.Set(fiber.HeaderAuthorization, "Bearer "+req.Token)
+	}
+	for name, value := range req.Headers {
+		httpReq.Header.Set(name, value)
+	}
+
+	resp, err := h.App.Test(httpReq, int(10*time.Second/time.Millisecond))
+	if err != nil {
+		h.t.Fatalf("apitest: %s %s: %v", req.Method, req.Path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		h.t.Fatalf("apitest: ler resposta de %s %s: %v", req.Method, req.Path, err)
+	}
+	return &Response{Status: resp.StatusCode, Header: resp.Header, Body: data}
+}
+
+// JSON decodifica o corpo em v, falhando o teste se não for JSON válido.
+func (r *Response) JSON(t testing.TB, v any) {
+	t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("apitest: corpo não é JSON (%v): %s", err, r.Body)
+	}
+}
+
+// ErrorCode devolve o campo code do envelope de erro, ou "" se não houver.
+func (r *Response) ErrorCode() string {
+	var envelope api.ErrorResponse
+	if err := json.Unmarshal(r.Body, &envelope); err != nil {
+		return ""
+	}
+	return string(envelope.Code)
+}
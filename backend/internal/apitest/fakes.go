@@ -0,0 +1,144 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/storage"
+)
+
+// MemoryStorage é um bucket em memória que implementa service.ObjectStorage.
+type MemoryStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{objects: map[string][]byte{}}
+}
+
+func (s *MemoryStorage) Put(name string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[name] = append([]byte(nil), data...)
+}
+
+func (s *MemoryStorage) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for name := range s.objects {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *MemoryStorage) OpenObject(ctx context.Context, name string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[name]
+	if !ok {
+		return nil, storage.ErrObjectNotExist
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *MemoryStorage) PutObject(ctx context.Context, name string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.Put(name, data)
+	return nil
+}
+
+func (s *MemoryStorage) DeletePrefix(ctx context.Context, prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name := range s.objects {
+		if strings.HasPrefix(name, prefix) {
+			delete(s.objects, name)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStorage) Check(ctx context.Context) error {
+	return nil
+}
+
+func (s *MemoryStorage) Close() error {
+	return nil
+}
+
+// ScriptFunc faz o papel de um script Python: recebe o comando montado pelo
+// handler (argumentos em cmd.Args, variáveis em cmd.Env) e devolve a saída.
+type ScriptFunc func(cmd *exec.Cmd) ([]byte, error)
+
+// FakeScripts implementa api.ScriptRunner sem subir processos. Script sem
+// resposta registrada falha, como um provedor fora do ar.
+type FakeScripts struct {
+	mu      sync.Mutex
+	scripts map[string]ScriptFunc
+	calls   map[string][]*exec.Cmd
+}
+
+func NewFakeScripts() *FakeScripts {
+	return &FakeScripts{
+		scripts: map[string]ScriptFunc{},
+		calls:   map[string][]*exec.Cmd{},
+	}
+}
+
+// Handle registra a resposta do script (api.ScriptSearch, api.ScriptLyrics...).
+func (f *FakeScripts) Handle(script string, fn ScriptFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scripts[script] = fn
+}
+
+// Respond registra uma saída fixa para o script.
+func (f *FakeScripts) Respond(script string, output string) {
+	f.Handle(script, func(*exec.Cmd) ([]byte, error) {
+		return []byte(output), nil
+	})
+}
+
+// Calls devolve os comandos recebidos pelo script, na ordem.
+func (f *FakeScripts) Calls(script string) []*exec.Cmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*exec.Cmd(nil), f.calls[script]...)
+}
+
+func (f *FakeScripts) Run(ctx context.Context, script string, cmd *exec.Cmd) ([]byte, error) {
+	f.mu.Lock()
+	fn := f.scripts[script]
+	f.calls[script] = append(f.calls[script], cmd)
+	f.mu.Unlock()
+
+	if fn == nil {
+		return nil, fmt.Errorf("script %s sem resposta no fake", script)
+	}
+	return fn(cmd)
+}
+
+// Env devolve o valor da variável name no ambiente do comando.
+func Env(cmd *exec.Cmd, name string) string {
+	value := ""
+	for _, entry := range cmd.Env {
+		if key, v, ok := strings.Cut(entry, "="); ok && key == name {
+			value = v
+		}
+	}
+	return value
+}
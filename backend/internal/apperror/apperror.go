@@ -0,0 +1,111 @@
+// Package apperror define o erro que os handlers devolvem ao Fiber: um código
+// estável que o cliente pode tratar, o status HTTP e detalhes seguros de
+// expor. A mensagem vem do catálogo, no idioma do Accept-Language.
+package apperror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Error é um erro da aplicação pronto para virar resposta. A causa (Unwrap)
+// só aparece nos logs; o cliente recebe o código, a mensagem do catálogo e
+// Details.
+type Error struct {
+	Code    Code
+	Status  int
+	Details any
+	cause   error
+}
+
+// Localizer é implementado por Details que dependem do idioma, como a lista
+// de campos inválidos de uma validação.
+type Localizer interface {
+	Localize(lang Language) any
+}
+
+// New cria o erro de um código, com o status do catálogo.
+func New(code Code) *Error {
+	return &Error{Code: code, Status: code.Status()}
+}
+
+// Wrap cria o erro de um código guardando err como causa.
+func Wrap(err error, code Code) *Error {
+	e := New(code)
+	e.cause = err
+	return e
+}
+
+// Internal embrulha uma falha inesperada. A resposta é um 500 genérico e a
+// causa vai para o log.
+func Internal(err error) *Error {
+	return Wrap(err, CodeInternal)
+}
+
+// WithDetails devolve uma cópia com detalhes para o cliente. Nunca passe
+// mensagens de erro ou saída de scripts: tudo aqui é serializado.
+func (e *Error) WithDetails(details any) *Error {
+	out := *e
+	out.Details = details
+	return &out
+}
+
+// WithStatus devolve uma cópia com outro status, para códigos cujo status
+// depende do contexto.
+func (e *Error) WithStatus(status int) *Error {
+	out := *e
+	out.Status = status
+	return &out
+}
+
+func (e *Error) Error() string {
+	message := Message(e.Code, DefaultLanguage)
+	if e.cause != nil {
+		return message + ": " + e.cause.Error()
+	}
+	return message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// From devolve o *Error em err, se houver um na cadeia.
+func From(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// FromStatus converte um status HTTP (de um *fiber.Error, por exemplo) no
+// código genérico equivalente.
+func FromStatus(status int) *Error {
+	var code Code
+	switch {
+	case status == http.StatusBadRequest:
+		code = CodeBadRequest
+	case status == http.StatusUnauthorized:
+		code = CodeUnauthorized
+	case status == http.StatusForbidden:
+		code = CodeForbidden
+	case status == http.StatusNotFound:
+		code = CodeRouteNotFound
+	case status == http.StatusMethodNotAllowed:
+		code = CodeMethodNotAllowed
+	case status == http.StatusRequestEntityTooLarge:
+		code = CodePayloadTooLarge
+	case status == http.StatusUnsupportedMediaType:
+		code = CodeUnsupportedMediaType
+	case status == http.StatusTooManyRequests:
+		code = CodeRateLimited
+	case status == http.StatusServiceUnavailable:
+		code = CodeUnavailable
+	case status >= 400 && status < 500:
+		code = CodeBadRequest
+	default:
+		code = CodeInternal
+	}
+	return New(code).WithStatus(status)
+}
@@ -0,0 +1,333 @@
+package apperror
+
+import (
+	"net/http"
+	"sort"
+)
+
+// Code identifica o erro para o cliente. Os valores fazem parte do contrato
+// da API: podem ganhar novos, mas não mudar de nome.
+type Code string
+
+// Erros genéricos
+const (
+	CodeBadRequest           Code = "bad_request"
+	CodeValidationFailed     Code = "validation_failed"
+	CodeUnauthorized         Code = "unauthorized"
+	CodeInvalidToken         Code = "invalid_token"
+	CodeSessionRevoked       Code = "session_revoked"
+	CodeInvalidAPIKey        Code = "invalid_api_key"
+	CodeAccountDisabled      Code = "account_disabled"
+	CodeForbidden            Code = "forbidden"
+	CodeAdminRequired        Code = "admin_required"
+	CodeAPIKeyScope          Code = "api_key_scope_insufficient"
+	CodeAPIKeyNotAllowed     Code = "api_key_not_allowed"
+	CodeRouteNotFound        Code = "route_not_found"
+	CodeMethodNotAllowed     Code = "method_not_allowed"
+	CodePayloadTooLarge      Code = "payload_too_large"
+	CodeUnsupportedMediaType Code = "unsupported_media_type"
+	CodeUpgradeRequired      Code = "upgrade_required"
+	CodeRateLimited          Code = "rate_limited"
+	CodeQuotaExceeded        Code = "quota_exceeded"
+	CodeUnavailable          Code = "unavailable"
+	CodeCapabilityDisabled   Code = "capability_unavailable"
+	CodeInternal             Code = "internal_error"
+)
+
+// Erros de domínio
+const (
+	CodeEmailMissing            Code = "email_missing"
+	CodeUserNotFound            Code = "user_not_found"
+	CodeRoleNotFound            Code = "role_not_found"
+	CodeArtistNotFound          Code = "artist_not_found"
+	CodeSongNotFound            Code = "song_not_found"
+	CodeAuthSessionNotFound     Code = "auth_session_not_found"
+	CodeAPIKeyNotFound          Code = "api_key_not_found"
+	CodeInvalidAPIKeyScope      Code = "invalid_api_key_scope"
+	CodeDeletionNotScheduled    Code = "deletion_not_scheduled"
+	CodePurgeUnavailable        Code = "purge_unavailable"
+	CodePlaylistNotFound        Code = "playlist_not_found"
+	CodePlaylistItemNotFound    Code = "playlist_item_not_found"
+	CodePlaylistForbidden       Code = "playlist_forbidden"
+	CodePlaylistVersionConflict Code = "playlist_version_conflict"
+	CodeInvalidPlaylistOrder    Code = "invalid_playlist_order"
+	CodeInvalidVisibility       Code = "invalid_visibility"
+	CodeSessionNotFound         Code = "session_not_found"
+	CodeSessionAlreadyEnded     Code = "session_already_ended"
+	CodeHistoryBusy             Code = "history_busy"
+	CodePartyRoomNotFound       Code = "party_room_not_found"
+	CodePartyNotHost            Code = "party_not_host"
+	CodePartyEmptyQueue         Code = "party_empty_queue"
+	CodePartyNothingToPlay      Code = "party_nothing_to_play"
+	CodePartyRoomBusy           Code = "party_room_busy"
+	CodeInvalidLyricPart        Code = "invalid_lyric_part"
+	CodePartnerNotFound         Code = "duet_partner_not_found"
+	CodeNotSessionMember        Code = "not_session_member"
+	CodePartNotInSession        Code = "part_not_in_session"
+	CodeSessionNotDuet          Code = "session_not_duet"
+	CodeDuetWithYourself        Code = "duet_with_yourself"
+	CodeDownloadFailed          Code = "download_failed"
+	CodeSearchFailed            Code = "search_failed"
+	CodeLyricsFailed            Code = "lyrics_failed"
+	CodeTranscriptionFailed     Code = "transcription_failed"
+)
+
+type entry struct {
+	status   int
+	messages map[Language]string
+}
+
+var catalog = map[Code]entry{
+	CodeBadRequest: {http.StatusBadRequest, map[Language]string{
+		PortugueseBR: "Requisição inválida",
+		English:      "Invalid request",
+	}},
+	CodeValidationFailed: {http.StatusUnprocessableEntity, map[Language]string{
+		PortugueseBR: "Dados inválidos",
+		English:      "Invalid data",
+	}},
+	CodeUnauthorized: {http.StatusUnauthorized, map[Language]string{
+		PortugueseBR: "Token de autorização necessário",
+		English:      "Authorization token required",
+	}},
+	CodeInvalidToken: {http.StatusUnauthorized, map[Language]string{
+		PortugueseBR: "Token inválido ou expirado",
+		English:      "Invalid or expired token",
+	}},
+	CodeSessionRevoked: {http.StatusUnauthorized, map[Language]string{
+		PortugueseBR: "Sessão encerrada. Faça login novamente.",
+		English:      "Session ended. Please sign in again.",
+	}},
+	CodeInvalidAPIKey: {http.StatusUnauthorized, map[Language]string{
+		PortugueseBR: "API key inválida, expirada ou revogada",
+		English:      "Invalid, expired or revoked API key",
+	}},
+	CodeAccountDisabled: {http.StatusForbidden, map[Language]string{
+		PortugueseBR: "Conta desativada",
+		English:      "Account disabled",
+	}},
+	CodeForbidden: {http.StatusForbidden, map[Language]string{
+		PortugueseBR: "Acesso negado. Permissão insuficiente.",
+		English:      "Access denied. Insufficient permission.",
+	}},
+	CodeAdminRequired: {http.StatusForbidden, map[Language]string{
+		PortugueseBR: "Acesso negado. Requer permissão de administrador.",
+		English:      "Access denied. Administrator permission required.",
+	}},
+	CodeAPIKeyScope: {http.StatusForbidden, map[Language]string{
+		PortugueseBR: "Acesso negado. Escopo da API key insuficiente.",
+		English:      "Access denied. API key scope is insufficient.",
+	}},
+	CodeAPIKeyNotAllowed: {http.StatusForbidden, map[Language]string{
+		PortugueseBR: "Acesso negado. Rota indisponível para API keys.",
+		English:      "Access denied. This route is not available to API keys.",
+	}},
+	CodeRouteNotFound: {http.StatusNotFound, map[Language]string{
+		PortugueseBR: "Rota não encontrada",
+		English:      "Route not found",
+	}},
+	CodeMethodNotAllowed: {http.StatusMethodNotAllowed, map[Language]string{
+		PortugueseBR: "Método não permitido",
+		English:      "Method not allowed",
+	}},
+	CodePayloadTooLarge: {http.StatusRequestEntityTooLarge, map[Language]string{
+		PortugueseBR: "Corpo da requisição grande demais",
+		English:      "Request body too large",
+	}},
+	CodeUnsupportedMediaType: {http.StatusUnsupportedMediaType, map[Language]string{
+		PortugueseBR: "Tipo de conteúdo não suportado",
+		English:      "Unsupported content type",
+	}},
+	CodeUpgradeRequired: {http.StatusUpgradeRequired, map[Language]string{
+		PortugueseBR: "Esta rota só aceita WebSocket",
+		English:      "This route only accepts WebSocket",
+	}},
+	CodeRateLimited: {http.StatusTooManyRequests, map[Language]string{
+		PortugueseBR: "Muitas requisições. Tente novamente mais tarde.",
+		English:      "Too many requests. Please try again later.",
+	}},
+	CodeQuotaExceeded: {http.StatusTooManyRequests, map[Language]string{
+		PortugueseBR: "Cota diária esgotada",
+		English:      "Daily quota exhausted",
+	}},
+	CodeUnavailable: {http.StatusServiceUnavailable, map[Language]string{
+		PortugueseBR: "Serviço indisponível",
+		English:      "Service unavailable",
+	}},
+	CodeCapabilityDisabled: {http.StatusServiceUnavailable, map[Language]string{
+		PortugueseBR: "Recurso indisponível",
+		English:      "Feature unavailable",
+	}},
+	CodeInternal: {http.StatusInternalServerError, map[Language]string{
+		PortugueseBR: "Erro interno do servidor",
+		English:      "Internal server error",
+	}},
+
+	CodeEmailMissing: {http.StatusBadRequest, map[Language]string{
+		PortugueseBR: "Token sem e-mail",
+		English:      "Token has no e-mail",
+	}},
+	CodeUserNotFound: {http.StatusNotFound, map[Language]string{
+		PortugueseBR: "Usuário não encontrado",
+		English:      "User not found",
+	}},
+	CodeRoleNotFound: {http.StatusNotFound, map[Language]string{
+		PortugueseBR: "Papel não encontrado",
+		English:      "Role not found",
+	}},
+	CodeArtistNotFound: {http.StatusNotFound, map[Language]string{
+		PortugueseBR: "Artista não encontrado",
+		English:      "Artist not found",
+	}},
+	CodeSongNotFound: {http.StatusNotFound, map[Language]string{
+		PortugueseBR: "Música não encontrada",
+		English:      "Song not found",
+	}},
+	CodeAuthSessionNotFound: {http.StatusNotFound, map[Language]string{
+		PortugueseBR: "Sessão de login não encontrada",
+		English:      "Login session not found",
+	}},
+	CodeAPIKeyNotFound: {http.StatusNotFound, map[Language]string{
+		PortugueseBR: "API key não encontrada",
+		English:      "API key not found",
+	}},
+	CodeInvalidAPIKeyScope: {http.StatusBadRequest, map[Language]string{
+		PortugueseBR: "Escopo de API key inválido",
+		English:      "Invalid API key scope",
+	}},
+	CodeDeletionNotScheduled: {http.StatusNotFound, map[Language]string{
+		PortugueseBR: "Não há exclusão de conta agendada",
+		English:      "No account deletion is scheduled",
+	}},
+	CodePurgeUnavailable: {http.StatusServiceUnavailable, map[Language]string{
+		PortugueseBR: "Exclusão de contas indisponível no momento",
+		English:      "Account deletion is currently unavailable",
+	}},
+	CodePlaylistNotFound: {http.StatusNotFound, map[Language]string{
+		PortugueseBR: "Playlist não encontrada",
+		English:      "Playlist not found",
+	}},
+	CodePlaylistItemNotFound: {http.StatusNotFound, map[Language]string{
+		PortugueseBR: "Item não encontrado na playlist",
+		English:      "Item not found in playlist",
+	}},
+	CodePlaylistForbidden: {http.StatusForbidden, map[Language]string{
+		PortugueseBR: "Sem permissão para alterar esta playlist",
+		English:      "You are not allowed to change this playlist",
+	}},
+	CodePlaylistVersionConflict: {http.StatusConflict, map[Language]string{
+		PortugueseBR: "A playlist foi alterada por outra requisição",
+		English:      "The playlist was changed by another request",
+	}},
+	CodeInvalidPlaylistOrder: {http.StatusBadRequest, map[Language]string{
+		PortugueseBR: "A nova ordem deve conter exatamente os itens da playlist",
+		English:      "The new order must contain exactly the playlist items",
+	}},
+	CodeInvalidVisibility: {http.StatusBadRequest, map[Language]string{
+		PortugueseBR: "Visibilidade inválida",
+		English:      "Invalid visibility",
+	}},
+	CodeSessionNotFound: {http.StatusNotFound, map[Language]string{
+		PortugueseBR: "Sessão não encontrada",
+		English:      "Session not found",
+	}},
+	CodeSessionAlreadyEnded: {http.StatusConflict, map[Language]string{
+		PortugueseBR: "Sessão já foi encerrada",
+		English:      "Session has already ended",
+	}},
+	CodeHistoryBusy: {http.StatusServiceUnavailable, map[Language]string{
+		PortugueseBR: "Histórico sobrecarregado, tente novamente",
+		English:      "History is overloaded, please try again",
+	}},
+	CodePartyRoomNotFound: {http.StatusNotFound, map[Language]string{
+		PortugueseBR: "Sala não encontrada",
+		English:      "Room not found",
+	}},
+	CodePartyNotHost: {http.StatusForbidden, map[Language]string{
+		PortugueseBR: "Apenas o anfitrião pode controlar a reprodução",
+		English:      "Only the host can control playback",
+	}},
+	CodePartyEmptyQueue: {http.StatusConflict, map[Language]string{
+		PortugueseBR: "A fila está vazia",
+		English:      "The queue is empty",
+	}},
+	CodePartyNothingToPlay: {http.StatusConflict, map[Language]string{
+		PortugueseBR: "Nenhuma música tocando",
+		English:      "Nothing is playing",
+	}},
+	CodePartyRoomBusy: {http.StatusConflict, map[Language]string{
+		PortugueseBR: "Sala com muitas alterações simultâneas, tente novamente",
+		English:      "Too many simultaneous changes to the room, please try again",
+	}},
+	CodeInvalidLyricPart: {http.StatusBadRequest, map[Language]string{
+		PortugueseBR: "Trecho de dueto inválido",
+		English:      "Invalid duet part",
+	}},
+	CodePartnerNotFound: {http.StatusNotFound, map[Language]string{
+		PortugueseBR: "Parceiro de dueto não encontrado",
+		English:      "Duet partner not found",
+	}},
+	CodeNotSessionMember: {http.StatusForbidden, map[Language]string{
+		PortugueseBR: "Usuário não participa desta sessão",
+		English:      "User is not part of this session",
+	}},
+	CodePartNotInSession: {http.StatusBadRequest, map[Language]string{
+		PortugueseBR: "Parte não pertence a esta sessão",
+		English:      "Part does not belong to this session",
+	}},
+	CodeSessionNotDuet: {http.StatusBadRequest, map[Language]string{
+		PortugueseBR: "Sessão não é um dueto",
+		English:      "Session is not a duet",
+	}},
+	CodeDuetWithYourself: {http.StatusBadRequest, map[Language]string{
+		PortugueseBR: "Não é possível fazer dueto consigo mesmo",
+		English:      "You cannot duet with yourself",
+	}},
+	CodeDownloadFailed: {http.StatusInternalServerError, map[Language]string{
+		PortugueseBR: "Erro ao baixar música",
+		English:      "Failed to download song",
+	}},
+	CodeSearchFailed: {http.StatusInternalServerError, map[Language]string{
+		PortugueseBR: "Erro ao buscar música",
+		English:      "Failed to search for songs",
+	}},
+	CodeLyricsFailed: {http.StatusInternalServerError, map[Language]string{
+		PortugueseBR: "Erro ao buscar a letra",
+		English:      "Failed to fetch lyrics",
+	}},
+	CodeTranscriptionFailed: {http.StatusInternalServerError, map[Language]string{
+		PortugueseBR: "Erro ao transcrever o áudio",
+		English:      "Failed to transcribe audio",
+	}},
+}
+
+// Status é o status HTTP padrão do código.
+func (c Code) Status() int {
+	if e, ok := catalog[c]; ok {
+		return e.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Codes lista todos os códigos do catálogo, em ordem alfabética.
+func Codes() []Code {
+	codes := make([]Code, 0, len(catalog))
+	for code := range catalog {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
+
+// Message devolve a mensagem do código no idioma pedido, caindo para o
+// português quando falta tradução.
+func Message(code Code, lang Language) string {
+	e, ok := catalog[code]
+	if !ok {
+		e = catalog[CodeInternal]
+	}
+	if message, ok := e.messages[lang]; ok {
+		return message
+	}
+	return e.messages[DefaultLanguage]
+}
@@ -0,0 +1,60 @@
+package apperror
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Language é um idioma com mensagens no catálogo, como tag BCP 47.
+type Language string
+
+const (
+	PortugueseBR Language = "pt-BR"
+	English      Language = "en"
+
+	// DefaultLanguage vale quando o cliente não manda Accept-Language ou não
+	// aceita nenhum dos idiomas suportados.
+	DefaultLanguage = PortugueseBR
+)
+
+// NegotiateLanguage escolhe o idioma pelo header Accept-Language, respeitando
+// os pesos (q=). Qualquer variante de português vira pt-BR e qualquer
+// variante de inglês vira en.
+func NegotiateLanguage(acceptLanguage string) Language {
+	type candidate struct {
+		lang Language
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		switch primary {
+		case "pt":
+			candidates = append(candidates, candidate{PortugueseBR, q})
+		case "en":
+			candidates = append(candidates, candidate{English, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return DefaultLanguage
+	}
+	// Estável: com pesos iguais vale a ordem do header
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].lang
+}
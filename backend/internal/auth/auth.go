@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// Token é o resultado de uma verificação bem-sucedida, independente de quem
+// emitiu o token.
+type Token struct {
+	UID      string
+	IssuedAt time.Time
+	AuthTime time.Time
+	Claims   map[string]interface{}
+}
+
+// UserState é o que o provedor sabe sobre a revogação de um usuário: tokens
+// emitidos antes de TokensValidAfter não valem mais.
+type UserState struct {
+	TokensValidAfter time.Time
+	Disabled         bool
+}
+
+// TokenVerifier valida ID tokens e revoga as sessões de um usuário.
+// VerifyIDToken só confere assinatura e validade; a revogação é checada à
+// parte com GetUserState, que pode ser cacheado.
+type TokenVerifier interface {
+	VerifyIDToken(ctx context.Context, idToken string) (*Token, error)
+	RevokeRefreshTokens(ctx context.Context, uid string) error
+	GetUserState(ctx context.Context, uid string) (*UserState, error)
+	// DeleteUser remove a conta no provedor. Remover uma conta que não existe
+	// não é erro.
+	DeleteUser(ctx context.Context, uid string) error
+}
+
+func (t *Token) Email() string {
+	return t.stringClaim("email")
+}
+
+func (t *Token) Name() string {
+	return t.stringClaim("name")
+}
+
+func (t *Token) Picture() string {
+	return t.stringClaim("picture")
+}
+
+func (t *Token) stringClaim(name string) string {
+	value, _ := t.Claims[name].(string)
+	return value
+}
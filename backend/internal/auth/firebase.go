@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	firebaseauth "firebase.google.com/go/v4/auth"
+)
+
+type FirebaseVerifier struct {
+	client *firebaseauth.Client
+}
+
+func NewFirebaseVerifier(client *firebaseauth.Client) *FirebaseVerifier {
+	return &FirebaseVerifier{client: client}
+}
+
+func (v *FirebaseVerifier) VerifyIDToken(ctx context.Context, idToken string) (*Token, error) {
+	decoded, err := v.client.VerifyIDToken(ctx, idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &Token{
+		UID:      decoded.UID,
+		IssuedAt: time.Unix(decoded.IssuedAt, 0),
+		Claims:   decoded.Claims,
+	}
+	if authTime, ok := decoded.Claims["auth_time"].(float64); ok {
+		token.AuthTime = time.Unix(int64(authTime), 0)
+	}
+	return token, nil
+}
+
+func (v *FirebaseVerifier) RevokeRefreshTokens(ctx context.Context, uid string) error {
+	return v.client.RevokeRefreshTokens(ctx, uid)
+}
+
+// GetUserState consulta o usuário no Firebase. Um usuário que não existe mais
+// é tratado como desativado.
+func (v *FirebaseVerifier) GetUserState(ctx context.Context, uid string) (*UserState, error) {
+	user, err := v.client.GetUser(ctx, uid)
+	if firebaseauth.IsUserNotFound(err) {
+		return &UserState{Disabled: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &UserState{
+		TokensValidAfter: time.UnixMilli(user.TokensValidAfterMillis),
+		Disabled:         user.Disabled,
+	}, nil
+}
+
+func (v *FirebaseVerifier) DeleteUser(ctx context.Context, uid string) error {
+	err := v.client.DeleteUser(ctx, uid)
+	if firebaseauth.IsUserNotFound(err) {
+		return nil
+	}
+	return err
+}
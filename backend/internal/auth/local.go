@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const localIssuer = "any-song-local"
+
+// LocalIssuer emite e valida tokens HS256 assinados com uma chave configurada,
+// com as mesmas claims que o Firebase usa (email, name, picture). Serve para
+// desenvolvimento e testes de integração sem credenciais do Firebase.
+type LocalIssuer struct {
+	key []byte
+	ttl time.Duration
+
+	mu         sync.RWMutex
+	validAfter map[string]time.Time
+}
+
+type LocalIdentity struct {
+	UID     string `json:"uid" validate:"required,max=128"`
+	Email   string `json:"email" validate:"required,email"`
+	Name    string `json:"name" validate:"max=100"`
+	Picture string `json:"picture" validate:"omitempty,http_url"`
+}
+
+type localClaims struct {
+	Email    string `json:"email,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Picture  string `json:"picture,omitempty"`
+	AuthTime int64  `json:"auth_time"`
+	jwt.RegisteredClaims
+}
+
+func NewLocalIssuer(key []byte, ttl time.Duration) (*LocalIssuer, error) {
+	if len(key) < 32 {
+		return nil, errors.New("a chave de assinatura local precisa ter pelo menos 32 bytes")
+	}
+	return &LocalIssuer{
+		key:        key,
+		ttl:        ttl,
+		validAfter: make(map[string]time.Time),
+	}, nil
+}
+
+func (i *LocalIssuer) Issue(identity LocalIdentity) (string, time.Time, error) {
+	if identity.UID == "" {
+		return "", time.Time{}, errors.New("uid é obrigatório")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(i.ttl)
+	claims := localClaims{
+		Email:    identity.Email,
+		Name:     identity.Name,
+		Picture:  identity.Picture,
+		AuthTime: now.Unix(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    localIssuer,
+			Subject:   identity.UID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.key)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("erro ao assinar token local: %w", err)
+	}
+	return signed, expiresAt, nil
+}
+
+func (i *LocalIssuer) VerifyIDToken(ctx context.Context, idToken string) (*Token, error) {
+	var claims localClaims
+	_, err := jwt.ParseWithClaims(idToken, &claims, func(*jwt.Token) (interface{}, error) {
+		return i.key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(localIssuer),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("token local sem subject")
+	}
+
+	token := &Token{
+		UID:      claims.Subject,
+		IssuedAt: claims.IssuedAt.Time,
+		AuthTime: time.Unix(claims.AuthTime, 0),
+		Claims: map[string]interface{}{
+			"email":     claims.Email,
+			"name":      claims.Name,
+			"picture":   claims.Picture,
+			"auth_time": float64(claims.AuthTime),
+		},
+	}
+	return token, nil
+}
+
+// RevokeRefreshTokens invalida os tokens já emitidos para o usuário. O estado
+// fica só na memória do processo.
+func (i *LocalIssuer) RevokeRefreshTokens(ctx context.Context, uid string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.validAfter[uid] = time.Now().Truncate(time.Second).Add(time.Second)
+	return nil
+}
+
+func (i *LocalIssuer) GetUserState(ctx context.Context, uid string) (*UserState, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return &UserState{TokensValidAfter: i.validAfter[uid]}, nil
+}
+
+// DeleteUser só revoga os tokens: o emissor local não guarda contas.
+func (i *LocalIssuer) DeleteUser(ctx context.Context, uid string) error {
+	return i.RevokeRefreshTokens(ctx, uid)
+}
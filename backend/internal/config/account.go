@@ -0,0 +1,7 @@
+package config
+
+// AccountConfig define o prazo entre o pedido de exclusão da conta e a
+// remoção definitiva (ACCOUNT_DELETION_GRACE_PERIOD, padrão 7 dias).
+type AccountConfig struct {
+	DeletionGracePeriod Duration `json:"deletion_grace_period" env:"ACCOUNT_DELETION_GRACE_PERIOD"`
+}
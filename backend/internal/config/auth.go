@@ -0,0 +1,42 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	AuthProviderFirebase = "firebase"
+	AuthProviderLocal    = "local"
+)
+
+// AuthConfig escolhe o provedor de tokens: firebase (padrão) ou local. No
+// modo local os tokens são assinados com LOCAL_AUTH_SIGNING_KEY e valem por
+// LOCAL_AUTH_TOKEN_TTL (padrão 1h).
+type AuthConfig struct {
+	Provider        string   `json:"provider" env:"AUTH_PROVIDER"`
+	LocalSigningKey Secret   `json:"local_signing_key" env:"LOCAL_AUTH_SIGNING_KEY"`
+	LocalTokenTTL   Duration `json:"local_token_ttl" env:"LOCAL_AUTH_TOKEN_TTL"`
+}
+
+// validate recusa o modo local fora de desenvolvimento: nele qualquer um
+// consegue um token para o UID que quiser pelo /dev/token.
+func (c *AuthConfig) validate(development bool) []error {
+	var errs []error
+	switch c.Provider {
+	case AuthProviderFirebase:
+	case AuthProviderLocal:
+		if !development {
+			errs = append(errs, fmt.Errorf("AUTH_PROVIDER=local só é permitido com ENVIRONMENT=%s ou %s", EnvironmentDevelopment, EnvironmentTest))
+		}
+		if len(c.LocalSigningKey) < 32 {
+			errs = append(errs, errors.New("LOCAL_AUTH_SIGNING_KEY precisa ter pelo menos 32 bytes quando AUTH_PROVIDER=local"))
+		}
+		if c.LocalTokenTTL.Duration <= 0 {
+			errs = append(errs, errors.New("LOCAL_AUTH_TOKEN_TTL deve ser maior que zero"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("AUTH_PROVIDER inválido: %s (use %s ou %s)", c.Provider, AuthProviderFirebase, AuthProviderLocal))
+	}
+	return errs
+}
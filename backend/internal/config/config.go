@@ -0,0 +1,362 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config reúne todas as configurações do backend. Load parte dos valores
+// padrão, aplica o arquivo JSON apontado por CONFIG_FILE (opcional) e por
+// último as variáveis de ambiente indicadas nas tags `env`.
+type Config struct {
+	Environment string          `json:"environment" env:"ENVIRONMENT"`
+	Log         LogConfig       `json:"log"`
+	Tracing     TracingConfig   `json:"tracing"`
+	Server      ServerConfig    `json:"server"`
+	Database    DatabaseConfig  `json:"database"`
+	Redis       RedisConfig     `json:"redis"`
+	Firebase    FirebaseConfig  `json:"firebase"`
+	GCS         GCSConfig       `json:"gcs"`
+	Auth        AuthConfig      `json:"auth"`
+	RateLimit   RateLimitConfig `json:"rate_limit"`
+	Account     AccountConfig   `json:"account"`
+}
+
+const (
+	EnvironmentDevelopment = "development"
+	EnvironmentTest        = "test"
+)
+
+// IsDevelopment diz se o backend roda em desenvolvimento ou nos testes, os
+// únicos ambientes em que AUTH_PROVIDER=local e o /dev/token são aceitos.
+func (c *Config) IsDevelopment() bool {
+	return c.Environment == EnvironmentDevelopment || c.Environment == EnvironmentTest
+}
+
+type ServerConfig struct {
+	Port        int      `json:"port" env:"PORT"`
+	CORSOrigins []string `json:"cors_origins" env:"CORS_ALLOW_ORIGINS"`
+	// ShutdownTimeout é quanto o desligamento espera pelas requisições em
+	// andamento (downloads, transcrições) antes de cancelá-las.
+	ShutdownTimeout Duration `json:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+type DatabaseConfig struct {
+	Host            string   `json:"host" env:"DB_HOST"`
+	Port            int      `json:"port" env:"DB_PORT"`
+	User            string   `json:"user" env:"DB_USER"`
+	Password        Secret   `json:"password" env:"DB_PASSWORD"`
+	Name            string   `json:"name" env:"DB_NAME"`
+	SSLMode         string   `json:"ssl_mode" env:"DB_SSL_MODE"`
+	MaxOpenConns    int      `json:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int      `json:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime Duration `json:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+}
+
+// Os subsistemas opcionais (Redis, Firestore, GCS) podem ser desligados com
+// *_ENABLED=false; a API sobe sem eles e as rotas que dependem deles
+// respondem 503.
+type RedisConfig struct {
+	Enabled  bool   `json:"enabled" env:"REDIS_ENABLED"`
+	Addr     string `json:"addr" env:"REDIS_ADDR"`
+	Password Secret `json:"password" env:"REDIS_PASSWORD"`
+	DB       int    `json:"db" env:"REDIS_DB"`
+	PoolSize int    `json:"pool_size" env:"REDIS_POOL_SIZE"`
+}
+
+type FirebaseConfig struct {
+	CredentialsPath  string `json:"credentials_path" env:"FIREBASE_CREDENTIALS_PATH"`
+	FirestoreEnabled bool   `json:"firestore_enabled" env:"FIRESTORE_ENABLED"`
+}
+
+type GCSConfig struct {
+	Enabled         bool   `json:"enabled" env:"GCS_ENABLED"`
+	BucketName      string `json:"bucket_name" env:"GCS_BUCKET_NAME"`
+	CredentialsPath string `json:"credentials_path" env:"GOOGLE_APPLICATION_CREDENTIALS"`
+}
+
+// Secret é um valor sensível: aparece como "********" quando é formatado ou
+// serializado (por exemplo em logs e em /debug/config). Value devolve o valor
+// real.
+type Secret string
+
+const redacted = "********"
+
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// Duration aceita "90s", "5m", "168h" no arquivo de configuração.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duração deve ser uma string como \"5m\": %w", err)
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+func Default() *Config {
+	return &Config{
+		Environment: EnvironmentDevelopment,
+		Log: LogConfig{
+			Level:  "info",
+			Format: LogFormatText,
+		},
+		Tracing: TracingConfig{
+			Exporter:    TracingExporterNone,
+			ServiceName: "any-song-backend",
+			SampleRatio: 1,
+		},
+		Server: ServerConfig{
+			Port:            8000,
+			CORSOrigins:     []string{"http://localhost:3000"},
+			ShutdownTimeout: Duration{30 * time.Second},
+		},
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            5432,
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: Duration{5 * time.Minute},
+		},
+		Redis: RedisConfig{
+			Enabled:  true,
+			Addr:     "localhost:6379",
+			PoolSize: 20,
+		},
+		Firebase: FirebaseConfig{
+			FirestoreEnabled: true,
+		},
+		GCS: GCSConfig{
+			Enabled: true,
+		},
+		Auth: AuthConfig{
+			Provider:      AuthProviderFirebase,
+			LocalTokenTTL: Duration{time.Hour},
+		},
+		RateLimit: RateLimitConfig{
+			Window:       Duration{time.Minute},
+			UserRequests: 10,
+			IPRequests:   30,
+			Quotas:       defaultQuotaTable(),
+		},
+		Account: AccountConfig{
+			DeletionGracePeriod: Duration{7 * 24 * time.Hour},
+		},
+	}
+}
+
+// Load monta a configuração e valida tudo de uma vez: o erro devolvido lista
+// todos os problemas encontrados, não só o primeiro.
+func Load() (*Config, error) {
+	cfg := Default()
+	var errs []error
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, loadEnv(reflect.ValueOf(cfg).Elem())...)
+	errs = append(errs, cfg.RateLimit.loadQuotaEnv()...)
+	errs = append(errs, cfg.Validate()...)
+
+	if len(errs) > 0 {
+		return nil, &LoadError{Errors: errs}
+	}
+	return cfg, nil
+}
+
+// LoadError agrupa os problemas encontrados ao carregar a configuração.
+type LoadError struct {
+	Errors []error
+}
+
+func (e *LoadError) Error() string {
+	var b strings.Builder
+	b.WriteString("configuração inválida:")
+	for _, err := range e.Errors {
+		b.WriteString("\n  - ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+func (e *LoadError) Unwrap() []error {
+	return e.Errors
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("CONFIG_FILE: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("CONFIG_FILE %s: %w", path, err)
+	}
+	// Um papel citado no arquivo substitui o mapa inteiro de cotas dele;
+	// as cotas que ficaram de fora voltam ao padrão.
+	c.RateLimit.fillDefaultQuotas()
+	return nil
+}
+
+var durationType = reflect.TypeOf(Duration{})
+
+// loadEnv percorre os campos com tag `env` e sobrescreve os que têm a
+// variável definida.
+func loadEnv(v reflect.Value) []error {
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		name := field.Tag.Get("env")
+		if name == "" {
+			if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+				errs = append(errs, loadEnv(value)...)
+			}
+			continue
+		}
+
+		raw, ok := os.LookupEnv(name)
+		if !ok || strings.TrimSpace(raw) == "" {
+			continue
+		}
+		raw = strings.TrimSpace(raw)
+
+		if err := setField(value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s inválido (%q): %w", name, raw, err))
+		}
+	}
+	return errs
+}
+
+func setField(value reflect.Value, raw string) error {
+	if value.Type() == durationType {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return errors.New("esperado uma duração como 30s, 5m ou 168h")
+		}
+		value.Set(reflect.ValueOf(Duration{parsed}))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int:
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return errors.New("esperado um número inteiro")
+		}
+		value.SetInt(int64(parsed))
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return errors.New("esperado um número")
+		}
+		value.SetFloat(parsed)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("esperado true ou false")
+		}
+		value.SetBool(parsed)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("tipo %s não suportado", value.Type())
+	}
+	return nil
+}
+
+// Validate confere os valores depois de aplicados padrão, arquivo e ambiente.
+func (c *Config) Validate() []error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	errs = append(errs, c.Log.validate()...)
+	errs = append(errs, c.Tracing.validate()...)
+	check(validPort(c.Server.Port), "PORT deve estar entre 1 e 65535 (atual: %d)", c.Server.Port)
+	check(len(c.Server.CORSOrigins) > 0, "CORS_ALLOW_ORIGINS precisa de pelo menos uma origem")
+	for _, origin := range c.Server.CORSOrigins {
+		// Com credenciais o navegador não aceita "*" e o middleware de CORS
+		// entra em pânico
+		check(origin != "*", "CORS_ALLOW_ORIGINS não pode usar \"*\": as rotas aceitam credenciais")
+	}
+	check(c.Server.ShutdownTimeout.Duration > 0, "SHUTDOWN_TIMEOUT deve ser maior que 0")
+
+	check(c.Database.Host != "", "DB_HOST é obrigatório")
+	check(validPort(c.Database.Port), "DB_PORT deve estar entre 1 e 65535 (atual: %d)", c.Database.Port)
+	check(c.Database.User != "", "DB_USER é obrigatório")
+	check(c.Database.Name != "", "DB_NAME é obrigatório")
+	switch c.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		check(false, "DB_SSL_MODE inválido: %s", c.Database.SSLMode)
+	}
+	check(c.Database.MaxOpenConns > 0, "DB_MAX_OPEN_CONNS deve ser maior que 0")
+	check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"DB_MAX_IDLE_CONNS deve estar entre 0 e DB_MAX_OPEN_CONNS (%d)", c.Database.MaxOpenConns)
+	check(c.Database.ConnMaxLifetime.Duration >= 0, "DB_CONN_MAX_LIFETIME não pode ser negativo")
+
+	if c.Redis.Enabled {
+		check(c.Redis.Addr != "", "REDIS_ADDR é obrigatório com REDIS_ENABLED=true")
+		check(c.Redis.DB >= 0, "REDIS_DB não pode ser negativo")
+		check(c.Redis.PoolSize > 0, "REDIS_POOL_SIZE deve ser maior que 0")
+	}
+
+	// Credenciais ausentes do Firebase e do GCS não impedem a subida: o
+	// subsistema fica indisponível e aparece assim no /health.
+	errs = append(errs, c.Auth.validate(c.IsDevelopment())...)
+	errs = append(errs, c.RateLimit.validate()...)
+	check(c.Account.DeletionGracePeriod.Duration >= 0, "ACCOUNT_DELETION_GRACE_PERIOD não pode ser negativo")
+	return errs
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
@@ -3,19 +3,17 @@ package config
 import (
 	"context"
 	"fmt"
-	"os"
 
 	firebase "firebase.google.com/go/v4"
 	"google.golang.org/api/option"
 )
 
-func GetFireBaseApp() (*firebase.App, error) {
-	path := os.Getenv("FIREBASE_CREDENTIALS_PATH")
-	if path == "" {
+func GetFireBaseApp(cfg FirebaseConfig) (*firebase.App, error) {
+	if cfg.CredentialsPath == "" {
 		return nil, fmt.Errorf("FIREBASE_CREDENTIALS_PATH environment variable is not set")
 	}
 
-	opt := option.WithCredentialsFile(path)
+	opt := option.WithCredentialsFile(cfg.CredentialsPath)
 
 	app, err := firebase.NewApp(context.Background(), nil, opt)
 	if err != nil {
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// LogConfig define o nível mínimo (LOG_LEVEL: debug, info, warn ou error) e o
+// formato (LOG_FORMAT: text ou json) dos logs.
+type LogConfig struct {
+	Level  string `json:"level" env:"LOG_LEVEL"`
+	Format string `json:"format" env:"LOG_FORMAT"`
+}
+
+func (c *LogConfig) validate() []error {
+	var errs []error
+	switch c.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_LEVEL inválido: %s (use debug, info, warn ou error)", c.Level))
+	}
+	switch c.Format {
+	case LogFormatText, LogFormatJSON:
+	default:
+		errs = append(errs, errors.New("LOG_FORMAT deve ser text ou json"))
+	}
+	return errs
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+)
+
+const (
+	QuotaDownloadMinutes      = "download_minutes"
+	QuotaTranscriptionMinutes = "transcription_minutes"
+)
+
+// RateLimitConfig define a janela deslizante das rotas caras
+// (RATE_LIMIT_WINDOW, RATE_LIMIT_USER_REQUESTS, RATE_LIMIT_IP_REQUESTS) e as
+// cotas diárias por papel. Uma cota 0 significa sem limite.
+type RateLimitConfig struct {
+	Window       Duration                      `json:"window" env:"RATE_LIMIT_WINDOW"`
+	UserRequests int                           `json:"user_requests" env:"RATE_LIMIT_USER_REQUESTS"`
+	IPRequests   int                           `json:"ip_requests" env:"RATE_LIMIT_IP_REQUESTS"`
+	Quotas       map[string]map[string]float64 `json:"quotas"`
+}
+
+var defaultQuotas = map[string]map[string]float64{
+	models.RoleUser:    {QuotaDownloadMinutes: 60, QuotaTranscriptionMinutes: 30},
+	models.RoleCurator: {QuotaDownloadMinutes: 300, QuotaTranscriptionMinutes: 120},
+	models.RoleAdmin:   {QuotaDownloadMinutes: 0, QuotaTranscriptionMinutes: 0},
+}
+
+func defaultQuotaTable() map[string]map[string]float64 {
+	quotas := make(map[string]map[string]float64, len(defaultQuotas))
+	for role, limits := range defaultQuotas {
+		quotas[role] = make(map[string]float64, len(limits))
+		for quota, limit := range limits {
+			quotas[role][quota] = limit
+		}
+	}
+	return quotas
+}
+
+func (c *RateLimitConfig) fillDefaultQuotas() {
+	if c.Quotas == nil {
+		c.Quotas = make(map[string]map[string]float64, len(defaultQuotas))
+	}
+	for role, limits := range defaultQuotas {
+		if c.Quotas[role] == nil {
+			c.Quotas[role] = make(map[string]float64, len(limits))
+		}
+		for quota, limit := range limits {
+			if _, ok := c.Quotas[role][quota]; !ok {
+				c.Quotas[role][quota] = limit
+			}
+		}
+	}
+}
+
+// loadQuotaEnv aplica QUOTA_<PAPEL>_<COTA>, por exemplo
+// QUOTA_USER_DOWNLOAD_MINUTES=120.
+func (c *RateLimitConfig) loadQuotaEnv() []error {
+	var errs []error
+	for role, limits := range c.Quotas {
+		for quota := range limits {
+			name := fmt.Sprintf("QUOTA_%s_%s", strings.ToUpper(role), strings.ToUpper(quota))
+			value := strings.TrimSpace(os.Getenv(name))
+			if value == "" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s inválido (%q): esperado um número", name, value))
+				continue
+			}
+			limits[quota] = parsed
+		}
+	}
+	return errs
+}
+
+func (c *RateLimitConfig) validate() []error {
+	var errs []error
+	if c.Window.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_WINDOW deve ser maior que zero"))
+	}
+	if c.UserRequests <= 0 {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_USER_REQUESTS deve ser maior que 0"))
+	}
+	if c.IPRequests <= 0 {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_IP_REQUESTS deve ser maior que 0"))
+	}
+	for role, limits := range c.Quotas {
+		for quota, limit := range limits {
+			if limit < 0 {
+				errs = append(errs, fmt.Errorf("cota %s do papel %s não pode ser negativa", quota, role))
+			}
+		}
+	}
+	return errs
+}
+
+// QuotaFor devolve a maior cota entre os papéis do usuário. Se algum papel
+// não tem limite, o usuário também não tem.
+func (c *RateLimitConfig) QuotaFor(roles []string, quota string) float64 {
+	var limit float64
+	for _, role := range roles {
+		roleLimit, ok := c.Quotas[role][quota]
+		if !ok {
+			continue
+		}
+		if roleLimit == 0 {
+			return 0
+		}
+		if roleLimit > limit {
+			limit = roleLimit
+		}
+	}
+	return limit
+}
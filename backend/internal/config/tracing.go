@@ -0,0 +1,39 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+)
+
+// TracingConfig liga o OpenTelemetry (TRACING_EXPORTER: none, stdout ou
+// otlp). O exportador OTLP usa HTTP e lê o destino das variáveis padrão
+// OTEL_EXPORTER_OTLP_ENDPOINT / OTEL_EXPORTER_OTLP_TRACES_ENDPOINT.
+// TRACING_SAMPLE_RATIO vale para traces que não chegam com decisão do
+// chamador.
+type TracingConfig struct {
+	Exporter    string  `json:"exporter" env:"TRACING_EXPORTER"`
+	ServiceName string  `json:"service_name" env:"OTEL_SERVICE_NAME"`
+	SampleRatio float64 `json:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+func (c *TracingConfig) validate() []error {
+	var errs []error
+	switch c.Exporter {
+	case TracingExporterNone, TracingExporterStdout, TracingExporterOTLP:
+	default:
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER inválido: %s (use none, stdout ou otlp)", c.Exporter))
+	}
+	if c.ServiceName == "" {
+		errs = append(errs, errors.New("OTEL_SERVICE_NAME não pode ser vazio"))
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("TRACING_SAMPLE_RATIO deve estar entre 0 e 1 (atual: %g)", c.SampleRatio))
+	}
+	return errs
+}
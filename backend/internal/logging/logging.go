@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/josevitorrodriguess/any-song/backend/internal/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey struct{}
+
+// WithRequestID guarda o ID da requisição no contexto. Todo log feito com
+// esse contexto (slog.InfoContext etc.) sai com o campo request_id e, se
+// houver span, com trace_id e span_id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// RequestID devolve o ID guardado por WithRequestID, ou "".
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Setup troca o logger padrão pelo configurado. O pacote log da biblioteca
+// padrão (usado por dependências) passa a escrever pelo mesmo handler.
+func Setup(cfg config.LogConfig) {
+	slog.SetDefault(New(cfg, os.Stdout))
+}
+
+// New monta um logger com o nível e o formato da configuração, que adiciona
+// o request_id do contexto e mascara tokens e e-mails.
+func New(cfg config.LogConfig, w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: parseLevel(cfg.Level)}
+
+	var base slog.Handler
+	if cfg.Format == config.LogFormatJSON {
+		base = slog.NewJSONHandler(w, opts)
+	} else {
+		base = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(&handler{next: base})
+}
+
+func parseLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+// handler adiciona o request_id e aplica a redação antes de repassar ao
+// handler de texto ou JSON.
+type handler struct {
+	next slog.Handler
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, RedactString(r.Message), r.PC)
+	if id := RequestID(ctx); id != "" {
+		out.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		out.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &handler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{next: h.next.WithGroup(name)}
+}
+
+// Tail devolve no máximo os últimos n bytes de output, para logar a saída dos
+// scripts sem despejar tudo.
+func Tail(output []byte, n int) string {
+	if len(output) <= n {
+		return string(output)
+	}
+	return "…" + string(output[len(output)-n:])
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys são atributos cujo valor nunca vai para o log.
+var sensitiveKeys = map[string]bool{
+	"token":         true,
+	"id_token":      true,
+	"access_token":  true,
+	"refresh_token": true,
+	"authorization": true,
+	"password":      true,
+	"secret":        true,
+	"api_key":       true,
+	"cookie":        true,
+}
+
+var (
+	bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`)
+	jwtPattern    = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	// A parte pública (ask_1a2b3c4d) identifica a chave e pode ficar.
+	apiKeyPattern = regexp.MustCompile(`\b(ask_[0-9a-f]{8})_[A-Za-z0-9_-]+`)
+	emailPattern  = regexp.MustCompile(`([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+)
+
+// RedactString mascara tokens (Bearer, JWT, API keys) e e-mails em um texto
+// livre. E-mails mantêm a primeira letra e o domínio: j***@exemplo.com.
+func RedactString(s string) string {
+	if s == "" {
+		return s
+	}
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = apiKeyPattern.ReplaceAllString(s, "${1}_"+redacted)
+	s = emailPattern.ReplaceAllString(s, "${1}***@${2}")
+	return s
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if sensitiveKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, RedactString(a.Value.String()))
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		out := make([]slog.Attr, len(attrs))
+		for i, attr := range attrs {
+			out[i] = redactAttr(attr)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(out...)}
+	case slog.KindAny:
+		// Erros costumam carregar e-mails ou tokens na mensagem
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, RedactString(err.Error()))
+		}
+	}
+	return a
+}
@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "anysong"
+
+// Séries expostas em /metrics. Ficam no registry padrão do Prometheus, junto
+// com as métricas de runtime do Go e do processo.
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Requisições HTTP por método, rota e status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latência das requisições HTTP por método, rota e status.",
+		// Downloads e transcrições levam minutos
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"method", "route", "status"})
+
+	cacheHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_hits_total",
+		Help:      "Leituras do cache que encontraram a chave, por prefixo.",
+	}, []string{"prefix"})
+
+	cacheMisses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_misses_total",
+		Help:      "Leituras do cache que não encontraram a chave, por prefixo.",
+	}, []string{"prefix"})
+
+	cacheErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_errors_total",
+		Help:      "Falhas do cache por prefixo e operação (get, set, delete).",
+	}, []string{"prefix", "operation"})
+
+	subprocessRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "subprocess_runs_total",
+		Help:      "Execuções de scripts Python por script e status de saída.",
+	}, []string{"script", "exit_status"})
+
+	subprocessDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "subprocess_duration_seconds",
+		Help:      "Duração das execuções de scripts Python por script e status de saída.",
+		Buckets:   []float64{.1, .5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800, 3600},
+	}, []string{"script", "exit_status"})
+
+	whisperModelLoad = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "whisper_model_load_seconds",
+		Help:      "Tempo de carregamento do modelo Whisper por tamanho de modelo.",
+		Buckets:   []float64{.5, 1, 2.5, 5, 10, 20, 30, 60, 120},
+	}, []string{"model"})
+
+	whisperTranscribe = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "whisper_transcribe_seconds",
+		Help:      "Tempo de transcrição do Whisper (sem o carregamento) por tamanho de modelo.",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+	}, []string{"model"})
+
+	whisperSpeedRatio = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "whisper_speed_ratio",
+		Help:      "Duração do áudio dividida pelo tempo total de transcrição (1 = tempo real).",
+		Buckets:   []float64{.25, .5, 1, 2, 4, 8, 16, 32, 64},
+	}, []string{"model"})
+)
+
+// ObserveHTTP registra uma requisição já respondida. route é o padrão da rota
+// (/playlists/:id), não o caminho, para não explodir a cardinalidade.
+func ObserveHTTP(method, route string, status int, elapsed time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(elapsed.Seconds())
+}
+
+func CacheHit(key string) {
+	cacheHits.WithLabelValues(CachePrefix(key)).Inc()
+}
+
+func CacheMiss(key string) {
+	cacheMisses.WithLabelValues(CachePrefix(key)).Inc()
+}
+
+func CacheError(key, operation string) {
+	cacheErrors.WithLabelValues(CachePrefix(key), operation).Inc()
+}
+
+// CachePrefix reduz a chave às partes fixas: "user:uid:abc123" vira
+// "user:uid" e "auth:session:seen:abc123:1700000000" vira
+// "auth:session:seen". Para no primeiro segmento que não é só letras
+// minúsculas e nunca inclui o último.
+func CachePrefix(key string) string {
+	segments := strings.Split(key, ":")
+	prefix := make([]string, 0, len(segments))
+	for _, segment := range segments[:len(segments)-1] {
+		if !isPrefixSegment(segment) {
+			break
+		}
+		prefix = append(prefix, segment)
+	}
+	if len(prefix) == 0 {
+		return "other"
+	}
+	return strings.Join(prefix, ":")
+}
+
+func isPrefixSegment(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	for _, r := range segment {
+		if (r < 'a' || r > 'z') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// ObserveSubprocess registra uma execução de script. exitStatus é o código de
+// saída ou "timeout", "canceled" e "error" (não chegou a rodar).
+func ObserveSubprocess(script, exitStatus string, elapsed time.Duration) {
+	subprocessRuns.WithLabelValues(script, exitStatus).Inc()
+	subprocessDuration.WithLabelValues(script, exitStatus).Observe(elapsed.Seconds())
+}
+
+// ObserveWhisper registra o TimingInfo devolvido por uma transcrição bem
+// sucedida. Os tempos vêm em segundos.
+func ObserveWhisper(model string, modelLoad, transcribe, speedRatio float64) {
+	whisperModelLoad.WithLabelValues(model).Observe(modelLoad)
+	whisperTranscribe.WithLabelValues(model).Observe(transcribe)
+	whisperSpeedRatio.WithLabelValues(model).Observe(speedRatio)
+}
+
+// RegisterDB expõe sql.DB.Stats (conexões abertas, em uso, esperas) do pool
+// do Postgres.
+func RegisterDB(db *sql.DB, name string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, name))
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// AccountDeletion é um pedido de exclusão de conta. Enquanto ScheduledFor não
+// chega o usuário pode cancelar; depois disso o worker apaga a conta e, com
+// ela, este registro.
+type AccountDeletion struct {
+	UserUID      string    `json:"user_uid" gorm:"primaryKey"`
+	User         User      `json:"-" gorm:"foreignKey:UserUID;references:FirebaseUID;constraint:OnDelete:CASCADE"`
+	RequestedAt  time.Time `json:"requested_at" gorm:"autoCreateTime"`
+	ScheduledFor time.Time `json:"scheduled_for" gorm:"not null;index"`
+	Attempts     int       `json:"attempts" gorm:"default:0"`
+	LastError    string    `json:"last_error,omitempty"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Escopos que uma API key pode receber. Um token do Firebase tem todos eles;
+// rotas de administração e de gestão de conta nunca aceitam API key.
+const (
+	ScopeCatalogWrite = "catalog:write"
+	ScopePlaylists    = "playlists"
+	ScopeHistory      = "history"
+	ScopeMedia        = "media"
+	ScopeParty        = "party"
+)
+
+var APIKeyScopes = []string{ScopeCatalogWrite, ScopePlaylists, ScopeHistory, ScopeMedia, ScopeParty}
+
+// APIKey é uma credencial para clientes sem fluxo interativo (scripts de
+// ingestão, quiosques). Só o hash SHA-256 da chave é guardado; o prefixo
+// fica visível para o usuário reconhecer a chave na listagem.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserUID    string     `json:"user_uid" gorm:"not null;index"`
+	User       User       `json:"-" gorm:"foreignKey:UserUID;references:FirebaseUID;constraint:OnDelete:CASCADE"`
+	Name       string     `json:"name" gorm:"not null"`
+	Prefix     string     `json:"prefix" gorm:"not null"`
+	Hash       string     `json:"-" gorm:"not null;uniqueIndex"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func IsValidAPIKeyScope(scope string) bool {
+	for _, s := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ações registradas no audit log.
+const (
+	AuditArtistCreate    = "artist.create"
+	AuditArtistUpdate    = "artist.update"
+	AuditArtistDelete    = "artist.delete"
+	AuditUserUpdate      = "user.update"
+	AuditUserDelete      = "user.delete"
+	AuditUserExport      = "user.export"
+	AuditDeletionRequest = "user.deletion_requested"
+	AuditDeletionCancel  = "user.deletion_cancelled"
+	AuditRoleGrant       = "role.grant"
+	AuditRoleRevoke      = "role.revoke"
+	AuditPermissionDeny  = "permission.denied"
+	AuditAPIKeyCreate    = "api_key.create"
+	AuditAPIKeyRevoke    = "api_key.revoke"
+	AuditLyricPartsSet   = "song.parts.update"
+	AuditPlaylistDelete  = "playlist.delete"
+	AuditSessionsRevoke  = "auth_sessions.revoke"
+)
+
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditLog é somente inserção: um trigger no Postgres recusa UPDATE e DELETE.
+// Não há chave estrangeira para o ator, para que o registro sobreviva à
+// remoção da conta.
+type AuditLog struct {
+	ID         uuid.UUID              `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ActorUID   string                 `json:"actor_uid" gorm:"index"`
+	Action     string                 `json:"action" gorm:"not null;index"`
+	TargetType string                 `json:"target_type" gorm:"not null;index:idx_audit_target"`
+	TargetID   string                 `json:"target_id" gorm:"index:idx_audit_target"`
+	Changes    map[string]AuditChange `json:"changes,omitempty" gorm:"type:jsonb;serializer:json"`
+	RequestID  string                 `json:"request_id"`
+	IPAddress  string                 `json:"ip_address"`
+	CreatedAt  time.Time              `json:"created_at" gorm:"autoCreateTime;index"`
+}
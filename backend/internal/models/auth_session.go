@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthSession representa um login. Cada login gera um auth_time diferente no
+// token, que é usado para identificar a sessão nas requisições seguintes.
+type AuthSession struct {
+	ID         uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserUID    string     `json:"-" gorm:"not null;uniqueIndex:idx_auth_session_user_auth_time"`
+	User       User       `json:"-" gorm:"foreignKey:UserUID;references:FirebaseUID;constraint:OnDelete:CASCADE"`
+	AuthTime   time.Time  `json:"auth_time" gorm:"not null;uniqueIndex:idx_auth_session_user_auth_time"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	DuetPartA    = "A"
+	DuetPartB    = "B"
+	DuetPartBoth = "both"
+)
+
+// LyricPart atribui um trecho da letra sincronizada a uma voz do dueto. O
+// trecho é um intervalo de linhas (inclusivo, a partir de 0) e pode ser
+// refinado por tempo com StartMs/EndMs quando a troca acontece no meio da
+// linha.
+type LyricPart struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	SongID    uuid.UUID `json:"song_id" gorm:"type:uuid;not null;index"`
+	Song      Song      `json:"-" gorm:"foreignKey:SongID;constraint:OnDelete:CASCADE"`
+	LineStart int       `json:"line_start" gorm:"not null"`
+	LineEnd   int       `json:"line_end" gorm:"not null"`
+	StartMs   *int64    `json:"start_ms,omitempty"`
+	EndMs     *int64    `json:"end_ms,omitempty"`
+	Part      string    `json:"part" gorm:"not null"`
+}
+
+// SessionParticipant é um cantor numa sessão. Em duetos cada participante
+// canta uma parte e recebe a própria nota.
+type SessionParticipant struct {
+	SessionID uuid.UUID `json:"session_id" gorm:"primaryKey;type:uuid"`
+	UserUID   string    `json:"user_uid" gorm:"primaryKey"`
+	Part      string    `json:"part" gorm:"not null"`
+	Score     *float64  `json:"score,omitempty"`
+}
+
+func IsValidDuetPart(part string) bool {
+	switch part {
+	case DuetPartA, DuetPartB, DuetPartBoth:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Favorite struct {
+	UserUID   string    `json:"-" gorm:"primaryKey"`
+	User      User      `json:"-" gorm:"foreignKey:UserUID;references:FirebaseUID;constraint:OnDelete:CASCADE"`
+	SongID    uuid.UUID `json:"-" gorm:"primaryKey;type:uuid"`
+	Song      Song      `json:"song" gorm:"foreignKey:SongID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SessionModeSolo = "solo"
+	SessionModeDuet = "duet"
+)
+
+// DeletedUserUID fica no lugar de quem abriu uma sessão compartilhada depois
+// que a conta dele é excluída, para a sessão continuar com os outros
+// participantes. Nenhum provedor emite UID vazio.
+const DeletedUserUID = ""
+
+// KaraokeSession agrupa as músicas cantadas numa mesma noite/sessão.
+type KaraokeSession struct {
+	ID           uuid.UUID            `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserUID      string               `json:"user_uid" gorm:"not null;index"`
+	Mode         string               `json:"mode" gorm:"not null;default:solo"`
+	SongID       *uuid.UUID           `json:"song_id,omitempty" gorm:"type:uuid"`
+	Participants []SessionParticipant `json:"participants,omitempty" gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE"`
+	StartedAt    time.Time            `json:"started_at" gorm:"not null;index"`
+	EndedAt      *time.Time           `json:"ended_at"`
+}
+
+// PlayEvent é uma linha do histórico. A tabela é append-only: linhas nunca
+// são atualizadas, só inseridas.
+type PlayEvent struct {
+	ID              uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserUID         string     `json:"-" gorm:"not null;index:idx_play_event_user_played_at"`
+	SongID          uuid.UUID  `json:"song_id" gorm:"type:uuid;not null;index"`
+	Song            *Song      `json:"song,omitempty" gorm:"foreignKey:SongID"`
+	SessionID       *uuid.UUID `json:"session_id,omitempty" gorm:"type:uuid;index"`
+	DurationSeconds int        `json:"duration_seconds"`
+	Score           *float64   `json:"score,omitempty"`
+	PlayedAt        time.Time  `json:"played_at" gorm:"not null;index:idx_play_event_user_played_at"`
+}
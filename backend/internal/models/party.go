@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PartyPlaybackStopped = "stopped"
+	PartyPlaybackPlaying = "playing"
+	PartyPlaybackPaused  = "paused"
+)
+
+// PartyRoom é o estado compartilhado de uma sala. Ele vive no Redis, não no
+// Postgres, para que qualquer instância da API possa atender a mesma sala.
+type PartyRoom struct {
+	Code       string            `json:"code"`
+	HostUID    string            `json:"host_uid"`
+	Members    []PartyMember     `json:"members"`
+	Queue      []PartyQueueEntry `json:"queue"`
+	NowPlaying *PartyQueueEntry  `json:"now_playing"`
+	Playback   PartyPlayback     `json:"playback"`
+	Version    int64             `json:"version"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+type PartyMember struct {
+	UID         string `json:"uid"`
+	Name        string `json:"name"`
+	Connections int    `json:"connections"`
+}
+
+type PartyQueueEntry struct {
+	ID              uuid.UUID `json:"id"`
+	SongID          uuid.UUID `json:"song_id"`
+	Title           string    `json:"title"`
+	Artist          string    `json:"artist"`
+	DurationSeconds int       `json:"duration_seconds"`
+	AddedBy         string    `json:"added_by"`
+	AddedAt         time.Time `json:"added_at"`
+}
+
+// PartyPlayback guarda a posição no instante UpdatedAt. Enquanto State for
+// "playing", a posição atual é PositionMs + (agora - UpdatedAt).
+type PartyPlayback struct {
+	State      string    `json:"state"`
+	PositionMs int64     `json:"position_ms"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// PartyEvent é a mensagem transmitida para todos os clientes da sala.
+type PartyEvent struct {
+	Type       string     `json:"type"`
+	ActorUID   string     `json:"actor_uid,omitempty"`
+	Room       *PartyRoom `json:"room,omitempty"`
+	ServerTime time.Time  `json:"server_time"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PlaylistPrivate  = "private"
+	PlaylistUnlisted = "unlisted"
+	PlaylistPublic   = "public"
+)
+
+type Playlist struct {
+	ID          uuid.UUID      `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	OwnerUID    string         `json:"owner_uid" gorm:"not null;index"`
+	Owner       User           `json:"-" gorm:"foreignKey:OwnerUID;references:FirebaseUID;constraint:OnDelete:CASCADE"`
+	Name        string         `json:"name" gorm:"not null"`
+	Description string         `json:"description"`
+	Visibility  string         `json:"visibility" gorm:"not null;default:private;index"`
+	Version     int            `json:"version" gorm:"not null;default:1"`
+	Items       []PlaylistItem `json:"items,omitempty" gorm:"foreignKey:PlaylistID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type PlaylistItem struct {
+	ID         uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	PlaylistID uuid.UUID `json:"playlist_id" gorm:"type:uuid;not null;index:idx_playlist_item_position"`
+	Position   int       `json:"position" gorm:"not null;index:idx_playlist_item_position"`
+	SongID     uuid.UUID `json:"-" gorm:"type:uuid;not null"`
+	Song       Song      `json:"song" gorm:"foreignKey:SongID"`
+	AddedAt    time.Time `json:"added_at" gorm:"autoCreateTime"`
+}
+
+func IsValidPlaylistVisibility(visibility string) bool {
+	switch visibility {
+	case PlaylistPrivate, PlaylistUnlisted, PlaylistPublic:
+		return true
+	}
+	return false
+}
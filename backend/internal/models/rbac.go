@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+const (
+	RoleAdmin   = "admin"
+	RoleCurator = "curator"
+	RoleUser    = "user"
+)
+
+const (
+	PermissionCatalogWrite = "catalog:write"
+	PermissionRolesManage  = "roles:manage"
+	PermissionUsersManage  = "users:manage"
+	PermissionAuditRead    = "audit:read"
+)
+
+type Permission struct {
+	Name        string `json:"name" gorm:"primaryKey"`
+	Description string `json:"description"`
+}
+
+type Role struct {
+	Name        string       `json:"name" gorm:"primaryKey"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions;constraint:OnDelete:CASCADE"`
+}
+
+// UserRole liga um usuário a um papel. Todo usuário autenticado tem o papel
+// "user" implicitamente, sem precisar de linha nesta tabela.
+type UserRole struct {
+	UserUID   string    `json:"user_uid" gorm:"primaryKey"`
+	User      User      `json:"-" gorm:"foreignKey:UserUID;references:FirebaseUID;constraint:OnDelete:CASCADE"`
+	RoleName  string    `json:"role" gorm:"primaryKey"`
+	Role      Role      `json:"-" gorm:"foreignKey:RoleName;references:Name;constraint:OnDelete:CASCADE"`
+	GrantedBy string    `json:"granted_by"`
+	GrantedAt time.Time `json:"granted_at" gorm:"autoCreateTime"`
+}
+
+// DefaultPermissions descreve as permissões conhecidas, usadas no seed.
+var DefaultPermissions = []Permission{
+	{Name: PermissionCatalogWrite, Description: "Criar, editar e remover artistas, músicas e partes de dueto"},
+	{Name: PermissionRolesManage, Description: "Conceder e revogar papéis"},
+	{Name: PermissionUsersManage, Description: "Editar e remover contas de outros usuários"},
+	{Name: PermissionAuditRead, Description: "Consultar o audit log"},
+}
+
+// DefaultRoles descreve os papéis e suas permissões, usados no seed.
+var DefaultRoles = map[string][]string{
+	RoleAdmin:   {PermissionCatalogWrite, PermissionRolesManage, PermissionUsersManage, PermissionAuditRead},
+	RoleCurator: {PermissionCatalogWrite},
+	RoleUser:    {},
+}
@@ -0,0 +1,336 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+)
+
+// Generator converte tipos Go em schemas. Structs com nome viram componentes
+// (referenciados por $ref); structs anônimos ficam inline.
+type Generator struct {
+	// Enums lista os valores aceitos pelas regras customizadas do validator
+	// que restringem o campo a uma lista, como "visibility".
+	Enums map[string][]string
+
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+	types   map[string]reflect.Type
+}
+
+func NewGenerator(enums map[string][]string) *Generator {
+	return &Generator{
+		Enums:   enums,
+		schemas: map[string]*Schema{},
+		names:   map[reflect.Type]string{},
+		types:   map[string]reflect.Type{},
+	}
+}
+
+// Schemas devolve os componentes gerados até aqui.
+func (g *Generator) Schemas() map[string]*Schema {
+	return g.schemas
+}
+
+// Register adiciona um schema escrito à mão aos componentes e devolve a
+// referência para ele.
+func (g *Generator) Register(name string, schema *Schema) *Schema {
+	g.schemas[name] = schema
+	return Ref(name)
+}
+
+// Schema descreve o tipo de v. Para structs com nome, devolve o $ref do
+// componente.
+func (g *Generator) Schema(v any) *Schema {
+	if v == nil {
+		return nil
+	}
+	return g.typeSchema(reflect.TypeOf(v))
+}
+
+// Parameters descreve os campos de um struct de query (in "query", tags
+// `query`) ou de rota (in "path", tags `params`).
+func (g *Generator) Parameters(v any, in string) []Parameter {
+	if v == nil {
+		return nil
+	}
+	tagName := "query"
+	if in == "path" {
+		tagName = "params"
+	}
+
+	t := indirect(reflect.TypeOf(v))
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.SplitN(field.Tag.Get(tagName), ",", 2)[0]
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		rules := field.Tag.Get("validate")
+		schema := g.typeSchema(field.Type)
+		g.applyRules(schema, rules)
+		params = append(params, Parameter{
+			Name:     name,
+			In:       in,
+			Required: in == "path" || requiredByRules(rules),
+			Schema:   schema,
+		})
+	}
+	return params
+}
+
+func (g *Generator) typeSchema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := g.typeSchema(t.Elem())
+		// Em 3.0 nullable não vale ao lado de $ref
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer"}
+	case reflect.Int32, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := g.componentName(t)
+		if _, ok := g.schemas[name]; !ok {
+			// Reservado antes de descer nos campos, para tipos recursivos
+			schema := &Schema{}
+			g.schemas[name] = schema
+			*schema = *g.structSchema(t)
+		}
+		return Ref(name)
+	}
+	// interface{} e afins aceitam qualquer valor
+	return &Schema{}
+}
+
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// Structs embutidos sem nome no json têm os campos promovidos
+		if field.Anonymous && name == "" {
+			if embedded := indirect(field.Type); embedded.Kind() == reflect.Struct {
+				inner := g.structSchema(embedded)
+				for prop, propSchema := range inner.Properties {
+					schema.Properties[prop] = propSchema
+				}
+				schema.Required = append(schema.Required, inner.Required...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		rules := field.Tag.Get("validate")
+		propSchema := g.typeSchema(field.Type)
+		g.applyRules(propSchema, rules)
+		schema.Properties[name] = propSchema
+		if fieldRequired(field, rules, options) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// fieldRequired decide se o campo é obrigatório: nas requisições, pelas
+// regras required/notblank; nas respostas (sem validate), por sempre ser
+// serializado.
+func fieldRequired(field reflect.StructField, rules, jsonOptions string) bool {
+	if requiredByRules(rules) {
+		return true
+	}
+	if rules != "" || field.Type.Kind() == reflect.Ptr {
+		return false
+	}
+	for _, option := range strings.Split(jsonOptions, ",") {
+		if option == "omitempty" {
+			return false
+		}
+	}
+	return true
+}
+
+// applyRules traduz as regras do validator em restrições do schema. As
+// regras depois de "dive" valem para os itens da lista.
+func (g *Generator) applyRules(schema *Schema, rules string) {
+	if rules == "" || schema.Ref != "" {
+		return
+	}
+	for _, rule := range strings.Split(rules, ",") {
+		if rule == "dive" {
+			if schema.Items != nil {
+				_, itemRules, _ := strings.Cut(rules, "dive,")
+				g.applyRules(schema.Items, itemRules)
+			}
+			return
+		}
+		// Alternativas como "eq=|http_url": basta uma delas definir o formato
+		for _, alternative := range strings.Split(rule, "|") {
+			name, param, _ := strings.Cut(alternative, "=")
+			g.applyRule(schema, name, param)
+		}
+	}
+}
+
+func (g *Generator) applyRule(schema *Schema, name, param string) {
+	if values, ok := g.Enums[name]; ok {
+		schema.Enum = values
+		return
+	}
+
+	switch name {
+	case "min", "gte":
+		setBound(schema, param, &schema.MinLength, &schema.MinItems, &schema.Minimum)
+	case "max", "lte":
+		setBound(schema, param, &schema.MaxLength, &schema.MaxItems, &schema.Maximum)
+	case "gt":
+		if schema.Format == "date-time" {
+			schema.Description = "Deve estar no futuro."
+			return
+		}
+		if setBound(schema, param, nil, nil, &schema.Minimum) {
+			schema.ExclusiveMinimum = true
+		}
+	case "oneof":
+		schema.Enum = strings.Fields(param)
+	case "uuid":
+		schema.Format = "uuid"
+	case "email":
+		schema.Format = "email"
+	case "url", "http_url":
+		schema.Format = "uri"
+	case "datetime":
+		schema.Format = "date-time"
+	case "unique":
+		schema.UniqueItems = true
+	}
+}
+
+// setBound grava o limite no campo que combina com o tipo do schema:
+// tamanho para strings, itens para listas e valor para números.
+func setBound(schema *Schema, param string, length, items **int, value **float64) bool {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return false
+	}
+	switch schema.Type {
+	case "string":
+		if length != nil {
+			*length = intPtr(int(n))
+			return true
+		}
+	case "array", "object":
+		if items != nil {
+			*items = intPtr(int(n))
+			return true
+		}
+	case "integer", "number":
+		*value = &n
+		return true
+	}
+	return false
+}
+
+// componentName é o nome do tipo no documento. Genéricos como
+// service.Page[models.Favorite] viram "FavoritePage"; nomes repetidos em
+// pacotes diferentes levam o pacote como prefixo.
+func (g *Generator) componentName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if base, args, ok := strings.Cut(name, "["); ok {
+		var prefix strings.Builder
+		for _, arg := range strings.Split(strings.TrimSuffix(args, "]"), ",") {
+			arg = strings.TrimLeft(arg, "*[]")
+			prefix.WriteString(arg[strings.LastIndex(arg, ".")+1:])
+		}
+		name = prefix.String() + base
+	}
+	if other, taken := g.types[name]; taken && other != t {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+
+	g.names[t] = name
+	g.types[name] = t
+	return name
+}
+
+// requiredByRules diz se as regras do próprio campo (antes de "dive") exigem
+// um valor. Com omitempty/omitnil a regra só vale quando o campo vem.
+func requiredByRules(rules string) bool {
+	rules, _, _ = strings.Cut(rules, ",dive")
+	return hasRule(rules, "required", "notblank") && !hasRule(rules, "omitempty", "omitnil")
+}
+
+func hasRule(rules string, names ...string) bool {
+	for _, rule := range strings.Split(rules, ",") {
+		for _, name := range names {
+			if rule == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func intPtr(n int) *int {
+	return &n
+}
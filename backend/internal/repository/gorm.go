@@ -0,0 +1,404 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore usa o Postgres. Dentro de Transaction, os repositórios usam o
+// tx em vez do db.
+func NewGormStore(db *gorm.DB) Store {
+	return &gormStore{db: db}
+}
+
+func (s *gormStore) Users() UserRepository               { return gormUsers{s.db} }
+func (s *gormStore) Artists() ArtistRepository           { return gormArtists{s.db} }
+func (s *gormStore) Genres() GenreRepository             { return gormGenres{s.db} }
+func (s *gormStore) Songs() SongRepository               { return gormSongs{s.db} }
+func (s *gormStore) AuthSessions() AuthSessionRepository { return gormAuthSessions{s.db} }
+func (s *gormStore) Roles() RoleRepository               { return gormRoles{s.db} }
+func (s *gormStore) Audit() AuditRepository              { return gormAudit{s.db} }
+func (s *gormStore) Accounts() AccountRepository         { return gormAccounts{s.db} }
+
+func (s *gormStore) Transaction(ctx context.Context, fn func(tx Store) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormStore{db: tx})
+	})
+}
+
+// translate troca os erros do GORM pelos do pacote. Duplicidade só é
+// reconhecida com TranslateError ligado na conexão.
+func translate(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicate
+	}
+	return err
+}
+
+func first[T any](query *gorm.DB) (*T, error) {
+	var value T
+	if err := query.First(&value).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &value, nil
+}
+
+type gormUsers struct{ db *gorm.DB }
+
+func (r gormUsers) Create(ctx context.Context, user *models.User) error {
+	return translate(r.db.WithContext(ctx).Create(user).Error)
+}
+
+func (r gormUsers) Save(ctx context.Context, user *models.User) error {
+	return translate(r.db.WithContext(ctx).Save(user).Error)
+}
+
+func (r gormUsers) FindByFirebaseUID(ctx context.Context, firebaseUID string) (*models.User, error) {
+	return first[models.User](r.db.WithContext(ctx).Where("firebase_uid = ?", firebaseUID))
+}
+
+func (r gormUsers) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return first[models.User](r.db.WithContext(ctx).Where("email = ?", email))
+}
+
+func (r gormUsers) FindByName(ctx context.Context, name string) (*models.User, error) {
+	return first[models.User](r.db.WithContext(ctx).Where("name = ?", name))
+}
+
+func (r gormUsers) Update(ctx context.Context, user *models.User, update UserUpdate) error {
+	fields := map[string]interface{}{}
+	if update.Name != nil {
+		fields["name"] = *update.Name
+	}
+	if update.ProfilePicture != nil {
+		fields["profile_picture"] = *update.ProfilePicture
+	}
+	if update.IsActive != nil {
+		fields["is_active"] = *update.IsActive
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return translate(r.db.WithContext(ctx).Model(user).Updates(fields).Error)
+}
+
+type gormArtists struct{ db *gorm.DB }
+
+func (r gormArtists) Create(ctx context.Context, artist *models.Artist) error {
+	return translate(r.db.WithContext(ctx).Create(artist).Error)
+}
+
+func (r gormArtists) FindByID(ctx context.Context, id uuid.UUID) (*models.Artist, error) {
+	return first[models.Artist](r.db.WithContext(ctx).Where("id = ?", id))
+}
+
+func (r gormArtists) Search(ctx context.Context, term string, limit int) ([]models.Artist, error) {
+	var artists []models.Artist
+	err := r.db.WithContext(ctx).
+		Where("normalized_name LIKE ?", "%"+term+"%").
+		Order("name ASC").
+		Limit(limit).
+		Find(&artists).Error
+	return artists, err
+}
+
+func (r gormArtists) List(ctx context.Context) ([]models.Artist, error) {
+	var artists []models.Artist
+	err := r.db.WithContext(ctx).Order("name ASC").Find(&artists).Error
+	return artists, err
+}
+
+func (r gormArtists) Update(ctx context.Context, artist *models.Artist) error {
+	return translate(r.db.WithContext(ctx).Model(&models.Artist{}).Where("id = ?", artist.ID).Updates(artist).Error)
+}
+
+func (r gormArtists) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.Artist{}).Error
+}
+
+type gormGenres struct{ db *gorm.DB }
+
+func (r gormGenres) Create(ctx context.Context, genre *models.Genre) error {
+	return translate(r.db.WithContext(ctx).Create(genre).Error)
+}
+
+func (r gormGenres) FindByID(ctx context.Context, id uuid.UUID) (*models.Genre, error) {
+	return first[models.Genre](r.db.WithContext(ctx).Where("id = ?", id))
+}
+
+func (r gormGenres) FindByName(ctx context.Context, name string) (*models.Genre, error) {
+	return first[models.Genre](r.db.WithContext(ctx).Where("name = ?", name))
+}
+
+func (r gormGenres) List(ctx context.Context) ([]models.Genre, error) {
+	var genres []models.Genre
+	err := r.db.WithContext(ctx).Order("name ASC").Find(&genres).Error
+	return genres, err
+}
+
+type gormSongs struct{ db *gorm.DB }
+
+func (r gormSongs) Create(ctx context.Context, song *models.Song) error {
+	return translate(r.db.WithContext(ctx).Omit(clause.Associations).Create(song).Error)
+}
+
+func (r gormSongs) FindByID(ctx context.Context, id uuid.UUID) (*models.Song, error) {
+	return first[models.Song](r.db.WithContext(ctx).Preload("Artist").Preload("Genre").Where("id = ?", id))
+}
+
+func (r gormSongs) ListByArtist(ctx context.Context, artistID uuid.UUID) ([]models.Song, error) {
+	var songs []models.Song
+	err := r.db.WithContext(ctx).Where("artist_id = ?", artistID).Order("title ASC").Find(&songs).Error
+	return songs, err
+}
+
+type gormAuthSessions struct{ db *gorm.DB }
+
+func (r gormAuthSessions) Upsert(ctx context.Context, session *models.AuthSession) error {
+	return r.db.WithContext(ctx).
+		Omit(clause.Associations).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_uid"}, {Name: "auth_time"}},
+			DoUpdates: clause.AssignmentColumns([]string{"last_seen_at", "user_agent", "ip_address"}),
+		}).
+		Create(session).Error
+}
+
+func (r gormAuthSessions) ListActive(ctx context.Context, userUID string, since time.Time) ([]models.AuthSession, error) {
+	var sessions []models.AuthSession
+	err := r.db.WithContext(ctx).
+		Where("user_uid = ? AND revoked_at IS NULL AND auth_time >= ?", userUID, since).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r gormAuthSessions) RevokedAuthTimes(ctx context.Context, userUID string, since time.Time) ([]time.Time, error) {
+	var authTimes []time.Time
+	err := r.db.WithContext(ctx).Model(&models.AuthSession{}).
+		Where("user_uid = ? AND revoked_at IS NOT NULL AND auth_time >= ?", userUID, since).
+		Pluck("auth_time", &authTimes).Error
+	return authTimes, err
+}
+
+func (r gormAuthSessions) Revoke(ctx context.Context, userUID string, id uuid.UUID, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.AuthSession{}).
+		Where("id = ? AND user_uid = ? AND revoked_at IS NULL", id, userUID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r gormAuthSessions) RevokeAll(ctx context.Context, userUID string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.AuthSession{}).
+		Where("user_uid = ? AND revoked_at IS NULL", userUID).
+		Update("revoked_at", at).Error
+}
+
+type gormRoles struct{ db *gorm.DB }
+
+func (r gormRoles) List(ctx context.Context) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").Order("name ASC").Find(&roles).Error
+	return roles, err
+}
+
+func (r gormRoles) Exists(ctx context.Context, name string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Role{}).Where("name = ?", name).Count(&count).Error
+	return count > 0, err
+}
+
+func (r gormRoles) UserRoles(ctx context.Context, userUID string) ([]models.UserRole, error) {
+	var userRoles []models.UserRole
+	err := r.db.WithContext(ctx).Where("user_uid = ?", userUID).Order("role_name ASC").Find(&userRoles).Error
+	return userRoles, err
+}
+
+func (r gormRoles) Permissions(ctx context.Context, roles []string) ([]string, error) {
+	var permissions []string
+	err := r.db.WithContext(ctx).Table("role_permissions").
+		Distinct("permission_name").
+		Where("role_name IN ?", roles).
+		Pluck("permission_name", &permissions).Error
+	return permissions, err
+}
+
+func (r gormRoles) Grant(ctx context.Context, userRole *models.UserRole) (bool, error) {
+	result := r.db.WithContext(ctx).Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(userRole)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r gormRoles) Revoke(ctx context.Context, userUID, role string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("user_uid = ? AND role_name = ?", userUID, role).Delete(&models.UserRole{})
+	return result.RowsAffected > 0, result.Error
+}
+
+type gormAudit struct{ db *gorm.DB }
+
+func (r gormAudit) Create(ctx context.Context, entry *models.AuditLog) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r gormAudit) Query(ctx context.Context, filter AuditFilter, offset, limit int) ([]models.AuditLog, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.AuditLog{})
+	if filter.ActorUID != "" {
+		query = query.Where("actor_uid = ?", filter.ActorUID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.AuditLog
+	err := query.
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+type gormAccounts struct{ db *gorm.DB }
+
+func (r gormAccounts) Data(ctx context.Context, userUID string) (*AccountData, error) {
+	db := r.db.WithContext(ctx)
+	var data AccountData
+	queries := []struct {
+		dest  interface{}
+		query *gorm.DB
+	}{
+		{&data.Roles, db.Where("user_uid = ?", userUID)},
+		{&data.AuthSessions, db.Where("user_uid = ?", userUID).Order("auth_time DESC")},
+		{&data.APIKeys, db.Where("user_uid = ?", userUID).Order("created_at DESC")},
+		{&data.KaraokeSessions, db.Preload("Participants").
+			Where("user_uid = ? OR id IN (?)", userUID,
+				db.Model(&models.SessionParticipant{}).Select("session_id").Where("user_uid = ?", userUID)).
+			Order("started_at DESC")},
+		{&data.PlayHistory, db.Where("user_uid = ?", userUID).Order("played_at DESC")},
+		{&data.Favorites, db.Preload("Song").Where("user_uid = ?", userUID).Order("created_at DESC")},
+		{&data.Playlists, db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).Preload("Items.Song").Where("owner_uid = ?", userUID).Order("created_at ASC")},
+	}
+	for _, q := range queries {
+		if err := q.query.Find(q.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &data, nil
+}
+
+func (r gormAccounts) APIKeyHashes(ctx context.Context, userUID string) ([]string, error) {
+	var hashes []string
+	err := r.db.WithContext(ctx).Model(&models.APIKey{}).Where("user_uid = ?", userUID).Pluck("hash", &hashes).Error
+	return hashes, err
+}
+
+func (r gormAccounts) ScheduleDeletion(ctx context.Context, deletion *models.AccountDeletion) (bool, error) {
+	db := r.db.WithContext(ctx)
+	result := db.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(deletion)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+	return false, db.Where("user_uid = ?", deletion.UserUID).First(deletion).Error
+}
+
+func (r gormAccounts) FindDeletion(ctx context.Context, userUID string) (*models.AccountDeletion, error) {
+	return first[models.AccountDeletion](r.db.WithContext(ctx).Where("user_uid = ?", userUID))
+}
+
+func (r gormAccounts) CancelDeletion(ctx context.Context, userUID string) (*models.AccountDeletion, error) {
+	var deletions []models.AccountDeletion
+	err := r.db.WithContext(ctx).Clauses(clause.Returning{}).Where("user_uid = ?", userUID).Delete(&deletions).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(deletions) == 0 {
+		return nil, ErrNotFound
+	}
+	return &deletions[0], nil
+}
+
+func (r gormAccounts) DueDeletions(ctx context.Context, now time.Time, maxAttempts int) ([]models.AccountDeletion, error) {
+	var due []models.AccountDeletion
+	err := r.db.WithContext(ctx).
+		Where("scheduled_for <= ? AND attempts < ?", now, maxAttempts).
+		Find(&due).Error
+	return due, err
+}
+
+func (r gormAccounts) DeletionFailed(ctx context.Context, userUID string, cause error) error {
+	return r.db.WithContext(ctx).Model(&models.AccountDeletion{}).
+		Where("user_uid = ?", userUID).
+		Updates(map[string]interface{}{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": cause.Error(),
+		}).Error
+}
+
+// Purge roda vários comandos; chame dentro de Transaction. Histórico e
+// sessões de karaokê guardam só o UID, sem chave estrangeira, então não saem
+// em cascata com a linha de users.
+func (r gormAccounts) Purge(ctx context.Context, userUID string) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("user_uid = ?", userUID).Delete(&models.PlayEvent{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("user_uid = ?", userUID).Delete(&models.SessionParticipant{}).Error; err != nil {
+		return err
+	}
+	// Depois do passo anterior, quem sobrou nas sessões é outro usuário
+	shared := db.Model(&models.SessionParticipant{}).Select("session_id")
+	err := db.Model(&models.KaraokeSession{}).
+		Where("user_uid = ? AND id IN (?)", userUID, shared).
+		Update("user_uid", models.DeletedUserUID).Error
+	if err != nil {
+		return err
+	}
+	if err := db.Where("user_uid = ?", userUID).Delete(&models.KaraokeSession{}).Error; err != nil {
+		return err
+	}
+	return db.Where("firebase_uid = ?", userUID).Delete(&models.User{}).Error
+}
@@ -0,0 +1,708 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+)
+
+// MemoryStore guarda tudo em mapas, para testes. Imita o que o Postgres faz
+// por conta própria: gera IDs e datas, aplica os defaults e as chaves únicas
+// dos modelos. Transações são serializadas entre si e desfeitas restaurando
+// uma cópia dos dados; não podem ser aninhadas.
+type MemoryStore struct {
+	mu   sync.Mutex
+	txMu sync.Mutex
+	data memoryData
+}
+
+type userRoleKey struct {
+	userUID string
+	role    string
+}
+
+type memoryData struct {
+	users     map[string]models.User
+	artists   map[uuid.UUID]models.Artist
+	genres    map[uuid.UUID]models.Genre
+	songs     map[uuid.UUID]models.Song
+	sessions  map[uuid.UUID]models.AuthSession
+	roles     map[string]models.Role
+	userRoles map[userRoleKey]models.UserRole
+	deletions map[string]models.AccountDeletion
+	audit     []models.AuditLog
+}
+
+// NewMemoryStore já vem com os papéis e permissões padrão, como o seed do
+// Postgres.
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{data: memoryData{
+		users:     map[string]models.User{},
+		artists:   map[uuid.UUID]models.Artist{},
+		genres:    map[uuid.UUID]models.Genre{},
+		songs:     map[uuid.UUID]models.Song{},
+		sessions:  map[uuid.UUID]models.AuthSession{},
+		roles:     map[string]models.Role{},
+		userRoles: map[userRoleKey]models.UserRole{},
+		deletions: map[string]models.AccountDeletion{},
+	}}
+
+	descriptions := map[string]string{}
+	for _, permission := range models.DefaultPermissions {
+		descriptions[permission.Name] = permission.Description
+	}
+	for name, permissionNames := range models.DefaultRoles {
+		role := models.Role{Name: name}
+		for _, permission := range permissionNames {
+			role.Permissions = append(role.Permissions, models.Permission{Name: permission, Description: descriptions[permission]})
+		}
+		s.data.roles[name] = role
+	}
+	return s
+}
+
+func (s *MemoryStore) Users() UserRepository               { return memoryUsers{s} }
+func (s *MemoryStore) Artists() ArtistRepository           { return memoryArtists{s} }
+func (s *MemoryStore) Genres() GenreRepository             { return memoryGenres{s} }
+func (s *MemoryStore) Songs() SongRepository               { return memorySongs{s} }
+func (s *MemoryStore) AuthSessions() AuthSessionRepository { return memoryAuthSessions{s} }
+func (s *MemoryStore) Roles() RoleRepository               { return memoryRoles{s} }
+func (s *MemoryStore) Audit() AuditRepository              { return memoryAudit{s} }
+func (s *MemoryStore) Accounts() AccountRepository         { return memoryAccounts{s} }
+
+func (s *MemoryStore) Transaction(ctx context.Context, fn func(tx Store) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.Lock()
+	snapshot := s.data.clone()
+	s.mu.Unlock()
+
+	if err := fn(s); err != nil {
+		s.mu.Lock()
+		s.data = snapshot
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// read e write dão acesso exclusivo aos dados durante fn.
+func (s *MemoryStore) read(fn func(d *memoryData)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.data)
+}
+
+func (s *MemoryStore) write(fn func(d *memoryData) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(&s.data)
+}
+
+func (d memoryData) clone() memoryData {
+	return memoryData{
+		users:     cloneMap(d.users),
+		artists:   cloneMap(d.artists),
+		genres:    cloneMap(d.genres),
+		songs:     cloneMap(d.songs),
+		sessions:  cloneMap(d.sessions),
+		roles:     cloneMap(d.roles),
+		userRoles: cloneMap(d.userRoles),
+		deletions: cloneMap(d.deletions),
+		audit:     append([]models.AuditLog(nil), d.audit...),
+	}
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	out := make(map[K]V, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func sortedValues[K comparable, V any](m map[K]V, keep func(V) bool, less func(a, b V) bool) []V {
+	var out []V
+	for _, v := range m {
+		if keep == nil || keep(v) {
+			out = append(out, v)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return less(out[i], out[j]) })
+	return out
+}
+
+type memoryUsers struct{ s *MemoryStore }
+
+func (r memoryUsers) Create(ctx context.Context, user *models.User) error {
+	return r.s.write(func(d *memoryData) error {
+		if _, ok := d.users[user.FirebaseUID]; ok {
+			return ErrDuplicate
+		}
+		if err := r.checkEmail(d, user); err != nil {
+			return err
+		}
+		now := time.Now()
+		user.CreatedAt, user.UpdatedAt = now, now
+		// O GORM não grava o zero de campos com default: is_active fica true
+		user.IsActive = true
+		d.users[user.FirebaseUID] = *user
+		return nil
+	})
+}
+
+func (r memoryUsers) Save(ctx context.Context, user *models.User) error {
+	return r.s.write(func(d *memoryData) error {
+		if err := r.checkEmail(d, user); err != nil {
+			return err
+		}
+		if user.CreatedAt.IsZero() {
+			user.CreatedAt = time.Now()
+		}
+		user.UpdatedAt = time.Now()
+		d.users[user.FirebaseUID] = *user
+		return nil
+	})
+}
+
+func (r memoryUsers) checkEmail(d *memoryData, user *models.User) error {
+	for uid, other := range d.users {
+		if uid != user.FirebaseUID && other.Email == user.Email {
+			return ErrDuplicate
+		}
+	}
+	return nil
+}
+
+func (r memoryUsers) FindByFirebaseUID(ctx context.Context, firebaseUID string) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.FirebaseUID == firebaseUID })
+}
+
+func (r memoryUsers) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.Email == email })
+}
+
+func (r memoryUsers) FindByName(ctx context.Context, name string) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.Name == name })
+}
+
+func (r memoryUsers) find(match func(models.User) bool) (*models.User, error) {
+	var found []models.User
+	r.s.read(func(d *memoryData) {
+		found = sortedValues(d.users, match, func(a, b models.User) bool { return a.FirebaseUID < b.FirebaseUID })
+	})
+	if len(found) == 0 {
+		return nil, ErrNotFound
+	}
+	return &found[0], nil
+}
+
+func (r memoryUsers) Update(ctx context.Context, user *models.User, update UserUpdate) error {
+	if update == (UserUpdate{}) {
+		return nil
+	}
+	return r.s.write(func(d *memoryData) error {
+		stored, ok := d.users[user.FirebaseUID]
+		if !ok {
+			return nil
+		}
+		if update.Name != nil {
+			stored.Name = *update.Name
+		}
+		if update.ProfilePicture != nil {
+			stored.ProfilePicture = *update.ProfilePicture
+		}
+		if update.IsActive != nil {
+			stored.IsActive = *update.IsActive
+		}
+		stored.UpdatedAt = time.Now()
+		d.users[user.FirebaseUID] = stored
+		*user = stored
+		return nil
+	})
+}
+
+type memoryArtists struct{ s *MemoryStore }
+
+func (r memoryArtists) Create(ctx context.Context, artist *models.Artist) error {
+	return r.s.write(func(d *memoryData) error {
+		if artist.ID == uuid.Nil {
+			artist.ID = uuid.New()
+		}
+		if _, ok := d.artists[artist.ID]; ok {
+			return ErrDuplicate
+		}
+		if err := r.checkNames(d, *artist); err != nil {
+			return err
+		}
+		d.artists[artist.ID] = *artist
+		return nil
+	})
+}
+
+func (r memoryArtists) checkNames(d *memoryData, artist models.Artist) error {
+	for id, other := range d.artists {
+		if id != artist.ID && (other.Name == artist.Name || other.NormalizedName == artist.NormalizedName) {
+			return ErrDuplicate
+		}
+	}
+	return nil
+}
+
+func (r memoryArtists) FindByID(ctx context.Context, id uuid.UUID) (*models.Artist, error) {
+	var (
+		artist models.Artist
+		ok     bool
+	)
+	r.s.read(func(d *memoryData) { artist, ok = d.artists[id] })
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &artist, nil
+}
+
+func (r memoryArtists) Search(ctx context.Context, term string, limit int) ([]models.Artist, error) {
+	artists := r.list(func(a models.Artist) bool { return strings.Contains(a.NormalizedName, term) })
+	if limit > 0 && len(artists) > limit {
+		artists = artists[:limit]
+	}
+	return artists, nil
+}
+
+func (r memoryArtists) List(ctx context.Context) ([]models.Artist, error) {
+	return r.list(nil), nil
+}
+
+func (r memoryArtists) list(keep func(models.Artist) bool) []models.Artist {
+	var artists []models.Artist
+	r.s.read(func(d *memoryData) {
+		artists = sortedValues(d.artists, keep, func(a, b models.Artist) bool { return a.Name < b.Name })
+	})
+	return artists
+}
+
+func (r memoryArtists) Update(ctx context.Context, artist *models.Artist) error {
+	return r.s.write(func(d *memoryData) error {
+		stored, ok := d.artists[artist.ID]
+		if !ok {
+			return nil
+		}
+		if artist.Name != "" {
+			stored.Name = artist.Name
+		}
+		if artist.NormalizedName != "" {
+			stored.NormalizedName = artist.NormalizedName
+		}
+		if err := r.checkNames(d, stored); err != nil {
+			return err
+		}
+		d.artists[artist.ID] = stored
+		return nil
+	})
+}
+
+func (r memoryArtists) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.s.write(func(d *memoryData) error {
+		delete(d.artists, id)
+		return nil
+	})
+}
+
+type memoryGenres struct{ s *MemoryStore }
+
+func (r memoryGenres) Create(ctx context.Context, genre *models.Genre) error {
+	return r.s.write(func(d *memoryData) error {
+		if genre.ID == uuid.Nil {
+			genre.ID = uuid.New()
+		}
+		for _, other := range d.genres {
+			if other.ID == genre.ID || other.Name == genre.Name {
+				return ErrDuplicate
+			}
+		}
+		d.genres[genre.ID] = *genre
+		return nil
+	})
+}
+
+func (r memoryGenres) FindByID(ctx context.Context, id uuid.UUID) (*models.Genre, error) {
+	return r.find(func(g models.Genre) bool { return g.ID == id })
+}
+
+func (r memoryGenres) FindByName(ctx context.Context, name string) (*models.Genre, error) {
+	return r.find(func(g models.Genre) bool { return g.Name == name })
+}
+
+func (r memoryGenres) find(match func(models.Genre) bool) (*models.Genre, error) {
+	genres := r.list(match)
+	if len(genres) == 0 {
+		return nil, ErrNotFound
+	}
+	return &genres[0], nil
+}
+
+func (r memoryGenres) List(ctx context.Context) ([]models.Genre, error) {
+	return r.list(nil), nil
+}
+
+func (r memoryGenres) list(keep func(models.Genre) bool) []models.Genre {
+	var genres []models.Genre
+	r.s.read(func(d *memoryData) {
+		genres = sortedValues(d.genres, keep, func(a, b models.Genre) bool { return a.Name < b.Name })
+	})
+	return genres
+}
+
+type memorySongs struct{ s *MemoryStore }
+
+func (r memorySongs) Create(ctx context.Context, song *models.Song) error {
+	return r.s.write(func(d *memoryData) error {
+		if song.ID == uuid.Nil {
+			song.ID = uuid.New()
+		}
+		for _, other := range d.songs {
+			if other.ID == song.ID || other.NormalizedTitle == song.NormalizedTitle {
+				return ErrDuplicate
+			}
+		}
+		if song.CreatedAt.IsZero() {
+			song.CreatedAt = time.Now()
+		}
+		stored := *song
+		stored.Artist, stored.Genre = models.Artist{}, models.Genre{}
+		d.songs[song.ID] = stored
+		return nil
+	})
+}
+
+func (r memorySongs) FindByID(ctx context.Context, id uuid.UUID) (*models.Song, error) {
+	var (
+		song models.Song
+		ok   bool
+	)
+	r.s.read(func(d *memoryData) {
+		song, ok = d.songs[id]
+		song.Artist = d.artists[song.ArtistID]
+		song.Genre = d.genres[song.GenreID]
+	})
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &song, nil
+}
+
+func (r memorySongs) ListByArtist(ctx context.Context, artistID uuid.UUID) ([]models.Song, error) {
+	var songs []models.Song
+	r.s.read(func(d *memoryData) {
+		songs = sortedValues(d.songs,
+			func(s models.Song) bool { return s.ArtistID == artistID },
+			func(a, b models.Song) bool { return a.Title < b.Title },
+		)
+	})
+	return songs, nil
+}
+
+type memoryAuthSessions struct{ s *MemoryStore }
+
+func (r memoryAuthSessions) Upsert(ctx context.Context, session *models.AuthSession) error {
+	return r.s.write(func(d *memoryData) error {
+		for id, existing := range d.sessions {
+			if existing.UserUID == session.UserUID && existing.AuthTime.Equal(session.AuthTime) {
+				existing.LastSeenAt = session.LastSeenAt
+				existing.UserAgent = session.UserAgent
+				existing.IPAddress = session.IPAddress
+				d.sessions[id] = existing
+				*session = existing
+				return nil
+			}
+		}
+		if session.ID == uuid.Nil {
+			session.ID = uuid.New()
+		}
+		session.CreatedAt = time.Now()
+		d.sessions[session.ID] = *session
+		return nil
+	})
+}
+
+func (r memoryAuthSessions) ListActive(ctx context.Context, userUID string, since time.Time) ([]models.AuthSession, error) {
+	var sessions []models.AuthSession
+	r.s.read(func(d *memoryData) {
+		sessions = sortedValues(d.sessions,
+			func(s models.AuthSession) bool {
+				return s.UserUID == userUID && s.RevokedAt == nil && !s.AuthTime.Before(since)
+			},
+			func(a, b models.AuthSession) bool { return a.LastSeenAt.After(b.LastSeenAt) },
+		)
+	})
+	return sessions, nil
+}
+
+func (r memoryAuthSessions) RevokedAuthTimes(ctx context.Context, userUID string, since time.Time) ([]time.Time, error) {
+	var authTimes []time.Time
+	r.s.read(func(d *memoryData) {
+		for _, s := range d.sessions {
+			if s.UserUID == userUID && s.RevokedAt != nil && !s.AuthTime.Before(since) {
+				authTimes = append(authTimes, s.AuthTime)
+			}
+		}
+	})
+	return authTimes, nil
+}
+
+func (r memoryAuthSessions) Revoke(ctx context.Context, userUID string, id uuid.UUID, at time.Time) error {
+	return r.s.write(func(d *memoryData) error {
+		session, ok := d.sessions[id]
+		if !ok || session.UserUID != userUID || session.RevokedAt != nil {
+			return ErrNotFound
+		}
+		session.RevokedAt = &at
+		d.sessions[id] = session
+		return nil
+	})
+}
+
+func (r memoryAuthSessions) RevokeAll(ctx context.Context, userUID string, at time.Time) error {
+	return r.s.write(func(d *memoryData) error {
+		for id, session := range d.sessions {
+			if session.UserUID == userUID && session.RevokedAt == nil {
+				session.RevokedAt = &at
+				d.sessions[id] = session
+			}
+		}
+		return nil
+	})
+}
+
+type memoryRoles struct{ s *MemoryStore }
+
+func (r memoryRoles) List(ctx context.Context) ([]models.Role, error) {
+	var roles []models.Role
+	r.s.read(func(d *memoryData) {
+		roles = sortedValues(d.roles, nil, func(a, b models.Role) bool { return a.Name < b.Name })
+	})
+	return roles, nil
+}
+
+func (r memoryRoles) Exists(ctx context.Context, name string) (bool, error) {
+	var ok bool
+	r.s.read(func(d *memoryData) { _, ok = d.roles[name] })
+	return ok, nil
+}
+
+func (r memoryRoles) UserRoles(ctx context.Context, userUID string) ([]models.UserRole, error) {
+	var userRoles []models.UserRole
+	r.s.read(func(d *memoryData) {
+		userRoles = sortedValues(d.userRoles,
+			func(ur models.UserRole) bool { return ur.UserUID == userUID },
+			func(a, b models.UserRole) bool { return a.RoleName < b.RoleName },
+		)
+	})
+	return userRoles, nil
+}
+
+func (r memoryRoles) Permissions(ctx context.Context, roles []string) ([]string, error) {
+	var permissions []string
+	r.s.read(func(d *memoryData) {
+		seen := map[string]bool{}
+		for _, name := range roles {
+			for _, permission := range d.roles[name].Permissions {
+				if !seen[permission.Name] {
+					seen[permission.Name] = true
+					permissions = append(permissions, permission.Name)
+				}
+			}
+		}
+	})
+	return permissions, nil
+}
+
+func (r memoryRoles) Grant(ctx context.Context, userRole *models.UserRole) (bool, error) {
+	var granted bool
+	err := r.s.write(func(d *memoryData) error {
+		key := userRoleKey{userRole.UserUID, userRole.RoleName}
+		if _, ok := d.userRoles[key]; ok {
+			return nil
+		}
+		userRole.GrantedAt = time.Now()
+		d.userRoles[key] = *userRole
+		granted = true
+		return nil
+	})
+	return granted, err
+}
+
+func (r memoryRoles) Revoke(ctx context.Context, userUID, role string) (bool, error) {
+	var revoked bool
+	err := r.s.write(func(d *memoryData) error {
+		key := userRoleKey{userUID, role}
+		_, revoked = d.userRoles[key]
+		delete(d.userRoles, key)
+		return nil
+	})
+	return revoked, err
+}
+
+type memoryAudit struct{ s *MemoryStore }
+
+func (r memoryAudit) Create(ctx context.Context, entry *models.AuditLog) error {
+	return r.s.write(func(d *memoryData) error {
+		if entry.ID == uuid.Nil {
+			entry.ID = uuid.New()
+		}
+		entry.CreatedAt = time.Now()
+		d.audit = append(d.audit, *entry)
+		return nil
+	})
+}
+
+func (r memoryAudit) Query(ctx context.Context, filter AuditFilter, offset, limit int) ([]models.AuditLog, int64, error) {
+	var matched []models.AuditLog
+	r.s.read(func(d *memoryData) {
+		// Da mais recente para a mais antiga: a ordem de inserção desempata
+		for i := len(d.audit) - 1; i >= 0; i-- {
+			if entry := d.audit[i]; filter.matches(entry) {
+				matched = append(matched, entry)
+			}
+		}
+	})
+
+	total := int64(len(matched))
+	if offset >= len(matched) {
+		return nil, total, nil
+	}
+	matched = matched[offset:]
+	if limit > 0 && len(matched) > limit {
+		matched = matched[:limit]
+	}
+	return matched, total, nil
+}
+
+func (f AuditFilter) matches(entry models.AuditLog) bool {
+	return (f.ActorUID == "" || entry.ActorUID == f.ActorUID) &&
+		(f.Action == "" || entry.Action == f.Action) &&
+		(f.TargetType == "" || entry.TargetType == f.TargetType) &&
+		(f.TargetID == "" || entry.TargetID == f.TargetID) &&
+		(f.From == nil || !entry.CreatedAt.Before(*f.From)) &&
+		(f.To == nil || entry.CreatedAt.Before(*f.To))
+}
+
+// memoryAccounts só conhece as tabelas que o MemoryStore guarda; o resto do
+// export vem vazio.
+type memoryAccounts struct{ s *MemoryStore }
+
+func (r memoryAccounts) Data(ctx context.Context, userUID string) (*AccountData, error) {
+	roles, err := r.s.Roles().UserRoles(ctx, userUID)
+	if err != nil {
+		return nil, err
+	}
+	data := AccountData{
+		Roles:           roles,
+		APIKeys:         []models.APIKey{},
+		KaraokeSessions: []models.KaraokeSession{},
+		PlayHistory:     []models.PlayEvent{},
+		Favorites:       []models.Favorite{},
+		Playlists:       []models.Playlist{},
+	}
+	r.s.read(func(d *memoryData) {
+		data.AuthSessions = sortedValues(d.sessions,
+			func(s models.AuthSession) bool { return s.UserUID == userUID },
+			func(a, b models.AuthSession) bool { return a.AuthTime.After(b.AuthTime) },
+		)
+	})
+	return &data, nil
+}
+
+func (r memoryAccounts) APIKeyHashes(ctx context.Context, userUID string) ([]string, error) {
+	return nil, nil
+}
+
+func (r memoryAccounts) ScheduleDeletion(ctx context.Context, deletion *models.AccountDeletion) (bool, error) {
+	var created bool
+	err := r.s.write(func(d *memoryData) error {
+		if existing, ok := d.deletions[deletion.UserUID]; ok {
+			*deletion = existing
+			return nil
+		}
+		deletion.RequestedAt = time.Now()
+		d.deletions[deletion.UserUID] = *deletion
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+func (r memoryAccounts) FindDeletion(ctx context.Context, userUID string) (*models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	var ok bool
+	r.s.read(func(d *memoryData) { deletion, ok = d.deletions[userUID] })
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &deletion, nil
+}
+
+func (r memoryAccounts) CancelDeletion(ctx context.Context, userUID string) (*models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	err := r.s.write(func(d *memoryData) error {
+		var ok bool
+		if deletion, ok = d.deletions[userUID]; !ok {
+			return ErrNotFound
+		}
+		delete(d.deletions, userUID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+func (r memoryAccounts) DueDeletions(ctx context.Context, now time.Time, maxAttempts int) ([]models.AccountDeletion, error) {
+	var due []models.AccountDeletion
+	r.s.read(func(d *memoryData) {
+		due = sortedValues(d.deletions,
+			func(del models.AccountDeletion) bool {
+				return !del.ScheduledFor.After(now) && del.Attempts < maxAttempts
+			},
+			func(a, b models.AccountDeletion) bool { return a.ScheduledFor.Before(b.ScheduledFor) },
+		)
+	})
+	return due, nil
+}
+
+func (r memoryAccounts) DeletionFailed(ctx context.Context, userUID string, cause error) error {
+	return r.s.write(func(d *memoryData) error {
+		if deletion, ok := d.deletions[userUID]; ok {
+			deletion.Attempts++
+			deletion.LastError = cause.Error()
+			d.deletions[userUID] = deletion
+		}
+		return nil
+	})
+}
+
+// Purge imita o ON DELETE CASCADE das tabelas que apontam para users.
+func (r memoryAccounts) Purge(ctx context.Context, userUID string) error {
+	return r.s.write(func(d *memoryData) error {
+		delete(d.users, userUID)
+		delete(d.deletions, userUID)
+		for key := range d.userRoles {
+			if key.userUID == userUID {
+				delete(d.userRoles, key)
+			}
+		}
+		for id, session := range d.sessions {
+			if session.UserUID == userUID {
+				delete(d.sessions, id)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,155 @@
+// Package repository isola o acesso aos dados dos serviços. Cada agregado tem
+// uma interface, com uma implementação em GORM para o Postgres e outra em
+// memória para os testes, que não precisam de banco.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+)
+
+var (
+	ErrNotFound  = errors.New("registro não encontrado")
+	ErrDuplicate = errors.New("registro duplicado")
+)
+
+// Store dá acesso aos repositórios. Transaction roda fn com um Store cujas
+// operações são confirmadas juntas; se fn devolver erro, todas são desfeitas.
+type Store interface {
+	Users() UserRepository
+	Artists() ArtistRepository
+	Genres() GenreRepository
+	Songs() SongRepository
+	AuthSessions() AuthSessionRepository
+	Roles() RoleRepository
+	Audit() AuditRepository
+	Accounts() AccountRepository
+	Transaction(ctx context.Context, fn func(tx Store) error) error
+}
+
+// UserUpdate lista os campos alteráveis de um usuário. Campos nil ficam como
+// estão; IsActive só é aceito nas rotas de administração.
+type UserUpdate struct {
+	Name           *string
+	ProfilePicture *string
+	IsActive       *bool
+}
+
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	// Save grava todos os campos do usuário, criando-o se não existir.
+	Save(ctx context.Context, user *models.User) error
+	FindByFirebaseUID(ctx context.Context, firebaseUID string) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByName(ctx context.Context, name string) (*models.User, error)
+	// Update grava só os campos informados e os copia para user.
+	Update(ctx context.Context, user *models.User, update UserUpdate) error
+}
+
+type ArtistRepository interface {
+	Create(ctx context.Context, artist *models.Artist) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Artist, error)
+	// Search devolve os artistas cujo nome normalizado contém term, em ordem
+	// alfabética.
+	Search(ctx context.Context, term string, limit int) ([]models.Artist, error)
+	List(ctx context.Context) ([]models.Artist, error)
+	// Update grava os campos não vazios de artist.
+	Update(ctx context.Context, artist *models.Artist) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type GenreRepository interface {
+	Create(ctx context.Context, genre *models.Genre) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Genre, error)
+	FindByName(ctx context.Context, name string) (*models.Genre, error)
+	List(ctx context.Context) ([]models.Genre, error)
+}
+
+type SongRepository interface {
+	Create(ctx context.Context, song *models.Song) error
+	// FindByID devolve a música com o artista e o gênero preenchidos.
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Song, error)
+	ListByArtist(ctx context.Context, artistID uuid.UUID) ([]models.Song, error)
+}
+
+type AuthSessionRepository interface {
+	// Upsert cria a sessão ou, se já houver uma com o mesmo usuário e
+	// auth_time, atualiza last_seen_at, user_agent e ip_address.
+	Upsert(ctx context.Context, session *models.AuthSession) error
+	// ListActive devolve as sessões não revogadas com auth_time a partir de
+	// since, da mais recente para a mais antiga.
+	ListActive(ctx context.Context, userUID string, since time.Time) ([]models.AuthSession, error)
+	RevokedAuthTimes(ctx context.Context, userUID string, since time.Time) ([]time.Time, error)
+	// Revoke devolve ErrNotFound se a sessão não existir ou já estiver
+	// revogada.
+	Revoke(ctx context.Context, userUID string, id uuid.UUID, at time.Time) error
+	RevokeAll(ctx context.Context, userUID string, at time.Time) error
+}
+
+type RoleRepository interface {
+	// List devolve os papéis com as permissões, em ordem alfabética.
+	List(ctx context.Context) ([]models.Role, error)
+	Exists(ctx context.Context, name string) (bool, error)
+	UserRoles(ctx context.Context, userUID string) ([]models.UserRole, error)
+	// Permissions devolve, sem repetição, as permissões dos papéis.
+	Permissions(ctx context.Context, roles []string) ([]string, error)
+	// Grant devolve false quando o usuário já tinha o papel.
+	Grant(ctx context.Context, userRole *models.UserRole) (bool, error)
+	// Revoke devolve false quando o usuário não tinha o papel.
+	Revoke(ctx context.Context, userUID, role string) (bool, error)
+}
+
+type AuditFilter struct {
+	ActorUID   string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, entry *models.AuditLog) error
+	// Query devolve uma página das entradas, das mais recentes para as mais
+	// antigas, e o total que casa com o filtro.
+	Query(ctx context.Context, filter AuditFilter, offset, limit int) ([]models.AuditLog, int64, error)
+}
+
+// AccountData é o que a conta tem nas outras tabelas, para o export.
+type AccountData struct {
+	Roles           []models.UserRole       `json:"roles"`
+	AuthSessions    []models.AuthSession    `json:"auth_sessions"`
+	APIKeys         []models.APIKey         `json:"api_keys"`
+	KaraokeSessions []models.KaraokeSession `json:"karaoke_sessions"`
+	PlayHistory     []models.PlayEvent      `json:"play_history"`
+	Favorites       []models.Favorite       `json:"favorites"`
+	Playlists       []models.Playlist       `json:"playlists"`
+}
+
+// AccountRepository cuida da conta como um todo: export, pedidos de exclusão
+// e a exclusão em si.
+type AccountRepository interface {
+	// Data inclui as sessões de karaokê em que o usuário participou, mesmo
+	// que abertas por outro.
+	Data(ctx context.Context, userUID string) (*AccountData, error)
+	APIKeyHashes(ctx context.Context, userUID string) ([]string, error)
+	// ScheduleDeletion grava o pedido. Se já houver um, devolve false e o
+	// copia para deletion.
+	ScheduleDeletion(ctx context.Context, deletion *models.AccountDeletion) (bool, error)
+	FindDeletion(ctx context.Context, userUID string) (*models.AccountDeletion, error)
+	// CancelDeletion apaga o pedido e o devolve, ou ErrNotFound.
+	CancelDeletion(ctx context.Context, userUID string) (*models.AccountDeletion, error)
+	// DueDeletions devolve os pedidos vencidos em now que ainda não
+	// esgotaram maxAttempts tentativas.
+	DueDeletions(ctx context.Context, now time.Time, maxAttempts int) ([]models.AccountDeletion, error)
+	// DeletionFailed conta mais uma tentativa e guarda o erro.
+	DeletionFailed(ctx context.Context, userUID string, cause error) error
+	// Purge apaga o usuário e tudo o que é dele. Sessões de dueto que ele
+	// abriu e ainda têm outros participantes ficam para eles, com o anfitrião
+	// trocado por models.DeletedUserUID. O audit log é mantido.
+	Purge(ctx context.Context, userUID string) error
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/josevitorrodriguess/any-song/backend/internal/auth"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"github.com/josevitorrodriguess/any-song/backend/internal/repository"
+)
+
+const (
+	accountDeletionInterval    = 15 * time.Minute
+	accountDeletionMaxAttempts = 10
+)
+
+var (
+	ErrDeletionNotScheduled = errors.New("não há exclusão de conta agendada")
+	ErrPurgeUnavailable     = errors.New("exclusão de contas indisponível sem autenticação e armazenamento")
+)
+
+// AccountExport reúne tudo o que a API guarda sobre o usuário. Os arquivos de
+// áudio vão no ZIP junto com o JSON; aqui ficam só os nomes.
+type AccountExport struct {
+	ExportedAt time.Time   `json:"exported_at"`
+	Profile    models.User `json:"profile"`
+	repository.AccountData
+	AudioFiles []string `json:"audio_files"`
+}
+
+// AccountService exporta os dados de um usuário e cuida da exclusão da conta,
+// que só acontece depois do prazo de carência e remove os dados do Postgres,
+// do bucket, do Redis e do provedor de autenticação.
+type AccountService struct {
+	store       repository.Store
+	redis       *redis.Client
+	storage     ObjectStorage
+	verifier    auth.TokenVerifier
+	gracePeriod time.Duration
+}
+
+func NewAccountService(store repository.Store, redisClient *redis.Client, storage ObjectStorage, verifier auth.TokenVerifier, gracePeriod time.Duration) *AccountService {
+	return &AccountService{
+		store:       store,
+		redis:       redisClient,
+		storage:     storage,
+		verifier:    verifier,
+		gracePeriod: gracePeriod,
+	}
+}
+
+func (s *AccountService) Export(ctx context.Context, userUID string) (*AccountExport, error) {
+	user, err := s.store.Users().FindByFirebaseUID(ctx, userUID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	data, err := s.store.Accounts().Data(ctx, userUID)
+	if err != nil {
+		return nil, err
+	}
+
+	audioFiles, err := s.storage.ListObjects(ctx, UserObjectPrefix(userUID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := storeAudit(ctx, s.store, models.AuditUserExport, "user", userUID, nil, nil); err != nil {
+		return nil, err
+	}
+	return &AccountExport{
+		ExportedAt:  time.Now().UTC(),
+		Profile:     *user,
+		AccountData: *data,
+		AudioFiles:  audioFiles,
+	}, nil
+}
+
+// SaveAudio guarda um áudio gerado para o usuário sob UserObjectPrefix, onde
+// o export e a exclusão da conta o encontram. Sem bucket, não faz nada.
+func (s *AccountService) SaveAudio(ctx context.Context, userUID, name string, r io.Reader) error {
+	if s.storage == nil {
+		return nil
+	}
+	return s.storage.PutObject(ctx, UserObjectPrefix(userUID)+name, r)
+}
+
+// WriteExportZip grava o export.json e os arquivos de áudio (em audio/) num
+// ZIP. Como a resposta já começou a ser enviada, erros aqui só interrompem o
+// arquivo.
+func (s *AccountService) WriteExportZip(ctx context.Context, export *AccountExport, w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	file, err := archive.Create("export.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return err
+	}
+
+	prefix := UserObjectPrefix(export.Profile.FirebaseUID)
+	for _, name := range export.AudioFiles {
+		if err := s.copyObject(ctx, archive, name, path.Join("audio", strings.TrimPrefix(name, prefix))); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func (s *AccountService) copyObject(ctx context.Context, archive *zip.Writer, objectName, entryName string) error {
+	reader, err := s.storage.OpenObject(ctx, objectName)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir objeto %s: %w", objectName, err)
+	}
+	defer reader.Close()
+
+	entry, err := archive.Create(entryName)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, reader)
+	return err
+}
+
+// ScheduleDeletion agenda a exclusão para depois do prazo de carência. Pedir
+// de novo devolve o agendamento existente.
+func (s *AccountService) ScheduleDeletion(ctx context.Context, userUID string) (*models.AccountDeletion, error) {
+	deletion := models.AccountDeletion{
+		UserUID:      userUID,
+		ScheduledFor: time.Now().UTC().Add(s.gracePeriod),
+	}
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		if _, err := tx.Users().FindByFirebaseUID(ctx, userUID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		created, err := tx.Accounts().ScheduleDeletion(ctx, &deletion)
+		if err != nil || !created {
+			return err
+		}
+		return storeAudit(ctx, tx, models.AuditDeletionRequest, "user", userUID, nil, deletion)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+func (s *AccountService) GetDeletion(ctx context.Context, userUID string) (*models.AccountDeletion, error) {
+	deletion, err := s.store.Accounts().FindDeletion(ctx, userUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDeletionNotScheduled
+	}
+	return deletion, err
+}
+
+func (s *AccountService) CancelDeletion(ctx context.Context, userUID string) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		deletion, err := tx.Accounts().CancelDeletion(ctx, userUID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrDeletionNotScheduled
+			}
+			return err
+		}
+		return storeAudit(ctx, tx, models.AuditDeletionCancel, "user", userUID, *deletion, nil)
+	})
+}
+
+// Run apaga periodicamente as contas cujo prazo de carência acabou, até ctx
+// ser cancelado. Falhas ficam registradas no pedido e são tentadas de novo.
+func (s *AccountService) Run(ctx context.Context) {
+	ticker := time.NewTicker(accountDeletionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.purgeDue(ctx)
+		}
+	}
+}
+
+func (s *AccountService) purgeDue(ctx context.Context) {
+	// Em modo degradado os pedidos ficam esperando, sem gastar tentativas.
+	if !s.canPurge() {
+		return
+	}
+
+	due, err := s.store.Accounts().DueDeletions(ctx, time.Now().UTC(), accountDeletionMaxAttempts)
+	if err != nil {
+		slog.ErrorContext(ctx, "Falha ao listar exclusões de conta pendentes", "error", err)
+		return
+	}
+
+	for _, deletion := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.Purge(ctx, deletion.UserUID); err != nil {
+			slog.ErrorContext(ctx, "Falha ao excluir a conta", "user_uid", deletion.UserUID, "error", err)
+			if err := s.store.Accounts().DeletionFailed(ctx, deletion.UserUID, err); err != nil {
+				slog.ErrorContext(ctx, "Falha ao registrar erro na exclusão de conta", "user_uid", deletion.UserUID, "error", err)
+			}
+		}
+	}
+}
+
+// Purge apaga a conta imediatamente. A ordem importa: primeiro o provedor,
+// para que o usuário não consiga entrar de novo e recriar a conta no meio do
+// processo; o Postgres fica por último porque é lá que está o pedido que o
+// worker usa para tentar de novo.
+func (s *AccountService) Purge(ctx context.Context, userUID string) error {
+	if !s.canPurge() {
+		return ErrPurgeUnavailable
+	}
+
+	user, err := s.store.Users().FindByFirebaseUID(ctx, userUID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	apiKeyHashes, err := s.store.Accounts().APIKeyHashes(ctx, userUID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.verifier.DeleteUser(ctx, userUID); err != nil {
+		return fmt.Errorf("erro ao remover usuário no provedor de autenticação: %w", err)
+	}
+	if err := s.storage.DeletePrefix(ctx, UserObjectPrefix(userUID)); err != nil {
+		return err
+	}
+	if err := s.purgeDatabase(ctx, *user); err != nil {
+		return err
+	}
+	// A conta já não existe; o que sobrar no Redis expira sozinho.
+	if err := s.purgeCache(ctx, *user, apiKeyHashes); err != nil {
+		slog.WarnContext(ctx, "Conta excluída, mas o cache não foi limpo", "user_uid", userUID, "error", err)
+	}
+	return nil
+}
+
+func (s *AccountService) canPurge() bool {
+	return s.verifier != nil && s.storage != nil
+}
+
+// purgeDatabase apaga o usuário e o que é dele; o audit log é mantido.
+// Sessões de dueto que o usuário abriu ficam com o parceiro, com o anfitrião
+// anonimizado: apagar a sessão levaria junto a participação e a nota dele.
+func (s *AccountService) purgeDatabase(ctx context.Context, user models.User) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Accounts().Purge(ctx, user.FirebaseUID); err != nil {
+			return err
+		}
+		return storeAudit(ctx, tx, models.AuditUserDelete, "user", user.FirebaseUID, user, nil)
+	})
+}
+
+func (s *AccountService) purgeCache(ctx context.Context, user models.User, apiKeyHashes []string) error {
+	if s.redis == nil {
+		return nil
+	}
+
+	keys := []string{
+		userUIDCacheKey(user.FirebaseUID),
+		userEmailCacheKey(user.Email),
+		authStateCacheKey(user.FirebaseUID),
+		rbacCacheKey(user.FirebaseUID),
+		recommendationCacheKey(user.FirebaseUID),
+	}
+	for _, hash := range apiKeyHashes {
+		keys = append(keys, apiKeyCacheKey(hash))
+	}
+
+	patterns := []string{
+		fmt.Sprintf("auth:session:seen:%s:*", user.FirebaseUID),
+		fmt.Sprintf("quota:*:%s:*", user.FirebaseUID),
+		fmt.Sprintf("ratelimit:*:uid:%s", user.FirebaseUID),
+	}
+	for _, pattern := range patterns {
+		iter := s.redis.Scan(ctx, 0, pattern, 100).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			return fmt.Errorf("erro ao buscar chaves %s no redis: %w", pattern, err)
+		}
+	}
+
+	if err := s.redis.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("erro ao remover chaves do usuário no redis: %w", err)
+	}
+	return nil
+}
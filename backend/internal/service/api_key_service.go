@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// APIKeyPrefix marca as chaves para que o AuthMiddleware as distinga de
+	// um ID token no header Authorization.
+	APIKeyPrefix = "ask_"
+
+	apiKeyTouchInterval = 5 * time.Minute
+)
+
+// Chaves inexistentes também ficam em cache, para que tentativas com chaves
+// inventadas não cheguem ao Postgres a cada requisição.
+var apiKeyCacheOptions = CacheOptions{
+	TTL:         time.Minute,
+	Jitter:      0.1,
+	NotFound:    ErrInvalidAPIKey,
+	NegativeTTL: time.Minute,
+}
+
+var (
+	ErrAPIKeyNotFound     = errors.New("API key não encontrada")
+	ErrInvalidAPIKey      = errors.New("API key inválida, expirada ou revogada")
+	ErrInvalidAPIKeyScope = errors.New("escopo de API key inválido")
+)
+
+type APIKeyService struct {
+	DB    *gorm.DB
+	cache *CacheService
+}
+
+func NewAPIKeyService(db *gorm.DB, cache *CacheService) *APIKeyService {
+	return &APIKeyService{
+		DB:    db,
+		cache: cache,
+	}
+}
+
+// IsAPIKey diz se a credencial tem o formato de uma API key.
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
+
+// CreateKey gera uma chave nova e devolve o texto dela. Esse texto não é
+// guardado e não pode ser recuperado depois.
+func (s *APIKeyService) CreateKey(ctx context.Context, userUID, name string, scopes []string, expiresAt *time.Time, createdBy string) (*models.APIKey, string, error) {
+	if len(scopes) == 0 {
+		return nil, "", fmt.Errorf("%w: informe ao menos um escopo", ErrInvalidAPIKeyScope)
+	}
+	for _, scope := range scopes {
+		if !models.IsValidAPIKeyScope(scope) {
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidAPIKeyScope, scope)
+		}
+	}
+
+	publicPart := make([]byte, 4)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(publicPart); err != nil {
+		return nil, "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	prefix := APIKeyPrefix + hex.EncodeToString(publicPart)
+	plaintext := prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	key := models.APIKey{
+		UserUID:   userUID,
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      hashAPIKey(plaintext),
+		Scopes:    scopes,
+		CreatedBy: createdBy,
+		ExpiresAt: expiresAt,
+	}
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureExists(tx, &models.User{}, "firebase_uid = ?", userUID, ErrUserNotFound); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(&key).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditAPIKeyCreate, "api_key", key.ID.String(), nil, key)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &key, plaintext, nil
+}
+
+func (s *APIKeyService) ListKeys(ctx context.Context, userUID string) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := s.DB.WithContext(ctx).
+		Where("user_uid = ?", userUID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *APIKeyService) RevokeKey(ctx context.Context, userUID string, keyID uuid.UUID) error {
+	var key models.APIKey
+	err := s.DB.WithContext(ctx).Where("id = ? AND user_uid = ?", keyID, userUID).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before := key
+		if err := tx.Model(&key).Update("revoked_at", time.Now().UTC()).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditAPIKeyRevoke, "api_key", key.ID.String(), before, key)
+	})
+	if err != nil {
+		return err
+	}
+	s.invalidate(ctx, key.Hash)
+	return nil
+}
+
+// Authenticate devolve a chave ativa correspondente ao texto recebido. O
+// resultado fica em cache por um minuto; revogar a chave limpa o cache.
+func (s *APIKeyService) Authenticate(ctx context.Context, plaintext string) (*models.APIKey, error) {
+	hash := hashAPIKey(plaintext)
+	key, err := GetOrLoad(ctx, s.cache, apiKeyCacheKey(hash), apiKeyCacheOptions, func(ctx context.Context) (models.APIKey, error) {
+		var key models.APIKey
+		err := s.DB.WithContext(ctx).Where("hash = ?", hash).First(&key).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return key, ErrInvalidAPIKey
+		}
+		return key, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+		err := s.DB.WithContext(ctx).Model(&models.APIKey{}).
+			Where("id = ?", key.ID).
+			Update("last_used_at", now.UTC()).Error
+		if err != nil {
+			slog.WarnContext(ctx, "Falha ao atualizar último uso da API key", "api_key_prefix", key.Prefix, "error", err)
+		} else {
+			s.invalidate(ctx, hash)
+		}
+	}
+	return &key, nil
+}
+
+func (s *APIKeyService) invalidate(ctx context.Context, hash string) {
+	if err := s.cache.Delete(apiKeyCacheKey(hash)); err != nil {
+		slog.WarnContext(ctx, "Erro ao invalidar API key no cache", "error", err)
+	}
+}
+
+// ensureExists devolve notFound se nenhuma linha de model casar com a
+// consulta, dentro da transação tx.
+func ensureExists(tx *gorm.DB, model interface{}, query string, value interface{}, notFound error) error {
+	var count int64
+	if err := tx.Model(model).Where(query, value).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+func apiKeyCacheKey(hash string) string {
+	return fmt.Sprintf("apikey:%s", hash)
+}
@@ -1,86 +1,102 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"unicode"
 
 	"github.com/google/uuid"
 	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"github.com/josevitorrodriguess/any-song/backend/internal/repository"
 	"golang.org/x/text/unicode/norm"
-	"gorm.io/gorm"
 )
 
+var ErrArtistNotFound = errors.New("artista não encontrado")
+
 type ArtistService struct {
-	DB *gorm.DB
+	store repository.Store
 }
 
-func NewArtistService(db *gorm.DB) *ArtistService {
+func NewArtistService(store repository.Store) *ArtistService {
 	return &ArtistService{
-		DB: db,
+		store: store,
 	}
 }
 
-func (s *ArtistService) CreateArtist(artist *models.Artist) error {
+func (s *ArtistService) CreateArtist(ctx context.Context, artist *models.Artist) error {
 	artist.NormalizedName = removeAccentsAndSpaces(artist.Name)
-	return s.DB.Create(artist).Error
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Artists().Create(ctx, artist); err != nil {
+			return err
+		}
+		return storeAudit(ctx, tx, models.AuditArtistCreate, "artist", artist.ID.String(), nil, artist)
+	})
 }
 
-func (s *ArtistService) SearchArtists(rawSearchTerm string, limit int) ([]models.Artist, error) {
-	normalizedSearchTerm := removeAccentsAndSpaces(rawSearchTerm)
-	searchPattern := "%" + normalizedSearchTerm + "%"
-
-	var artists []models.Artist
-
-	err := s.DB.Model(&models.Artist{}).
-		Where("normalized_name LIKE ?", searchPattern).
-		Order("name asc").
-		Limit(limit).
-		Find(&artists).Error
-
-	if err != nil {
-		return nil, err
-	}
-
-	return artists, nil
+func (s *ArtistService) SearchArtists(ctx context.Context, rawSearchTerm string, limit int) ([]models.Artist, error) {
+	return s.store.Artists().Search(ctx, removeAccentsAndSpaces(rawSearchTerm), limit)
 }
 
-func (s *ArtistService) GetArtistByID(id string) (*models.Artist, error) {
-	uuid, err := uuid.Parse(id)
+func (s *ArtistService) GetArtistByID(ctx context.Context, id string) (*models.Artist, error) {
+	artistID, err := uuid.Parse(id)
 	if err != nil {
 		return nil, err
 	}
-	var artist models.Artist
-	if err := s.DB.Where("id = ?", uuid).First(&artist).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	artist, err := s.store.Artists().FindByID(ctx, artistID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			return nil, nil
 		}
 		return nil, err
 	}
-	return &artist, nil
+	return artist, nil
 }
 
-func (s *ArtistService) GetAllArtists() ([]models.Artist, error) {
-	var artists []models.Artist
-	if err := s.DB.Order("name ASC").Find(&artists).Error; err != nil {
-		return nil, err
-	}
-	return artists, nil
+func (s *ArtistService) GetAllArtists(ctx context.Context) ([]models.Artist, error) {
+	return s.store.Artists().List(ctx)
 }
 
-func (s *ArtistService) UpdateArtist(artist *models.Artist) error {
+func (s *ArtistService) UpdateArtist(ctx context.Context, artist *models.Artist) error {
 	artist.NormalizedName = removeAccentsAndSpaces(artist.Name)
-	return s.DB.Model(&models.Artist{}).Where("id = ?", artist.ID).Updates(artist).Error
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		before, err := tx.Artists().FindByID(ctx, artist.ID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrArtistNotFound
+			}
+			return err
+		}
+		if err := tx.Artists().Update(ctx, artist); err != nil {
+			return err
+		}
+		after, err := tx.Artists().FindByID(ctx, artist.ID)
+		if err != nil {
+			return err
+		}
+		return storeAudit(ctx, tx, models.AuditArtistUpdate, "artist", artist.ID.String(), before, after)
+	})
 }
 
-func (s *ArtistService) DeleteArtist(id string) error {
-	var artist models.Artist
-	if err := s.DB.Where("id = ?", id).First(&artist).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil
-		}
+func (s *ArtistService) DeleteArtist(ctx context.Context, id string) error {
+	artistID, err := uuid.Parse(id)
+	if err != nil {
 		return err
 	}
-	return s.DB.Delete(&artist).Error
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		artist, err := tx.Artists().FindByID(ctx, artistID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+		if err := tx.Artists().Delete(ctx, artist.ID); err != nil {
+			return err
+		}
+		return storeAudit(ctx, tx, models.AuditArtistDelete, "artist", artist.ID.String(), artist, nil)
+	})
 }
+
 func removeAccentsAndSpaces(s string) string {
 	t := norm.NFD.String(s)
 	result := make([]rune, 0, len(t))
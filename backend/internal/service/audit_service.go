@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"github.com/josevitorrodriguess/any-song/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// AuditActor é quem fez a requisição. O AuthMiddleware coloca no contexto e
+// recordAudit lê de lá, então os serviços não precisam recebê-lo.
+type AuditActor struct {
+	UID       string
+	RequestID string
+	IPAddress string
+}
+
+type auditActorKey struct{}
+
+func WithAuditActor(ctx context.Context, actor AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+func auditActorFrom(ctx context.Context) AuditActor {
+	if ctx == nil {
+		return AuditActor{}
+	}
+	actor, _ := ctx.Value(auditActorKey{}).(AuditActor)
+	return actor
+}
+
+type AuditFilter = repository.AuditFilter
+
+type AuditService struct {
+	store repository.Store
+}
+
+func NewAuditService(store repository.Store) *AuditService {
+	return &AuditService{
+		store: store,
+	}
+}
+
+// Record grava uma entrada fora de transação, para eventos que não alteram
+// dados, como uma permissão negada.
+func (s *AuditService) Record(ctx context.Context, action, targetType, targetID string) error {
+	return storeAudit(ctx, s.store, action, targetType, targetID, nil, nil)
+}
+
+func (s *AuditService) Query(ctx context.Context, filter AuditFilter, page, pageSize int) (*Page[models.AuditLog], error) {
+	entries, total, err := s.store.Audit().Query(ctx, filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Page[models.AuditLog]{Items: entries, Page: page, PageSize: pageSize, Total: total}, nil
+}
+
+// recordAudit grava a entrada com o tx recebido, para que ela só exista se a
+// alteração auditada também for confirmada. before e after são serializados
+// em JSON e só os campos que mudaram entram no diff; nil representa criação
+// ou remoção.
+func recordAudit(tx *gorm.DB, action, targetType, targetID string, before, after interface{}) error {
+	entry, err := newAuditEntry(tx.Statement.Context, action, targetType, targetID, before, after)
+	if err != nil {
+		return err
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return fmt.Errorf("erro ao gravar audit log (%s): %w", action, err)
+	}
+	return nil
+}
+
+// storeAudit é o recordAudit dos serviços que usam o repository.
+func storeAudit(ctx context.Context, tx repository.Store, action, targetType, targetID string, before, after interface{}) error {
+	entry, err := newAuditEntry(ctx, action, targetType, targetID, before, after)
+	if err != nil {
+		return err
+	}
+	if err := tx.Audit().Create(ctx, entry); err != nil {
+		return fmt.Errorf("erro ao gravar audit log (%s): %w", action, err)
+	}
+	return nil
+}
+
+func newAuditEntry(ctx context.Context, action, targetType, targetID string, before, after interface{}) (*models.AuditLog, error) {
+	changes, err := auditDiff(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	actor := auditActorFrom(ctx)
+	return &models.AuditLog{
+		ActorUID:   actor.UID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Changes:    changes,
+		RequestID:  actor.RequestID,
+		IPAddress:  actor.IPAddress,
+	}, nil
+}
+
+func auditDiff(before, after interface{}) (map[string]models.AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]models.AuditChange)
+	for field, value := range beforeFields {
+		if newValue, ok := afterFields[field]; !ok || !reflect.DeepEqual(value, newValue) {
+			changes[field] = models.AuditChange{Before: value, After: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = models.AuditChange{After: value}
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return changes, nil
+}
+
+func auditFields(value interface{}) (map[string]interface{}, error) {
+	if value == nil || (reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil()) {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/auth"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"github.com/josevitorrodriguess/any-song/backend/internal/repository"
+)
+
+const authSessionTouchTTL = 5 * time.Minute
+
+// Revogações feitas direto no provedor só aparecem quando a chave expira,
+// então o estado de login não é servido stale.
+var authStateCacheOptions = CacheOptions{
+	TTL:    time.Minute,
+	Jitter: 0.1,
+}
+
+var (
+	ErrTokenRevoked        = errors.New("token revogado")
+	ErrUserInactive        = errors.New("usuário desativado")
+	ErrAuthSessionNotFound = errors.New("sessão de login não encontrada")
+)
+
+// AuthSessionService decide se um ID token já verificado ainda vale: o
+// usuário não pode estar desativado (no provedor ou no Postgres), o token não
+// pode ser anterior à última revogação geral e a sessão dele não pode ter sido
+// revogada individualmente. Esse estado fica em cache por um minuto para não
+// consultar o Firebase a cada requisição.
+type AuthSessionService struct {
+	store    repository.Store
+	cache    *CacheService
+	verifier auth.TokenVerifier
+}
+
+type authState struct {
+	TokensValidAfter time.Time `json:"tokens_valid_after"`
+	Disabled         bool      `json:"disabled"`
+	Inactive         bool      `json:"inactive"`
+	Registered       bool      `json:"registered"`
+	// RevokedAuthTimes guarda o auth_time (Unix) das sessões revogadas uma a
+	// uma depois da última revogação geral.
+	RevokedAuthTimes []int64 `json:"revoked_auth_times"`
+}
+
+func NewAuthSessionService(store repository.Store, cache *CacheService, verifier auth.TokenVerifier) *AuthSessionService {
+	return &AuthSessionService{
+		store:    store,
+		cache:    cache,
+		verifier: verifier,
+	}
+}
+
+// CheckToken retorna ErrUserInactive ou ErrTokenRevoked quando o token não
+// deve mais ser aceito.
+func (s *AuthSessionService) CheckToken(ctx context.Context, token *auth.Token) error {
+	state, err := s.state(ctx, token.UID)
+	if err != nil {
+		return err
+	}
+	if state.Disabled || state.Inactive {
+		return ErrUserInactive
+	}
+	if token.IssuedAt.Before(state.TokensValidAfter) {
+		return ErrTokenRevoked
+	}
+	for _, authTime := range state.RevokedAuthTimes {
+		if authTime == token.AuthTime.Unix() {
+			return ErrTokenRevoked
+		}
+	}
+	return nil
+}
+
+// CheckUser retorna ErrUserInactive quando a conta está desativada. É usado
+// para credenciais que não passam pelo provedor, como API keys.
+func (s *AuthSessionService) CheckUser(ctx context.Context, userUID string) error {
+	state, err := s.state(ctx, userUID)
+	if err != nil {
+		return err
+	}
+	if state.Disabled || state.Inactive {
+		return ErrUserInactive
+	}
+	return nil
+}
+
+// Touch registra a sessão do token ou atualiza o last_seen_at dela. A escrita
+// no Postgres acontece no máximo uma vez a cada cinco minutos por sessão, e só
+// depois que o usuário tem conta (o SignIn cria a conta e chama Touch).
+func (s *AuthSessionService) Touch(ctx context.Context, token *auth.Token, userAgent, ipAddress string) error {
+	throttleKey := fmt.Sprintf("auth:session:seen:%s:%d", token.UID, token.AuthTime.Unix())
+	var seen bool
+	found, err := s.cache.Get(throttleKey, &seen)
+	if err != nil {
+		slog.WarnContext(ctx, "Erro no cache de sessões do usuário", "user_uid", token.UID, "error", err)
+	}
+	if found {
+		return nil
+	}
+
+	state, err := s.state(ctx, token.UID)
+	if err != nil {
+		return err
+	}
+	if !state.Registered {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	session := models.AuthSession{
+		UserUID:    token.UID,
+		AuthTime:   token.AuthTime.UTC(),
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		LastSeenAt: now,
+	}
+	if err := s.store.AuthSessions().Upsert(ctx, &session); err != nil {
+		return err
+	}
+
+	if err := s.cache.Set(throttleKey, true, authSessionTouchTTL); err != nil {
+		slog.WarnContext(ctx, "Erro ao salvar sessão do usuário no cache", "user_uid", token.UID, "error", err)
+	}
+	return nil
+}
+
+// ListSessions devolve as sessões ainda ativas do usuário, da mais recente
+// para a mais antiga.
+func (s *AuthSessionService) ListSessions(ctx context.Context, userUID string) ([]models.AuthSession, error) {
+	state, err := s.state(ctx, userUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.AuthSessions().ListActive(ctx, userUID, state.TokensValidAfter.Truncate(time.Second))
+}
+
+// RevokeSession revoga uma única sessão. O provedor não permite revogar um
+// refresh token isolado, então os ID tokens com o auth_time dessa sessão
+// passam a ser recusados aqui, inclusive os renovados.
+func (s *AuthSessionService) RevokeSession(ctx context.Context, userUID string, sessionID uuid.UUID) error {
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		err := tx.AuthSessions().Revoke(ctx, userUID, sessionID, time.Now().UTC())
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrAuthSessionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return storeAudit(ctx, tx, models.AuditSessionsRevoke, "auth_session", sessionID.String(), nil, nil)
+	})
+	if err != nil {
+		return err
+	}
+	s.Invalidate(ctx, userUID)
+	return nil
+}
+
+// RevokeAll revoga os refresh tokens no provedor e marca todas as sessões do
+// usuário como revogadas.
+func (s *AuthSessionService) RevokeAll(ctx context.Context, userUID string) error {
+	if err := s.verifier.RevokeRefreshTokens(ctx, userUID); err != nil {
+		return err
+	}
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.AuthSessions().RevokeAll(ctx, userUID, time.Now().UTC()); err != nil {
+			return err
+		}
+		return storeAudit(ctx, tx, models.AuditSessionsRevoke, "user", userUID, nil, nil)
+	})
+	s.Invalidate(ctx, userUID)
+	return err
+}
+
+// Invalidate descarta o estado em cache do usuário. Deve ser chamado sempre
+// que a conta for desativada ou as sessões forem revogadas.
+func (s *AuthSessionService) Invalidate(ctx context.Context, userUID string) {
+	if err := s.cache.Delete(authStateCacheKey(userUID)); err != nil {
+		slog.WarnContext(ctx, "Erro ao invalidar estado de login do usuário no cache", "user_uid", userUID, "error", err)
+	}
+}
+
+func (s *AuthSessionService) state(ctx context.Context, userUID string) (*authState, error) {
+	state, err := GetOrLoad(ctx, s.cache, authStateCacheKey(userUID), authStateCacheOptions, func(ctx context.Context) (authState, error) {
+		var state authState
+		providerState, err := s.verifier.GetUserState(ctx, userUID)
+		if err != nil {
+			return state, fmt.Errorf("erro ao consultar revogação do usuário %s: %w", userUID, err)
+		}
+		state.TokensValidAfter = providerState.TokensValidAfter
+		state.Disabled = providerState.Disabled
+
+		// Quem ainda não tem conta no Postgres está no primeiro login e não
+		// pode estar desativado.
+		user, err := s.store.Users().FindByFirebaseUID(ctx, userUID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return state, err
+		}
+		state.Registered = user != nil
+		state.Inactive = state.Registered && !user.IsActive
+
+		revoked, err := s.store.AuthSessions().RevokedAuthTimes(ctx, userUID, state.TokensValidAfter.Truncate(time.Second))
+		if err != nil {
+			return state, err
+		}
+		for _, authTime := range revoked {
+			state.RevokedAuthTimes = append(state.RevokedAuthTimes, authTime.Unix())
+		}
+		return state, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func authStateCacheKey(userUID string) string {
+	return fmt.Sprintf("auth:state:%s", userUID)
+}
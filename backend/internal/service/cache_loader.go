@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+const (
+	cacheLockTTL      = 10 * time.Second
+	cacheLockWait     = time.Second
+	cacheLockPollTick = 25 * time.Millisecond
+)
+
+// releaseCacheLock só apaga o lock se ele ainda for nosso: se expirou e outro
+// processo o pegou, o DEL simples liberaria o lock alheio.
+var releaseCacheLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// CacheOptions define como GetOrLoad guarda uma chave.
+type CacheOptions struct {
+	// TTL é por quanto tempo o valor é servido sem recarregar.
+	TTL time.Duration
+	// Jitter espalha o TTL em até ±Jitter (0.1 = 10%), para que chaves
+	// gravadas juntas não expirem juntas.
+	Jitter float64
+	// StaleFor mantém o valor no Redis por mais esse tempo depois do TTL.
+	// Nesse intervalo ele ainda é devolvido, e uma única goroutine recarrega
+	// em segundo plano.
+	StaleFor time.Duration
+	// NotFound é o erro que o loader devolve quando o registro não existe.
+	// Com NegativeTTL > 0 a ausência também vai para o cache, e até lá
+	// GetOrLoad devolve NotFound sem chamar o loader.
+	NotFound    error
+	NegativeTTL time.Duration
+	// Lock usa um lock no Redis para que só um processo carregue a chave; os
+	// outros esperam até LockWait (padrão de 1s) pelo valor e, se ele não
+	// chegar, carregam por conta própria.
+	Lock     bool
+	LockWait time.Duration
+}
+
+// cacheEntry é o que GetOrLoad grava no Redis. FreshUntil é guardado junto
+// porque o TTL do Redis inclui o período stale.
+type cacheEntry[T any] struct {
+	Value      T         `json:"value"`
+	NotFound   bool      `json:"not_found,omitempty"`
+	FreshUntil time.Time `json:"fresh_until"`
+}
+
+func (e cacheEntry[T]) result(opts CacheOptions) (T, error) {
+	if e.NotFound {
+		var zero T
+		return zero, opts.NotFound
+	}
+	return e.Value, nil
+}
+
+// GetOrLoad devolve o valor da chave, chamando load só quando ele não está
+// no cache. Chamadas simultâneas para a mesma chave no mesmo processo
+// compartilham uma única execução de load, que roda sem o cancelamento do
+// contexto de quem chegou primeiro. Erros do Redis nunca falham a leitura:
+// viram log e métrica, e o valor vem de load.
+func GetOrLoad[T any](ctx context.Context, cache *CacheService, key string, opts CacheOptions, load func(ctx context.Context) (T, error)) (T, error) {
+	var entry cacheEntry[T]
+	found, err := cache.Get(key, &entry)
+	if err != nil {
+		slog.WarnContext(ctx, "Erro ao ler do cache", "key", key, "error", err)
+	}
+	if found {
+		if time.Now().After(entry.FreshUntil) {
+			revalidate(ctx, cache, key, opts, load)
+		}
+		return entry.result(opts)
+	}
+
+	shared, err, _ := cache.loads.Do(key, func() (any, error) {
+		return fill(context.WithoutCancel(ctx), cache, key, opts, load, false)
+	})
+	if errors.Is(err, errCacheLockBusy) {
+		// Pegamos carona numa recarga em segundo plano que desistiu porque
+		// outro processo tem o lock; o valor stale já sumiu, então é
+		// preciso esperar por ele
+		shared, err = fill(context.WithoutCancel(ctx), cache, key, opts, load, false)
+	}
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return shared.(cacheEntry[T]).result(opts)
+}
+
+// revalidate recarrega em segundo plano uma chave stale. Se a recarga da
+// chave já estiver em andamento, não faz nada.
+func revalidate[T any](ctx context.Context, cache *CacheService, key string, opts CacheOptions, load func(ctx context.Context) (T, error)) {
+	ctx = context.WithoutCancel(ctx)
+	cache.loads.DoChan(key, func() (any, error) {
+		entry, err := fill(ctx, cache, key, opts, load, true)
+		if err != nil && !errors.Is(err, errCacheLockBusy) {
+			slog.WarnContext(ctx, "Erro ao recarregar valor stale do cache", "key", key, "error", err)
+		}
+		return entry, err
+	})
+}
+
+var errCacheLockBusy = errors.New("outro processo está carregando a chave")
+
+// fill chama load e grava o resultado. Com opts.Lock, quem não consegue o
+// lock espera o valor do dono dele; numa recarga em segundo plano
+// (background) desiste na hora, já que ainda há o valor stale.
+func fill[T any](ctx context.Context, cache *CacheService, key string, opts CacheOptions, load func(ctx context.Context) (T, error), background bool) (cacheEntry[T], error) {
+	if opts.Lock {
+		unlock, acquired := cache.lock(ctx, key)
+		if acquired {
+			defer unlock()
+		} else if background {
+			return cacheEntry[T]{}, errCacheLockBusy
+		} else if entry, ok := waitForEntry[T](ctx, cache, key, opts.LockWait); ok {
+			return entry, nil
+		}
+	}
+
+	value, err := load(ctx)
+	entry := cacheEntry[T]{Value: value}
+	ttl := jitterTTL(opts.TTL, opts.Jitter)
+	keep := ttl + opts.StaleFor
+	if err != nil {
+		if opts.NotFound == nil || opts.NegativeTTL <= 0 || !errors.Is(err, opts.NotFound) {
+			return entry, err
+		}
+		entry = cacheEntry[T]{NotFound: true}
+		ttl = jitterTTL(opts.NegativeTTL, opts.Jitter)
+		keep = ttl
+	}
+
+	entry.FreshUntil = time.Now().Add(ttl)
+	if err := cache.Set(key, entry, keep); err != nil {
+		slog.WarnContext(ctx, "Erro ao salvar no cache", "key", key, "error", err)
+	}
+	return entry, nil
+}
+
+// waitForEntry consulta o cache até o valor aparecer ou o tempo acabar.
+func waitForEntry[T any](ctx context.Context, cache *CacheService, key string, wait time.Duration) (cacheEntry[T], bool) {
+	if wait <= 0 {
+		wait = cacheLockWait
+	}
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	tick := time.NewTicker(cacheLockPollTick)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return cacheEntry[T]{}, false
+		case <-deadline.C:
+			return cacheEntry[T]{}, false
+		case <-tick.C:
+			var entry cacheEntry[T]
+			if found, _ := cache.Get(key, &entry); found {
+				return entry, true
+			}
+		}
+	}
+}
+
+// lock tenta pegar o lock de carga da chave. Sem Redis não há outros
+// processos a coordenar, e o lock é sempre concedido.
+func (s *CacheService) lock(ctx context.Context, key string) (unlock func(), acquired bool) {
+	if s.redisClient == nil {
+		return func() {}, true
+	}
+
+	lockKey := "lock:" + key
+	token := uuid.NewString()
+	acquired, err := s.redisClient.SetNX(ctx, lockKey, token, cacheLockTTL).Result()
+	if err != nil {
+		// Sem conseguir falar com o Redis, esperar por outro processo não
+		// adianta
+		slog.WarnContext(ctx, "Erro ao pegar lock do cache", "key", key, "error", err)
+		return func() {}, true
+	}
+	if !acquired {
+		return nil, false
+	}
+	return func() {
+		if err := releaseCacheLock.Run(ctx, s.redisClient, []string{lockKey}, token).Err(); err != nil {
+			slog.WarnContext(ctx, "Erro ao liberar lock do cache", "key", key, "error", err)
+		}
+	}, true
+}
+
+func jitterTTL(ttl time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 || ttl <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration((rand.Float64()*2-1)*jitter*float64(ttl))
+}
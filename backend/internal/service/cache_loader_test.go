@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+var errMissing = errors.New("não existe")
+
+func newTestCache(t *testing.T) (*CacheService, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	return newTestCacheOn(t, mr), mr
+}
+
+// newTestCacheOn devolve outro CacheService sobre o mesmo Redis, como um
+// segundo processo da API.
+func newTestCacheOn(t *testing.T, mr *miniredis.Miniredis) *CacheService {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewCacheService(client)
+}
+
+func TestGetOrLoadCachesValue(t *testing.T) {
+	cache, _ := newTestCache(t)
+	var loads atomic.Int32
+	load := func(ctx context.Context) (string, error) {
+		loads.Add(1)
+		return "valor", nil
+	}
+
+	for range 3 {
+		got, err := GetOrLoad(t.Context(), cache, "test:key", CacheOptions{TTL: time.Minute}, load)
+		if err != nil || got != "valor" {
+			t.Fatalf("GetOrLoad = %q, %v", got, err)
+		}
+	}
+	if n := loads.Load(); n != 1 {
+		t.Errorf("load chamado %d vezes, esperado 1", n)
+	}
+}
+
+func TestGetOrLoadDeduplicatesConcurrentLoads(t *testing.T) {
+	cache, _ := newTestCache(t)
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (int, error) {
+		loads.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = GetOrLoad(t.Context(), cache, "test:key", CacheOptions{TTL: time.Minute}, load)
+		}()
+	}
+	// Dá tempo de todas as goroutines entrarem no singleflight
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := loads.Load(); n != 1 {
+		t.Errorf("load chamado %d vezes, esperado 1", n)
+	}
+	for i, got := range results {
+		if got != 42 {
+			t.Errorf("resultado %d = %d", i, got)
+		}
+	}
+}
+
+func TestGetOrLoadDoesNotCacheErrors(t *testing.T) {
+	cache, _ := newTestCache(t)
+	var loads atomic.Int32
+	load := func(ctx context.Context) (string, error) {
+		loads.Add(1)
+		return "", errors.New("postgres fora do ar")
+	}
+
+	for range 2 {
+		if _, err := GetOrLoad(t.Context(), cache, "test:key", CacheOptions{TTL: time.Minute}, load); err == nil {
+			t.Fatal("esperado erro do load")
+		}
+	}
+	if n := loads.Load(); n != 2 {
+		t.Errorf("load chamado %d vezes, esperado 2", n)
+	}
+}
+
+func TestGetOrLoadNegativeCaching(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		opts      CacheOptions
+		wantLoads int32
+	}{
+		{"sem NegativeTTL", CacheOptions{TTL: time.Minute, NotFound: errMissing}, 2},
+		{"com NegativeTTL", CacheOptions{TTL: time.Minute, NotFound: errMissing, NegativeTTL: time.Minute}, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cache, _ := newTestCache(t)
+			var loads atomic.Int32
+			load := func(ctx context.Context) (string, error) {
+				loads.Add(1)
+				return "", errMissing
+			}
+
+			for range 2 {
+				if _, err := GetOrLoad(t.Context(), cache, "test:key", tc.opts, load); !errors.Is(err, errMissing) {
+					t.Fatalf("erro = %v, esperado %v", err, errMissing)
+				}
+			}
+			if n := loads.Load(); n != tc.wantLoads {
+				t.Errorf("load chamado %d vezes, esperado %d", n, tc.wantLoads)
+			}
+		})
+	}
+}
+
+func TestGetOrLoadServesStaleWhileRevalidating(t *testing.T) {
+	cache, _ := newTestCache(t)
+	stale := cacheEntry[string]{Value: "antigo", FreshUntil: time.Now().Add(-time.Second)}
+	if err := cache.Set("test:key", stale, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := make(chan struct{})
+	var once sync.Once
+	load := func(ctx context.Context) (string, error) {
+		defer once.Do(func() { close(reloaded) })
+		return "novo", nil
+	}
+	opts := CacheOptions{TTL: time.Minute, StaleFor: time.Minute}
+
+	got, err := GetOrLoad(t.Context(), cache, "test:key", opts, load)
+	if err != nil || got != "antigo" {
+		t.Fatalf("GetOrLoad = %q, %v; esperado o valor stale", got, err)
+	}
+
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second):
+		t.Fatal("o valor stale não foi recarregado")
+	}
+	// A recarga grava logo depois de load voltar
+	deadline := time.Now().Add(time.Second)
+	for {
+		got, _ = GetOrLoad(t.Context(), cache, "test:key", opts, load)
+		if got == "novo" || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got != "novo" {
+		t.Errorf("depois da recarga = %q, esperado \"novo\"", got)
+	}
+}
+
+func TestGetOrLoadWaitsForLockOwner(t *testing.T) {
+	mr := miniredis.RunT(t)
+	owner, waiter := newTestCacheOn(t, mr), newTestCacheOn(t, mr)
+	opts := CacheOptions{TTL: time.Minute, Lock: true, LockWait: 2 * time.Second}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		GetOrLoad(t.Context(), owner, "test:key", opts, func(ctx context.Context) (string, error) {
+			close(started)
+			<-release
+			return "do dono", nil
+		})
+	}()
+	<-started
+
+	var waiterLoads atomic.Int32
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	got, err := GetOrLoad(t.Context(), waiter, "test:key", opts, func(ctx context.Context) (string, error) {
+		waiterLoads.Add(1)
+		return "do outro processo", nil
+	})
+	<-done
+
+	if err != nil || got != "do dono" {
+		t.Errorf("GetOrLoad = %q, %v; esperado o valor de quem tinha o lock", got, err)
+	}
+	if n := waiterLoads.Load(); n != 0 {
+		t.Errorf("o processo sem lock chamou load %d vezes", n)
+	}
+	if mr.Exists("lock:test:key") {
+		t.Error("o lock não foi liberado")
+	}
+}
+
+func TestGetOrLoadWithoutRedis(t *testing.T) {
+	cache := NewCacheService(nil)
+	var loads atomic.Int32
+	load := func(ctx context.Context) (string, error) {
+		loads.Add(1)
+		return "valor", nil
+	}
+
+	opts := CacheOptions{TTL: time.Minute, Lock: true}
+	for range 2 {
+		if got, err := GetOrLoad(t.Context(), cache, "test:key", opts, load); err != nil || got != "valor" {
+			t.Fatalf("GetOrLoad = %q, %v", got, err)
+		}
+	}
+	if n := loads.Load(); n != 2 {
+		t.Errorf("load chamado %d vezes, esperado 2 (sem Redis não há cache)", n)
+	}
+}
+
+func TestJitterTTL(t *testing.T) {
+	if got := jitterTTL(time.Minute, 0); got != time.Minute {
+		t.Errorf("sem jitter = %v", got)
+	}
+	for range 100 {
+		got := jitterTTL(time.Minute, 0.1)
+		if got < 54*time.Second || got > 66*time.Second {
+			t.Fatalf("jitterTTL(1m, 0.1) = %v, fora de ±10%%", got)
+		}
+	}
+}
@@ -7,10 +7,17 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/josevitorrodriguess/any-song/backend/internal/metrics"
+	"golang.org/x/sync/singleflight"
 )
 
+// CacheService guarda valores em JSON no Redis. Sem Redis (cliente nil, em
+// modo degradado) o cache fica desligado: Get sempre dá miss e Set/Delete não
+// fazem nada. Para leituras com carga no miss, use GetOrLoad.
 type CacheService struct {
 	redisClient *redis.Client
+	// loads junta as cargas simultâneas da mesma chave feitas por GetOrLoad
+	loads singleflight.Group
 }
 
 func NewCacheService(client *redis.Client) *CacheService {
@@ -20,6 +27,10 @@ func NewCacheService(client *redis.Client) *CacheService {
 // Set serializa um valor para JSON e o armazena no cache com um TTL.
 // Aceita qualquer tipo de valor (`interface{}`) e o transforma em JSON.
 func (s *CacheService) Set(key string, value interface{}, ttl time.Duration) error {
+	if s.redisClient == nil {
+		return nil
+	}
+
 	// 1. Serializar o valor para o formato JSON, que é um texto.
 	// O Redis armazena dados como texto ou bytes.
 	dataToCache, err := json.Marshal(value)
@@ -31,6 +42,7 @@ func (s *CacheService) Set(key string, value interface{}, ttl time.Duration) err
 	// O `context.Background()` é usado porque esta é uma operação rápida.
 	err = s.redisClient.Set(context.Background(), key, dataToCache, ttl).Err()
 	if err != nil {
+		metrics.CacheError(key, "set")
 		return fmt.Errorf("erro ao salvar no redis (chave: %s): %w", key, err)
 	}
 
@@ -41,14 +53,20 @@ func (s *CacheService) Set(key string, value interface{}, ttl time.Duration) err
 // `dest` deve ser um ponteiro para a variável onde você quer guardar o resultado (ex: &models.User{}).
 // Retorna `true` se encontrou (Cache Hit), ou `false` se não encontrou (Cache Miss).
 func (s *CacheService) Get(key string, dest interface{}) (bool, error) {
+	if s.redisClient == nil {
+		return false, nil
+	}
+
 	// 1. Chamar o comando GET do Redis.
 	val, err := s.redisClient.Get(context.Background(), key).Bytes()
 
 	// 2. Checar o tipo de erro. Se for `redis.Nil`, significa que a chave não existe.
 	// Isso é um "Cache Miss", e não um erro do sistema.
 	if err == redis.Nil {
+		metrics.CacheMiss(key)
 		return false, nil // Cache Miss
 	} else if err != nil {
+		metrics.CacheError(key, "get")
 		return false, fmt.Errorf("erro ao obter do redis (chave: %s): %w", key, err)
 	}
 
@@ -56,21 +74,26 @@ func (s *CacheService) Get(key string, dest interface{}) (bool, error) {
 	// para dentro da variável de destino (`dest`) que foi passada como ponteiro.
 	err = json.Unmarshal(val, dest)
 	if err != nil {
+		metrics.CacheError(key, "get")
 		return false, fmt.Errorf("erro ao desserializar valor do cache (chave: %s): %w", key, err)
 	}
 
+	metrics.CacheHit(key)
 	return true, nil 
 }
 
 // Delete remove uma ou mais chaves do cache. É usado para invalidação.
 func (s *CacheService) Delete(keys ...string) error {
-	if len(keys) == 0 {
+	if len(keys) == 0 || s.redisClient == nil {
 		return nil
 	}
 
 	// O comando DEL do Redis pode remover múltiplas chaves de uma vez.
 	err := s.redisClient.Del(context.Background(), keys...).Err()
 	if err != nil {
+		for _, key := range keys {
+			metrics.CacheError(key, "delete")
+		}
 		return fmt.Errorf("erro ao deletar chaves do redis: %w", err)
 	}
 
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidLyricPart = errors.New("trecho de dueto inválido")
+	ErrPartnerNotFound  = errors.New("parceiro de dueto não encontrado")
+	ErrNotSessionMember = errors.New("usuário não participa desta sessão")
+	ErrPartNotInSession = errors.New("parte não pertence a esta sessão")
+	ErrSessionNotDuet   = errors.New("sessão não é um dueto")
+	ErrDuetWithYourself = errors.New("não é possível fazer dueto consigo mesmo")
+)
+
+type DuetService struct {
+	DB *gorm.DB
+}
+
+func NewDuetService(db *gorm.DB) *DuetService {
+	return &DuetService{
+		DB: db,
+	}
+}
+
+func (s *DuetService) GetParts(ctx context.Context, songID uuid.UUID) ([]models.LyricPart, error) {
+	var parts []models.LyricPart
+	err := s.DB.WithContext(ctx).
+		Where("song_id = ?", songID).
+		Order("line_start ASC, start_ms ASC NULLS FIRST").
+		Find(&parts).Error
+	if err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+// SetParts substitui todas as partes da música. Os trechos são validados
+// contra o número de linhas da letra e não podem se sobrepor.
+func (s *DuetService) SetParts(ctx context.Context, songID uuid.UUID, parts []models.LyricPart) ([]models.LyricPart, error) {
+	var song models.Song
+	if err := s.DB.WithContext(ctx).Select("id", "lyrics").Where("id = ?", songID).First(&song).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSongNotFound
+		}
+		return nil, err
+	}
+
+	lineCount := 0
+	if strings.TrimSpace(song.Lyrics) != "" {
+		lineCount = len(strings.Split(strings.TrimRight(song.Lyrics, "\n"), "\n"))
+	}
+	if err := validateLyricParts(parts, lineCount); err != nil {
+		return nil, err
+	}
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var previous []models.LyricPart
+		if err := tx.Where("song_id = ?", songID).Order("line_start ASC").Find(&previous).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("song_id = ?", songID).Delete(&models.LyricPart{}).Error; err != nil {
+			return err
+		}
+		if len(parts) > 0 {
+			for i := range parts {
+				parts[i].ID = uuid.Nil
+				parts[i].SongID = songID
+			}
+			if err := tx.Omit("Song").Create(&parts).Error; err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, models.AuditLyricPartsSet, "song", songID.String(),
+			map[string]interface{}{"parts": previous}, map[string]interface{}{"parts": parts})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetParts(ctx, songID)
+}
+
+// StartDuetSession abre uma sessão com dois participantes: quem inicia canta
+// a parte A e o parceiro a parte B.
+func (s *DuetService) StartDuetSession(ctx context.Context, hostUID, partnerUID string, songID *uuid.UUID) (*models.KaraokeSession, error) {
+	if hostUID == partnerUID {
+		return nil, ErrDuetWithYourself
+	}
+
+	session := models.KaraokeSession{
+		UserUID:   hostUID,
+		Mode:      models.SessionModeDuet,
+		SongID:    songID,
+		StartedAt: time.Now().UTC(),
+	}
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("firebase_uid = ?", partnerUID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrPartnerNotFound
+		}
+		if songID != nil {
+			if err := tx.Model(&models.Song{}).Where("id = ?", *songID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return ErrSongNotFound
+			}
+		}
+
+		if err := tx.Omit("Participants").Create(&session).Error; err != nil {
+			return err
+		}
+		session.Participants = []models.SessionParticipant{
+			{SessionID: session.ID, UserUID: hostUID, Part: models.DuetPartA},
+			{SessionID: session.ID, UserUID: partnerUID, Part: models.DuetPartB},
+		}
+		if err := tx.Create(&session.Participants).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).
+			Where("firebase_uid IN ?", []string{hostUID, partnerUID}).
+			Update("total_sessions", gorm.Expr("total_sessions + 1")).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RecordPartScore grava a nota de uma parte do dueto. Qualquer participante
+// pode registrar, já que normalmente um único aparelho pontua os dois.
+func (s *DuetService) RecordPartScore(ctx context.Context, sessionID uuid.UUID, actorUID, part string, score float64) (*models.KaraokeSession, error) {
+	var session models.KaraokeSession
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Participants").Where("id = ?", sessionID).First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSessionNotFound
+			}
+			return err
+		}
+		if session.Mode != models.SessionModeDuet {
+			return ErrSessionNotDuet
+		}
+
+		isMember := false
+		var target *models.SessionParticipant
+		for i := range session.Participants {
+			if session.Participants[i].UserUID == actorUID {
+				isMember = true
+			}
+			if session.Participants[i].Part == part {
+				target = &session.Participants[i]
+			}
+		}
+		if !isMember {
+			return ErrNotSessionMember
+		}
+		if target == nil {
+			return ErrPartNotInSession
+		}
+
+		target.Score = &score
+		return tx.Model(&models.SessionParticipant{}).
+			Where("session_id = ? AND user_uid = ?", target.SessionID, target.UserUID).
+			Update("score", score).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func validateLyricParts(parts []models.LyricPart, lineCount int) error {
+	for _, part := range parts {
+		if !models.IsValidDuetPart(part.Part) {
+			return fmt.Errorf("%w: parte '%s' deve ser A, B ou both", ErrInvalidLyricPart, part.Part)
+		}
+		if part.LineStart < 0 || part.LineEnd < part.LineStart {
+			return fmt.Errorf("%w: intervalo de linhas %d-%d", ErrInvalidLyricPart, part.LineStart, part.LineEnd)
+		}
+		if lineCount > 0 && part.LineEnd >= lineCount {
+			return fmt.Errorf("%w: a letra tem apenas %d linhas", ErrInvalidLyricPart, lineCount)
+		}
+		if (part.StartMs == nil) != (part.EndMs == nil) {
+			return fmt.Errorf("%w: start_ms e end_ms devem ser informados juntos", ErrInvalidLyricPart)
+		}
+		if part.StartMs != nil && *part.EndMs <= *part.StartMs {
+			return fmt.Errorf("%w: end_ms deve ser maior que start_ms", ErrInvalidLyricPart)
+		}
+	}
+
+	sorted := make([]models.LyricPart, len(parts))
+	copy(sorted, parts)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].LineStart < sorted[j].LineStart })
+	// Um trecho longo pode cobrir vários dos seguintes, então cada um é
+	// comparado com todos os anteriores, não só com o vizinho.
+	for i, cur := range sorted {
+		for _, prev := range sorted[:i] {
+			if cur.LineStart > prev.LineEnd {
+				continue
+			}
+			// Linhas em comum só são aceitas quando os dois trechos são
+			// recortados por tempo sem se sobrepor.
+			if prev.StartMs == nil || cur.StartMs == nil || (*cur.StartMs < *prev.EndMs && *prev.StartMs < *cur.EndMs) {
+				return fmt.Errorf("%w: trechos se sobrepõem nas linhas %d-%d", ErrInvalidLyricPart, cur.LineStart, min(cur.LineEnd, prev.LineEnd))
+			}
+		}
+	}
+	return nil
+}
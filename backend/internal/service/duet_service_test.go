@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+)
+
+func part(name string, lineStart, lineEnd int) models.LyricPart {
+	return models.LyricPart{Part: name, LineStart: lineStart, LineEnd: lineEnd}
+}
+
+func timedPart(name string, lineStart, lineEnd int, startMs, endMs int64) models.LyricPart {
+	p := part(name, lineStart, lineEnd)
+	p.StartMs, p.EndMs = &startMs, &endMs
+	return p
+}
+
+func TestValidateLyricParts(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		parts []models.LyricPart
+		valid bool
+	}{
+		{"trechos separados", []models.LyricPart{part("A", 0, 3), part("B", 4, 7), part("both", 8, 9)}, true},
+		{"linhas em comum sem tempo", []models.LyricPart{part("A", 0, 3), part("B", 3, 5)}, false},
+		{"mesmas linhas em tempos diferentes", []models.LyricPart{timedPart("A", 0, 1, 0, 5000), timedPart("B", 0, 1, 5000, 9000)}, true},
+		{"mesmas linhas em tempos sobrepostos", []models.LyricPart{timedPart("A", 0, 1, 0, 5000), timedPart("B", 0, 1, 4000, 9000)}, false},
+		{
+			"trecho sem tempo dentro de um longo, depois de outro trecho",
+			[]models.LyricPart{timedPart("A", 0, 10, 0, 10000), timedPart("B", 2, 3, 10000, 20000), part("A", 5, 6)},
+			false,
+		},
+		{
+			"trecho com tempo sobreposto dentro de um longo",
+			[]models.LyricPart{timedPart("A", 0, 10, 0, 10000), timedPart("B", 2, 3, 10000, 12000), timedPart("B", 5, 6, 9000, 11000)},
+			false,
+		},
+		{
+			"trechos dentro de um longo, todos em tempos diferentes",
+			[]models.LyricPart{timedPart("A", 0, 10, 0, 10000), timedPart("B", 2, 3, 10000, 12000), timedPart("B", 5, 6, 12000, 14000)},
+			true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateLyricParts(tc.parts, 0)
+			if tc.valid && err != nil {
+				t.Errorf("esperado válido, erro: %v", err)
+			}
+			if !tc.valid && !errors.Is(err, ErrInvalidLyricPart) {
+				t.Errorf("erro = %v, esperado %v", err, ErrInvalidLyricPart)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FavoriteService struct {
+	DB *gorm.DB
+}
+
+func NewFavoriteService(db *gorm.DB) *FavoriteService {
+	return &FavoriteService{
+		DB: db,
+	}
+}
+
+// AddFavorite é idempotente: favoritar duas vezes a mesma música não gera erro.
+func (s *FavoriteService) AddFavorite(ctx context.Context, userUID string, songID uuid.UUID) error {
+	var count int64
+	if err := s.DB.WithContext(ctx).Model(&models.Song{}).Where("id = ?", songID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrSongNotFound
+	}
+
+	favorite := models.Favorite{UserUID: userUID, SongID: songID}
+	return s.DB.WithContext(ctx).
+		Omit(clause.Associations).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&favorite).Error
+}
+
+func (s *FavoriteService) RemoveFavorite(ctx context.Context, userUID string, songID uuid.UUID) error {
+	return s.DB.WithContext(ctx).
+		Where("user_uid = ? AND song_id = ?", userUID, songID).
+		Delete(&models.Favorite{}).Error
+}
+
+func (s *FavoriteService) ListFavorites(ctx context.Context, userUID string, page, pageSize int) (*Page[models.Favorite], error) {
+	query := s.DB.WithContext(ctx).Model(&models.Favorite{}).Where("user_uid = ?", userUID).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var favorites []models.Favorite
+	err := query.
+		Preload("Song").
+		Preload("Song.Artist").
+		Preload("Song.Genre").
+		Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&favorites).Error
+	if err != nil {
+		return nil, err
+	}
+	return &Page[models.Favorite]{Items: favorites, Page: page, PageSize: pageSize, Total: total}, nil
+}
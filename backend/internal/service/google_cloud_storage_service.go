@@ -2,21 +2,60 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 
 	"cloud.google.com/go/storage"
+	"github.com/josevitorrodriguess/any-song/backend/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/api/iterator"
 )
 
+// ObjectStorage é o bucket padrão, onde ficam os arquivos dos usuários.
+// GoogleCloudStorageService é a implementação de produção.
+type ObjectStorage interface {
+	// ListObjects devolve os nomes dos objetos com o prefixo.
+	ListObjects(ctx context.Context, prefix string) ([]string, error)
+	OpenObject(ctx context.Context, name string) (io.ReadCloser, error)
+	// PutObject grava o conteúdo de r no objeto, substituindo o que houver.
+	PutObject(ctx context.Context, name string, r io.Reader) error
+	// DeletePrefix remove todos os objetos com o prefixo.
+	DeletePrefix(ctx context.Context, prefix string) error
+	// Check confere se o bucket está acessível, para o readiness.
+	Check(ctx context.Context) error
+	Close() error
+}
+
 type GoogleCloudStorageService struct {
 	GoogleCloudStorageClient *storage.Client
+	// BucketName é o bucket padrão (GCS_BUCKET_NAME), onde ficam os arquivos
+	// dos usuários.
+	BucketName string
 }
 
-func NewGoogleCloudStorageService(client *storage.Client) *GoogleCloudStorageService {
+func NewGoogleCloudStorageService(client *storage.Client, bucketName string) *GoogleCloudStorageService {
 	return &GoogleCloudStorageService{
 		GoogleCloudStorageClient: client,
+		BucketName:               bucketName,
 	}
 }
 
+func (s *GoogleCloudStorageService) startSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("gcs.bucket", s.BucketName))
+	return tracing.Tracer().Start(ctx, "gcs "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// UserObjectPrefix é o prefixo dos objetos de um usuário no bucket padrão.
+// Tudo o que fica ali entra no export da conta e sai com a exclusão.
+func UserObjectPrefix(userUID string) string {
+	return fmt.Sprintf("users/%s/", userUID)
+}
+
 func (s *GoogleCloudStorageService) UploadFile(bucketName, objectName string, fileData []byte) (string, error) {
 	ctx := context.Background()
 	bucket := s.GoogleCloudStorageClient.Bucket(bucketName)
@@ -34,3 +73,77 @@ func (s *GoogleCloudStorageService) UploadFile(bucketName, objectName string, fi
 	url := fmt.Sprintf("https://storage.googleapis.com/%s/%s", bucketName, objectName)
 	return url, nil
 }
+
+// ListObjects devolve os nomes dos objetos do bucket padrão com o prefixo.
+func (s *GoogleCloudStorageService) ListObjects(ctx context.Context, prefix string) (names []string, err error) {
+	ctx, span := s.startSpan(ctx, "ListObjects", attribute.String("gcs.prefix", prefix))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	it := s.GoogleCloudStorageClient.Bucket(s.BucketName).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return names, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar objetos com prefixo %s: %w", prefix, err)
+		}
+		names = append(names, attrs.Name)
+	}
+}
+
+// OpenObject abre o objeto para leitura. O span cobre só a abertura; a
+// leitura acontece depois, com o contexto de quem chamou.
+func (s *GoogleCloudStorageService) OpenObject(ctx context.Context, name string) (io.ReadCloser, error) {
+	spanCtx, span := s.startSpan(ctx, "OpenObject", attribute.String("gcs.object", name))
+	reader, err := s.GoogleCloudStorageClient.Bucket(s.BucketName).Object(name).NewReader(spanCtx)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// PutObject grava r no objeto do bucket padrão.
+func (s *GoogleCloudStorageService) PutObject(ctx context.Context, name string, r io.Reader) (err error) {
+	ctx, span := s.startSpan(ctx, "PutObject", attribute.String("gcs.object", name))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	w := s.GoogleCloudStorageClient.Bucket(s.BucketName).Object(name).NewWriter(ctx)
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return fmt.Errorf("erro ao escrever o objeto %s: %w", name, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("erro ao fechar o objeto %s: %w", name, err)
+	}
+	return nil
+}
+
+// DeletePrefix remove todos os objetos do bucket padrão com o prefixo.
+func (s *GoogleCloudStorageService) DeletePrefix(ctx context.Context, prefix string) (err error) {
+	ctx, span := s.startSpan(ctx, "DeletePrefix", attribute.String("gcs.prefix", prefix))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	names, err := s.ListObjects(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		err := s.GoogleCloudStorageClient.Bucket(s.BucketName).Object(name).Delete(ctx)
+		if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+			return fmt.Errorf("erro ao remover objeto %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Check lê os atributos do bucket padrão.
+func (s *GoogleCloudStorageService) Check(ctx context.Context) error {
+	_, err := s.GoogleCloudStorageClient.Bucket(s.BucketName).Attrs(ctx)
+	return err
+}
+
+func (s *GoogleCloudStorageService) Close() error {
+	return s.GoogleCloudStorageClient.Close()
+}
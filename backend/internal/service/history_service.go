@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	historyBufferSize    = 1024
+	historyBatchSize     = 200
+	historyFlushInterval = 2 * time.Second
+)
+
+var (
+	ErrHistoryBusy         = errors.New("histórico sobrecarregado, tente novamente")
+	ErrSessionNotFound     = errors.New("sessão não encontrada")
+	ErrSessionAlreadyEnded = errors.New("sessão já foi encerrada")
+)
+
+// Page é uma página de resultados com o total para a paginação.
+type Page[T any] struct {
+	Items    []T   `json:"items"`
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+	Total    int64 `json:"total"`
+}
+
+type RecentlyPlayedSong struct {
+	Song         models.Song `json:"song"`
+	LastPlayedAt time.Time   `json:"last_played_at"`
+	TimesPlayed  int         `json:"times_played"`
+}
+
+// HistoryService grava o histórico de forma assíncrona: RecordPlay só coloca
+// o evento num buffer e um worker grava em lotes, para que um volume alto de
+// plays não pese no caminho da requisição.
+type HistoryService struct {
+	DB    *gorm.DB
+	plays chan models.PlayEvent
+}
+
+func NewHistoryService(db *gorm.DB) *HistoryService {
+	return &HistoryService{
+		DB:    db,
+		plays: make(chan models.PlayEvent, historyBufferSize),
+	}
+}
+
+// Run consome o buffer até ctx ser cancelado e então grava o que sobrou.
+func (s *HistoryService) Run(ctx context.Context) {
+	ticker := time.NewTicker(historyFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.PlayEvent, 0, historyBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		s.writeBatch(batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case event := <-s.plays:
+			batch = append(batch, event)
+			if len(batch) >= historyBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-s.plays:
+					batch = append(batch, event)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// RecordPlay confere a música e a sessão e enfileira o play; a gravação fica
+// para o próximo lote. Retorna ErrHistoryBusy se o buffer estiver cheio.
+func (s *HistoryService) RecordPlay(ctx context.Context, event models.PlayEvent) error {
+	if err := s.checkPlay(ctx, event); err != nil {
+		return err
+	}
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.PlayedAt.IsZero() {
+		event.PlayedAt = time.Now().UTC()
+	}
+	event.Song = nil
+
+	select {
+	case s.plays <- event:
+		return nil
+	default:
+		return ErrHistoryBusy
+	}
+}
+
+// checkPlay recusa música inexistente e sessão de que o usuário não participa.
+// No lote a música inexistente seria descartada sem o cliente saber, e a
+// sessão alheia receberia o play.
+func (s *HistoryService) checkPlay(ctx context.Context, event models.PlayEvent) error {
+	db := s.DB.WithContext(ctx)
+	var count int64
+	if err := db.Model(&models.Song{}).Where("id = ?", event.SongID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrSongNotFound
+	}
+
+	if event.SessionID == nil {
+		return nil
+	}
+	err := db.Model(&models.KaraokeSession{}).
+		Where("id = ?", *event.SessionID).
+		Where(s.sessionVisibleTo(event.UserUID)).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (s *HistoryService) StartSession(ctx context.Context, userUID string) (*models.KaraokeSession, error) {
+	session := models.KaraokeSession{UserUID: userUID, Mode: models.SessionModeSolo, StartedAt: time.Now().UTC()}
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).
+			Where("firebase_uid = ?", userUID).
+			Update("total_sessions", gorm.Expr("total_sessions + 1")).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *HistoryService) EndSession(ctx context.Context, userUID string, sessionID uuid.UUID) (*models.KaraokeSession, error) {
+	var session models.KaraokeSession
+	if err := s.DB.WithContext(ctx).Where("id = ? AND user_uid = ?", sessionID, userUID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	if session.EndedAt != nil {
+		return nil, ErrSessionAlreadyEnded
+	}
+
+	now := time.Now().UTC()
+	if err := s.DB.WithContext(ctx).Model(&session).Update("ended_at", now).Error; err != nil {
+		return nil, err
+	}
+	session.EndedAt = &now
+	return &session, nil
+}
+
+// GetSession retorna a sessão se o usuário for o dono ou um participante.
+func (s *HistoryService) GetSession(ctx context.Context, userUID string, sessionID uuid.UUID) (*models.KaraokeSession, error) {
+	var session models.KaraokeSession
+	err := s.DB.WithContext(ctx).
+		Preload("Participants").
+		Where("id = ?", sessionID).
+		Where(s.sessionVisibleTo(userUID)).
+		First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListSessions inclui os duetos em que o usuário foi convidado.
+func (s *HistoryService) ListSessions(ctx context.Context, userUID string, page, pageSize int) (*Page[models.KaraokeSession], error) {
+	query := s.DB.WithContext(ctx).Model(&models.KaraokeSession{}).Where(s.sessionVisibleTo(userUID)).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var sessions []models.KaraokeSession
+	err := query.Preload("Participants").Order("started_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return &Page[models.KaraokeSession]{Items: sessions, Page: page, PageSize: pageSize, Total: total}, nil
+}
+
+func (s *HistoryService) sessionVisibleTo(userUID string) *gorm.DB {
+	participantSessions := s.DB.Model(&models.SessionParticipant{}).Select("session_id").Where("user_uid = ?", userUID)
+	return s.DB.Where("user_uid = ?", userUID).Or("id IN (?)", participantSessions)
+}
+
+func (s *HistoryService) ListPlays(ctx context.Context, userUID string, page, pageSize int) (*Page[models.PlayEvent], error) {
+	query := s.DB.WithContext(ctx).Model(&models.PlayEvent{}).Where("user_uid = ?", userUID).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var plays []models.PlayEvent
+	err := query.
+		Preload("Song").
+		Preload("Song.Artist").
+		Order("played_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&plays).Error
+	if err != nil {
+		return nil, err
+	}
+	return &Page[models.PlayEvent]{Items: plays, Page: page, PageSize: pageSize, Total: total}, nil
+}
+
+// RecentlyPlayed lista cada música uma única vez, pela data do play mais
+// recente.
+func (s *HistoryService) RecentlyPlayed(ctx context.Context, userUID string, page, pageSize int) (*Page[RecentlyPlayedSong], error) {
+	db := s.DB.WithContext(ctx)
+
+	var total int64
+	err := db.Model(&models.PlayEvent{}).
+		Where("user_uid = ?", userUID).
+		Distinct("song_id").
+		Count(&total).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		SongID       uuid.UUID
+		LastPlayedAt time.Time
+		TimesPlayed  int
+	}
+	err = db.Model(&models.PlayEvent{}).
+		Select("song_id, MAX(played_at) AS last_played_at, COUNT(*) AS times_played").
+		Where("user_uid = ?", userUID).
+		Group("song_id").
+		Order("last_played_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	songIDs := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		songIDs[i] = row.SongID
+	}
+	var songs []models.Song
+	if len(songIDs) > 0 {
+		if err := db.Preload("Artist").Preload("Genre").Where("id IN ?", songIDs).Find(&songs).Error; err != nil {
+			return nil, err
+		}
+	}
+	songsByID := make(map[uuid.UUID]models.Song, len(songs))
+	for _, song := range songs {
+		songsByID[song.ID] = song
+	}
+
+	items := make([]RecentlyPlayedSong, 0, len(rows))
+	for _, row := range rows {
+		song, ok := songsByID[row.SongID]
+		if !ok {
+			continue
+		}
+		items = append(items, RecentlyPlayedSong{Song: song, LastPlayedAt: row.LastPlayedAt, TimesPlayed: row.TimesPlayed})
+	}
+	return &Page[RecentlyPlayedSong]{Items: items, Page: page, PageSize: pageSize, Total: total}, nil
+}
+
+// writeBatch grava o lote e atualiza o play_count das músicas na mesma
+// transação. Se o lote falhar (ex.: uma música inexistente), grava evento a
+// evento para não perder os válidos.
+func (s *HistoryService) writeBatch(batch []models.PlayEvent) {
+	err := s.insertPlays(batch)
+	if err == nil {
+		return
+	}
+	slog.Warn("Falha ao gravar lote de plays, gravando individualmente", "plays", len(batch), "error", err)
+
+	for _, event := range batch {
+		if err := s.insertPlays([]models.PlayEvent{event}); err != nil {
+			slog.Error("Play descartado", "play_id", event.ID, "error", err)
+		}
+	}
+}
+
+func (s *HistoryService) insertPlays(events []models.PlayEvent) error {
+	counts := make(map[uuid.UUID]int)
+	for _, event := range events {
+		counts[event.SongID]++
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(events, historyBatchSize).Error; err != nil {
+			return err
+		}
+		for songID, count := range counts {
+			err := tx.Model(&models.Song{}).
+				Where("id = ?", songID).
+				Update("play_count", gorm.Expr("play_count + ?", count)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	partyRoomTTL          = 12 * time.Hour
+	partyCodeLength       = 6
+	partyCodeAlphabet     = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	partyMaxUpdateRetries = 10
+)
+
+var (
+	ErrPartyRoomNotFound  = errors.New("sala não encontrada")
+	ErrPartyNotHost       = errors.New("apenas o anfitrião pode controlar a reprodução")
+	ErrPartyEmptyQueue    = errors.New("a fila está vazia")
+	ErrPartyNothingToPlay = errors.New("nenhuma música tocando")
+	ErrPartyRoomBusy      = errors.New("sala com muitas alterações simultâneas, tente novamente")
+)
+
+type PartyService struct {
+	DB          *gorm.DB
+	redisClient *redis.Client
+}
+
+func NewPartyService(db *gorm.DB, client *redis.Client) *PartyService {
+	return &PartyService{
+		DB:          db,
+		redisClient: client,
+	}
+}
+
+// CreateRoom cria uma sala com um código de entrada único e o usuário como
+// anfitrião.
+func (s *PartyService) CreateRoom(ctx context.Context, hostUID string) (*models.PartyRoom, error) {
+	now := time.Now().UTC()
+	room := models.PartyRoom{
+		HostUID:   hostUID,
+		Members:   []models.PartyMember{},
+		Queue:     []models.PartyQueueEntry{},
+		Playback:  models.PartyPlayback{State: models.PartyPlaybackStopped, UpdatedAt: now},
+		Version:   1,
+		CreatedAt: now,
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		code, err := generatePartyCode()
+		if err != nil {
+			return nil, err
+		}
+		room.Code = code
+
+		data, err := json.Marshal(room)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao serializar sala: %w", err)
+		}
+		created, err := s.redisClient.SetNX(ctx, partyRoomKey(code), data, partyRoomTTL).Result()
+		if err != nil {
+			return nil, fmt.Errorf("erro ao salvar sala no redis: %w", err)
+		}
+		if created {
+			return &room, nil
+		}
+	}
+	return nil, errors.New("não foi possível gerar um código de sala único")
+}
+
+func (s *PartyService) GetRoom(ctx context.Context, code string) (*models.PartyRoom, error) {
+	data, err := s.redisClient.Get(ctx, partyRoomKey(code)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrPartyRoomNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("erro ao obter sala do redis: %w", err)
+	}
+
+	var room models.PartyRoom
+	if err := json.Unmarshal(data, &room); err != nil {
+		return nil, fmt.Errorf("erro ao desserializar sala: %w", err)
+	}
+	return &room, nil
+}
+
+func (s *PartyService) Join(ctx context.Context, code, uid, name string) (*models.PartyRoom, error) {
+	return s.update(ctx, code, "member_joined", uid, func(room *models.PartyRoom) error {
+		for i := range room.Members {
+			if room.Members[i].UID == uid {
+				room.Members[i].Connections++
+				return nil
+			}
+		}
+		room.Members = append(room.Members, models.PartyMember{UID: uid, Name: name, Connections: 1})
+		return nil
+	})
+}
+
+func (s *PartyService) Leave(ctx context.Context, code, uid string) (*models.PartyRoom, error) {
+	return s.update(ctx, code, "member_left", uid, func(room *models.PartyRoom) error {
+		for i := range room.Members {
+			if room.Members[i].UID != uid {
+				continue
+			}
+			room.Members[i].Connections--
+			if room.Members[i].Connections <= 0 {
+				room.Members = append(room.Members[:i], room.Members[i+1:]...)
+			}
+			return nil
+		}
+		return nil
+	})
+}
+
+// Enqueue adiciona uma música do catálogo ao fim da fila. Qualquer membro
+// pode enfileirar.
+func (s *PartyService) Enqueue(ctx context.Context, code, uid string, songID uuid.UUID) (*models.PartyRoom, error) {
+	var song models.Song
+	if err := s.DB.WithContext(ctx).Preload("Artist").Where("id = ?", songID).First(&song).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSongNotFound
+		}
+		return nil, err
+	}
+
+	entry := models.PartyQueueEntry{
+		ID:              uuid.New(),
+		SongID:          song.ID,
+		Title:           song.Title,
+		Artist:          song.Artist.Name,
+		DurationSeconds: song.DurationSeconds,
+		AddedBy:         uid,
+		AddedAt:         time.Now().UTC(),
+	}
+	return s.update(ctx, code, "queue_updated", uid, func(room *models.PartyRoom) error {
+		room.Queue = append(room.Queue, entry)
+		return nil
+	})
+}
+
+// Play retoma a música atual ou, se nada estiver tocando, começa a próxima
+// da fila.
+func (s *PartyService) Play(ctx context.Context, code, uid string) (*models.PartyRoom, error) {
+	return s.hostUpdate(ctx, code, "playback_updated", uid, func(room *models.PartyRoom, now time.Time) error {
+		if room.NowPlaying == nil {
+			if len(room.Queue) == 0 {
+				return ErrPartyEmptyQueue
+			}
+			advanceQueue(room, now)
+		}
+		room.Playback.PositionMs = currentPosition(room.Playback, now)
+		room.Playback.State = models.PartyPlaybackPlaying
+		room.Playback.UpdatedAt = now
+		return nil
+	})
+}
+
+func (s *PartyService) Pause(ctx context.Context, code, uid string) (*models.PartyRoom, error) {
+	return s.hostUpdate(ctx, code, "playback_updated", uid, func(room *models.PartyRoom, now time.Time) error {
+		if room.NowPlaying == nil {
+			return ErrPartyNothingToPlay
+		}
+		room.Playback.PositionMs = currentPosition(room.Playback, now)
+		room.Playback.State = models.PartyPlaybackPaused
+		room.Playback.UpdatedAt = now
+		return nil
+	})
+}
+
+// Skip descarta a música atual e começa a próxima. Com a fila vazia, a
+// reprodução para.
+func (s *PartyService) Skip(ctx context.Context, code, uid string) (*models.PartyRoom, error) {
+	return s.hostUpdate(ctx, code, "playback_updated", uid, func(room *models.PartyRoom, now time.Time) error {
+		if len(room.Queue) == 0 {
+			room.NowPlaying = nil
+			room.Playback = models.PartyPlayback{State: models.PartyPlaybackStopped, UpdatedAt: now}
+			return nil
+		}
+		advanceQueue(room, now)
+		room.Playback.State = models.PartyPlaybackPlaying
+		return nil
+	})
+}
+
+func (s *PartyService) Seek(ctx context.Context, code, uid string, positionMs int64) (*models.PartyRoom, error) {
+	return s.hostUpdate(ctx, code, "playback_updated", uid, func(room *models.PartyRoom, now time.Time) error {
+		if room.NowPlaying == nil {
+			return ErrPartyNothingToPlay
+		}
+		if positionMs < 0 {
+			positionMs = 0
+		}
+		if maxMs := int64(room.NowPlaying.DurationSeconds) * 1000; maxMs > 0 && positionMs > maxMs {
+			positionMs = maxMs
+		}
+		room.Playback.PositionMs = positionMs
+		room.Playback.UpdatedAt = now
+		return nil
+	})
+}
+
+// CloseRoom remove a sala e avisa os clientes conectados.
+func (s *PartyService) CloseRoom(ctx context.Context, code, uid string) error {
+	room, err := s.GetRoom(ctx, code)
+	if err != nil {
+		return err
+	}
+	if room.HostUID != uid {
+		return ErrPartyNotHost
+	}
+	if err := s.redisClient.Del(ctx, partyRoomKey(code)).Err(); err != nil {
+		return fmt.Errorf("erro ao remover sala do redis: %w", err)
+	}
+	return s.publish(ctx, code, models.PartyEvent{Type: "room_closed", ActorUID: uid, ServerTime: time.Now().UTC()})
+}
+
+// Subscribe devolve os eventos publicados para a sala por qualquer instância
+// da API. O canal é fechado quando ctx é cancelado.
+func (s *PartyService) Subscribe(ctx context.Context, code string) (<-chan []byte, error) {
+	pubsub := s.redisClient.Subscribe(ctx, partyEventsChannel(code))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("erro ao assinar eventos da sala: %w", err)
+	}
+
+	events := make(chan []byte, 16)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case events <- []byte(msg.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+func (s *PartyService) hostUpdate(ctx context.Context, code, eventType, uid string, fn func(room *models.PartyRoom, now time.Time) error) (*models.PartyRoom, error) {
+	return s.update(ctx, code, eventType, uid, func(room *models.PartyRoom) error {
+		if room.HostUID != uid {
+			return ErrPartyNotHost
+		}
+		return fn(room, time.Now().UTC())
+	})
+}
+
+// update aplica fn ao estado da sala com WATCH/MULTI, repetindo se outra
+// instância alterar a sala no meio, e publica o novo estado.
+func (s *PartyService) update(ctx context.Context, code, eventType, actorUID string, fn func(room *models.PartyRoom) error) (*models.PartyRoom, error) {
+	key := partyRoomKey(code)
+	var updated models.PartyRoom
+
+	txf := func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			return ErrPartyRoomNotFound
+		} else if err != nil {
+			return err
+		}
+
+		var room models.PartyRoom
+		if err := json.Unmarshal(data, &room); err != nil {
+			return fmt.Errorf("erro ao desserializar sala: %w", err)
+		}
+		if err := fn(&room); err != nil {
+			return err
+		}
+		room.Version++
+
+		newData, err := json.Marshal(room)
+		if err != nil {
+			return fmt.Errorf("erro ao serializar sala: %w", err)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, newData, partyRoomTTL)
+			return nil
+		})
+		if err == nil {
+			updated = room
+		}
+		return err
+	}
+
+	for attempt := 0; attempt < partyMaxUpdateRetries; attempt++ {
+		err := s.redisClient.Watch(ctx, txf, key)
+		if err == redis.TxFailedErr {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		event := models.PartyEvent{Type: eventType, ActorUID: actorUID, Room: &updated, ServerTime: time.Now().UTC()}
+		if err := s.publish(ctx, code, event); err != nil {
+			return nil, err
+		}
+		return &updated, nil
+	}
+	return nil, ErrPartyRoomBusy
+}
+
+func (s *PartyService) publish(ctx context.Context, code string, event models.PartyEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar evento da sala: %w", err)
+	}
+	if err := s.redisClient.Publish(ctx, partyEventsChannel(code), data).Err(); err != nil {
+		return fmt.Errorf("erro ao publicar evento da sala: %w", err)
+	}
+	return nil
+}
+
+func advanceQueue(room *models.PartyRoom, now time.Time) {
+	next := room.Queue[0]
+	room.Queue = room.Queue[1:]
+	room.NowPlaying = &next
+	room.Playback.PositionMs = 0
+	room.Playback.UpdatedAt = now
+}
+
+func currentPosition(playback models.PartyPlayback, now time.Time) int64 {
+	if playback.State != models.PartyPlaybackPlaying {
+		return playback.PositionMs
+	}
+	return playback.PositionMs + now.Sub(playback.UpdatedAt).Milliseconds()
+}
+
+// NormalizePartyCode deixa o código no formato guardado no Redis, para que
+// "abc123" e "ABC123" apontem para a mesma sala.
+func NormalizePartyCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func partyRoomKey(code string) string {
+	return fmt.Sprintf("party:room:%s", code)
+}
+
+func partyEventsChannel(code string) string {
+	return fmt.Sprintf("party:room:%s:events", code)
+}
+
+func generatePartyCode() (string, error) {
+	code := make([]byte, partyCodeLength)
+	max := big.NewInt(int64(len(partyCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("erro ao gerar código da sala: %w", err)
+		}
+		code[i] = partyCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPlaylistNotFound        = errors.New("playlist não encontrada")
+	ErrPlaylistForbidden       = errors.New("sem permissão para alterar esta playlist")
+	ErrPlaylistVersionConflict = errors.New("a playlist foi alterada por outra requisição")
+	ErrPlaylistItemNotFound    = errors.New("item não encontrado na playlist")
+	ErrInvalidPlaylistOrder    = errors.New("a nova ordem deve conter exatamente os itens da playlist")
+	ErrInvalidVisibility       = errors.New("visibilidade inválida")
+	ErrSongNotFound            = errors.New("música não encontrada")
+)
+
+type PlaylistService struct {
+	DB *gorm.DB
+}
+
+// PlaylistUpdate carrega apenas os campos editáveis; campos nil não são alterados.
+type PlaylistUpdate struct {
+	Name        *string
+	Description *string
+	Visibility  *string
+}
+
+func NewPlaylistService(db *gorm.DB) *PlaylistService {
+	return &PlaylistService{
+		DB: db,
+	}
+}
+
+func (s *PlaylistService) CreatePlaylist(ctx context.Context, playlist *models.Playlist) error {
+	if playlist.Visibility == "" {
+		playlist.Visibility = models.PlaylistPrivate
+	}
+	if !models.IsValidPlaylistVisibility(playlist.Visibility) {
+		return ErrInvalidVisibility
+	}
+	playlist.ID = uuid.Nil
+	playlist.Version = 1
+	playlist.Items = nil
+	return s.DB.WithContext(ctx).Create(playlist).Error
+}
+
+// GetPlaylist retorna a playlist com seus itens ordenados. Playlists privadas
+// só são visíveis para o dono; unlisted e public para qualquer um com o ID.
+func (s *PlaylistService) GetPlaylist(ctx context.Context, id uuid.UUID, viewerUID string) (*models.Playlist, error) {
+	playlist, err := s.loadPlaylist(s.DB.WithContext(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	if playlist.Visibility == models.PlaylistPrivate && playlist.OwnerUID != viewerUID {
+		return nil, ErrPlaylistNotFound
+	}
+	return playlist, nil
+}
+
+func (s *PlaylistService) ListUserPlaylists(ctx context.Context, ownerUID string) ([]models.Playlist, error) {
+	var playlists []models.Playlist
+	err := s.DB.WithContext(ctx).
+		Where("owner_uid = ?", ownerUID).
+		Order("updated_at DESC").
+		Find(&playlists).Error
+	if err != nil {
+		return nil, err
+	}
+	return playlists, nil
+}
+
+func (s *PlaylistService) ListPublicPlaylists(ctx context.Context, rawSearchTerm string, limit int) ([]models.Playlist, error) {
+	var playlists []models.Playlist
+	query := s.DB.WithContext(ctx).Where("visibility = ?", models.PlaylistPublic)
+	if term := strings.TrimSpace(rawSearchTerm); term != "" {
+		query = query.Where("name ILIKE ?", "%"+term+"%")
+	}
+	if err := query.Order("updated_at DESC").Limit(limit).Find(&playlists).Error; err != nil {
+		return nil, err
+	}
+	return playlists, nil
+}
+
+func (s *PlaylistService) UpdatePlaylist(ctx context.Context, id uuid.UUID, ownerUID string, update PlaylistUpdate, expectedVersion int) (*models.Playlist, error) {
+	if update.Visibility != nil && !models.IsValidPlaylistVisibility(*update.Visibility) {
+		return nil, ErrInvalidVisibility
+	}
+	return s.mutate(ctx, id, ownerUID, expectedVersion, func(tx *gorm.DB, playlist *models.Playlist) error {
+		changes := map[string]interface{}{}
+		if update.Name != nil {
+			changes["name"] = *update.Name
+		}
+		if update.Description != nil {
+			changes["description"] = *update.Description
+		}
+		if update.Visibility != nil {
+			changes["visibility"] = *update.Visibility
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		return tx.Model(&models.Playlist{}).Where("id = ?", playlist.ID).Updates(changes).Error
+	})
+}
+
+func (s *PlaylistService) DeletePlaylist(ctx context.Context, id uuid.UUID, ownerUID string) error {
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var playlist models.Playlist
+		if err := lockPlaylist(tx, id, &playlist); err != nil {
+			return err
+		}
+		if playlist.OwnerUID != ownerUID {
+			return ErrPlaylistForbidden
+		}
+		if err := tx.Where("playlist_id = ?", id).Delete(&models.PlaylistItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&playlist).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditPlaylistDelete, "playlist", id.String(), playlist, nil)
+	})
+}
+
+// AddSong insere a música na posição indicada ou no fim da playlist quando
+// position é nil.
+func (s *PlaylistService) AddSong(ctx context.Context, id uuid.UUID, ownerUID string, songID uuid.UUID, position *int, expectedVersion int) (*models.Playlist, error) {
+	return s.mutate(ctx, id, ownerUID, expectedVersion, func(tx *gorm.DB, playlist *models.Playlist) error {
+		var count int64
+		if err := tx.Model(&models.Song{}).Where("id = ?", songID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrSongNotFound
+		}
+
+		items, err := orderedItems(tx, playlist.ID)
+		if err != nil {
+			return err
+		}
+		item := models.PlaylistItem{PlaylistID: playlist.ID, SongID: songID, Position: len(items)}
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+
+		target := len(items)
+		if position != nil {
+			target = clampPosition(*position, len(items))
+		}
+		return reindexItems(tx, insertAt(items, item, target))
+	})
+}
+
+func (s *PlaylistService) RemoveItem(ctx context.Context, id uuid.UUID, ownerUID string, itemID uuid.UUID, expectedVersion int) (*models.Playlist, error) {
+	return s.mutate(ctx, id, ownerUID, expectedVersion, func(tx *gorm.DB, playlist *models.Playlist) error {
+		result := tx.Where("id = ? AND playlist_id = ?", itemID, playlist.ID).Delete(&models.PlaylistItem{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPlaylistItemNotFound
+		}
+		items, err := orderedItems(tx, playlist.ID)
+		if err != nil {
+			return err
+		}
+		return reindexItems(tx, items)
+	})
+}
+
+// ReorderItems aplica uma ordem completa. itemIDs precisa conter cada item da
+// playlist exatamente uma vez.
+func (s *PlaylistService) ReorderItems(ctx context.Context, id uuid.UUID, ownerUID string, itemIDs []uuid.UUID, expectedVersion int) (*models.Playlist, error) {
+	return s.mutate(ctx, id, ownerUID, expectedVersion, func(tx *gorm.DB, playlist *models.Playlist) error {
+		items, err := orderedItems(tx, playlist.ID)
+		if err != nil {
+			return err
+		}
+		if len(itemIDs) != len(items) {
+			return ErrInvalidPlaylistOrder
+		}
+
+		byID := make(map[uuid.UUID]models.PlaylistItem, len(items))
+		for _, item := range items {
+			byID[item.ID] = item
+		}
+		reordered := make([]models.PlaylistItem, 0, len(items))
+		for _, itemID := range itemIDs {
+			item, ok := byID[itemID]
+			if !ok {
+				return ErrInvalidPlaylistOrder
+			}
+			delete(byID, itemID)
+			reordered = append(reordered, item)
+		}
+		return reindexItems(tx, reordered)
+	})
+}
+
+// MoveItem move um único item para a posição indicada, deslocando os demais.
+func (s *PlaylistService) MoveItem(ctx context.Context, id uuid.UUID, ownerUID string, itemID uuid.UUID, toPosition int, expectedVersion int) (*models.Playlist, error) {
+	return s.mutate(ctx, id, ownerUID, expectedVersion, func(tx *gorm.DB, playlist *models.Playlist) error {
+		items, err := orderedItems(tx, playlist.ID)
+		if err != nil {
+			return err
+		}
+		from := -1
+		for i, item := range items {
+			if item.ID == itemID {
+				from = i
+				break
+			}
+		}
+		if from == -1 {
+			return ErrPlaylistItemNotFound
+		}
+
+		moved := items[from]
+		remaining := append(items[:from:from], items[from+1:]...)
+		return reindexItems(tx, insertAt(remaining, moved, clampPosition(toPosition, len(remaining))))
+	})
+}
+
+// DuplicatePlaylist copia uma playlist pública (ou do próprio usuário) para
+// newOwnerUID. A cópia sempre começa privada.
+func (s *PlaylistService) DuplicatePlaylist(ctx context.Context, id uuid.UUID, newOwnerUID, name string) (*models.Playlist, error) {
+	var copyID uuid.UUID
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		source, err := s.loadPlaylist(tx, id)
+		if err != nil {
+			return err
+		}
+		if source.Visibility != models.PlaylistPublic && source.OwnerUID != newOwnerUID {
+			return ErrPlaylistNotFound
+		}
+
+		if strings.TrimSpace(name) == "" {
+			name = source.Name
+		}
+		duplicate := models.Playlist{
+			OwnerUID:    newOwnerUID,
+			Name:        name,
+			Description: source.Description,
+			Visibility:  models.PlaylistPrivate,
+			Version:     1,
+		}
+		if err := tx.Create(&duplicate).Error; err != nil {
+			return err
+		}
+
+		if len(source.Items) > 0 {
+			items := make([]models.PlaylistItem, len(source.Items))
+			for i, item := range source.Items {
+				items[i] = models.PlaylistItem{PlaylistID: duplicate.ID, SongID: item.SongID, Position: i}
+			}
+			if err := tx.Omit("Song").Create(&items).Error; err != nil {
+				return err
+			}
+		}
+		copyID = duplicate.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.loadPlaylist(s.DB.WithContext(ctx), copyID)
+}
+
+// mutate executa fn com a linha da playlist travada (SELECT ... FOR UPDATE),
+// confere dono e versão esperada e incrementa a versão ao final. Um
+// expectedVersion zero desliga a checagem de versão.
+func (s *PlaylistService) mutate(ctx context.Context, id uuid.UUID, ownerUID string, expectedVersion int, fn func(tx *gorm.DB, playlist *models.Playlist) error) (*models.Playlist, error) {
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var playlist models.Playlist
+		if err := lockPlaylist(tx, id, &playlist); err != nil {
+			return err
+		}
+		if playlist.OwnerUID != ownerUID {
+			return ErrPlaylistForbidden
+		}
+		if expectedVersion != 0 && expectedVersion != playlist.Version {
+			return ErrPlaylistVersionConflict
+		}
+		if err := fn(tx, &playlist); err != nil {
+			return err
+		}
+		return tx.Model(&models.Playlist{}).
+			Where("id = ?", playlist.ID).
+			Update("version", gorm.Expr("version + 1")).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.loadPlaylist(s.DB.WithContext(ctx), id)
+}
+
+func (s *PlaylistService) loadPlaylist(db *gorm.DB, id uuid.UUID) (*models.Playlist, error) {
+	var playlist models.Playlist
+	err := db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Preload("Items.Song").
+		Preload("Items.Song.Artist").
+		Preload("Items.Song.Genre").
+		Where("id = ?", id).
+		First(&playlist).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlaylistNotFound
+		}
+		return nil, err
+	}
+	return &playlist, nil
+}
+
+func lockPlaylist(tx *gorm.DB, id uuid.UUID, playlist *models.Playlist) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(playlist).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPlaylistNotFound
+	}
+	return err
+}
+
+func orderedItems(tx *gorm.DB, playlistID uuid.UUID) ([]models.PlaylistItem, error) {
+	var items []models.PlaylistItem
+	if err := tx.Where("playlist_id = ?", playlistID).Order("position ASC, added_at ASC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// reindexItems grava posições contíguas (0..n-1) seguindo a ordem do slice,
+// atualizando só as linhas que mudaram.
+func reindexItems(tx *gorm.DB, items []models.PlaylistItem) error {
+	for i, item := range items {
+		if item.Position == i {
+			continue
+		}
+		if err := tx.Model(&models.PlaylistItem{}).Where("id = ?", item.ID).Update("position", i).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertAt(items []models.PlaylistItem, item models.PlaylistItem, position int) []models.PlaylistItem {
+	result := make([]models.PlaylistItem, 0, len(items)+1)
+	result = append(result, items[:position]...)
+	result = append(result, item)
+	return append(result, items[position:]...)
+}
+
+func clampPosition(position, length int) int {
+	if position < 0 {
+		return 0
+	}
+	if position > length {
+		return length
+	}
+	return position
+}
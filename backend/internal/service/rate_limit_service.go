@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// slidingWindowScript guarda cada requisição aceita num ZSET com o horário
+// em milissegundos. Entradas fora da janela são descartadas antes de contar,
+// tudo de forma atômica. Retorna {aceita, total na janela, ms até liberar}.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local reset = 0
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+// reserveQuotaScript soma ARGV[1] ao consumo do dia se ele ainda não tiver
+// chegado ao limite ARGV[2]. Ler e somar no mesmo script faz com que
+// requisições simultâneas vejam as reservas umas das outras. Retorna
+// {reservou, consumo}; o consumo vai como string porque o Redis trunca
+// números do Lua para inteiro.
+var reserveQuotaScript = redis.NewScript(`
+local usage = tonumber(redis.call('GET', KEYS[1]) or '0')
+if usage >= tonumber(ARGV[2]) then
+	return {0, tostring(usage)}
+end
+usage = redis.call('INCRBYFLOAT', KEYS[1], ARGV[1])
+redis.call('EXPIRE', KEYS[1], ARGV[3])
+return {1, usage}
+`)
+
+const quotaKeyTTL = 48 * time.Hour
+
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset é quanto falta para a requisição mais antiga sair da janela.
+	Reset time.Duration
+}
+
+// RateLimitService implementa janelas deslizantes e cotas diárias no Redis,
+// compartilhadas entre todas as instâncias da API.
+type RateLimitService struct {
+	redis *redis.Client
+}
+
+func NewRateLimitService(client *redis.Client) *RateLimitService {
+	return &RateLimitService{
+		redis: client,
+	}
+}
+
+// Allow conta uma requisição na janela da chave e diz se ela cabe no limite.
+// Requisições recusadas não entram na contagem.
+func (s *RateLimitService) Allow(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	now := time.Now().UnixMilli()
+	values, err := slidingWindowScript.Run(ctx, s.redis,
+		[]string{fmt.Sprintf("ratelimit:%s", key)},
+		now, window.Milliseconds(), limit, uuid.NewString(),
+	).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := limit - int(values[1])
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &RateLimitResult{
+		Allowed:   values[0] == 1,
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
+
+// QuotaUsage devolve quanto o usuário já consumiu da cota hoje (UTC).
+func (s *RateLimitService) QuotaUsage(ctx context.Context, userUID, quota string) (float64, error) {
+	usage, err := s.redis.Get(ctx, quotaKey(userUID, quota, time.Now())).Float64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return usage, err
+}
+
+// ConsumeQuota soma amount ao consumo do dia. A chave expira depois que o
+// dia acaba.
+func (s *RateLimitService) ConsumeQuota(ctx context.Context, userUID, quota string, amount float64) error {
+	return s.incrQuota(ctx, quotaKey(userUID, quota, time.Now()), amount)
+}
+
+// QuotaReservation é a parte da cota separada antes de um trabalho cujo
+// consumo só se conhece no fim.
+type QuotaReservation struct {
+	key    string
+	amount float64
+}
+
+// ReserveQuota separa amount da cota do dia, a não ser que o consumo já
+// tenha chegado a limit; nesse caso a reserva volta nil. usage é o consumo
+// do dia, já com a reserva.
+func (s *RateLimitService) ReserveQuota(ctx context.Context, userUID, quota string, amount, limit float64) (reservation *QuotaReservation, usage float64, err error) {
+	key := quotaKey(userUID, quota, time.Now())
+	values, err := reserveQuotaScript.Run(ctx, s.redis, []string{key}, amount, limit, int(quotaKeyTTL.Seconds())).Slice()
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(values) != 2 {
+		return nil, 0, fmt.Errorf("resposta inesperada do script de cota: %v", values)
+	}
+	usage, err = strconv.ParseFloat(fmt.Sprint(values[1]), 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("consumo de cota inválido: %w", err)
+	}
+	if reserved, _ := values[0].(int64); reserved != 1 {
+		return nil, usage, nil
+	}
+	return &QuotaReservation{key: key, amount: amount}, usage, nil
+}
+
+// SettleQuota troca a reserva pelo consumo real; com actual zero, só a
+// devolve. O acerto vai para o dia da reserva, mesmo que o trabalho termine
+// depois da meia-noite.
+func (s *RateLimitService) SettleQuota(ctx context.Context, reservation *QuotaReservation, actual float64) error {
+	return s.incrQuota(ctx, reservation.key, actual-reservation.amount)
+}
+
+func (s *RateLimitService) incrQuota(ctx context.Context, key string, amount float64) error {
+	if amount == 0 {
+		return nil
+	}
+	pipe := s.redis.TxPipeline()
+	pipe.IncrByFloat(ctx, key, amount)
+	pipe.Expire(ctx, key, quotaKeyTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// QuotaReset é o início do próximo dia em UTC, quando as cotas zeram.
+func QuotaReset(now time.Time) time.Time {
+	year, month, day := now.UTC().Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC)
+}
+
+func quotaKey(userUID, quota string, now time.Time) string {
+	return fmt.Sprintf("quota:%s:%s:%s", quota, userUID, now.UTC().Format("2006-01-02"))
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"github.com/josevitorrodriguess/any-song/backend/internal/repository"
+)
+
+// Conceder e revogar papéis limpa a chave na hora; o TTL só limita o estrago
+// de uma invalidação que falhou.
+var rbacCacheOptions = CacheOptions{
+	TTL:      15 * time.Minute,
+	Jitter:   0.1,
+	StaleFor: time.Minute,
+}
+
+var (
+	ErrRoleNotFound = errors.New("papel não encontrado")
+	ErrUserNotFound = errors.New("usuário não encontrado")
+)
+
+// RBACService resolve papéis e permissões dos usuários a partir do Postgres,
+// com cache no Redis invalidado a cada concessão ou revogação.
+type RBACService struct {
+	store repository.Store
+	cache *CacheService
+}
+
+type userAccess struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+func NewRBACService(store repository.Store, cache *CacheService) *RBACService {
+	return &RBACService{
+		store: store,
+		cache: cache,
+	}
+}
+
+func (s *RBACService) RolesForUser(ctx context.Context, userUID string) ([]string, error) {
+	access, err := s.access(ctx, userUID)
+	if err != nil {
+		return nil, err
+	}
+	return access.Roles, nil
+}
+
+func (s *RBACService) HasPermission(ctx context.Context, userUID, permission string) (bool, error) {
+	access, err := s.access(ctx, userUID)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range access.Permissions {
+		if p == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *RBACService) HasRole(ctx context.Context, userUID, role string) (bool, error) {
+	access, err := s.access(ctx, userUID)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range access.Roles {
+		if r == role {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *RBACService) ListRoles(ctx context.Context) ([]models.Role, error) {
+	return s.store.Roles().List(ctx)
+}
+
+func (s *RBACService) ListUserRoles(ctx context.Context, userUID string) ([]models.UserRole, error) {
+	return s.store.Roles().UserRoles(ctx, userUID)
+}
+
+// GrantRole é idempotente: conceder um papel que o usuário já tem não gera
+// erro nem altera quem concedeu originalmente.
+func (s *RBACService) GrantRole(ctx context.Context, userUID, role, grantedBy string) error {
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		exists, err := tx.Roles().Exists(ctx, role)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrRoleNotFound
+		}
+		if _, err := tx.Users().FindByFirebaseUID(ctx, userUID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		userRole := models.UserRole{UserUID: userUID, RoleName: role, GrantedBy: grantedBy}
+		granted, err := tx.Roles().Grant(ctx, &userRole)
+		if err != nil || !granted {
+			return err
+		}
+		return storeAudit(ctx, tx, models.AuditRoleGrant, "user", userUID, nil, map[string]string{"role": role})
+	})
+	if err != nil {
+		return err
+	}
+	s.invalidate(ctx, userUID)
+	return nil
+}
+
+func (s *RBACService) RevokeRole(ctx context.Context, userUID, role string) error {
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		revoked, err := tx.Roles().Revoke(ctx, userUID, role)
+		if err != nil || !revoked {
+			return err
+		}
+		return storeAudit(ctx, tx, models.AuditRoleRevoke, "user", userUID, map[string]string{"role": role}, nil)
+	})
+	if err != nil {
+		return err
+	}
+	s.invalidate(ctx, userUID)
+	return nil
+}
+
+// BootstrapAdminsFromFirestore concede o papel admin a todos os UIDs do
+// documento groups/admins. Usuários que ainda não existem no Postgres são
+// ignorados e entram no próximo bootstrap, depois do primeiro login.
+func (s *RBACService) BootstrapAdminsFromFirestore(ctx context.Context, client *firestore.Client) error {
+	admins, err := firestoreAdmins(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	for _, uid := range admins {
+		err := s.GrantRole(ctx, uid, models.RoleAdmin, "bootstrap:firestore")
+		if errors.Is(err, ErrUserNotFound) {
+			slog.WarnContext(ctx, "Admin do Firestore ainda não tem conta, ignorando", "user_uid", uid)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BootstrapAdminIfListed concede o papel admin só a userUID, se ele estiver
+// em groups/admins. É o que roda no primeiro login: os outros membros já
+// passaram pelo bootstrap, e conceder de novo desfaria revogações feitas
+// depois dele.
+func (s *RBACService) BootstrapAdminIfListed(ctx context.Context, client *firestore.Client, userUID string) error {
+	admins, err := firestoreAdmins(ctx, client)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(admins, userUID) {
+		return nil
+	}
+	return s.GrantRole(ctx, userUID, models.RoleAdmin, "bootstrap:firestore")
+}
+
+// firestoreAdmins lê os UIDs do documento groups/admins.
+func firestoreAdmins(ctx context.Context, client *firestore.Client) ([]string, error) {
+	doc, err := client.Collection("groups").Doc("admins").Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar grupo de admins no Firestore: %w", err)
+	}
+
+	members, ok := doc.Data()["members"].([]interface{})
+	if !ok {
+		return nil, errors.New("grupo de admins no Firestore sem a lista members")
+	}
+
+	var admins []string
+	for _, member := range members {
+		if uid, ok := member.(string); ok && uid != "" {
+			admins = append(admins, uid)
+		}
+	}
+	return admins, nil
+}
+
+func (s *RBACService) access(ctx context.Context, userUID string) (*userAccess, error) {
+	access, err := GetOrLoad(ctx, s.cache, rbacCacheKey(userUID), rbacCacheOptions, func(ctx context.Context) (userAccess, error) {
+		userRoles, err := s.store.Roles().UserRoles(ctx, userUID)
+		if err != nil {
+			return userAccess{}, err
+		}
+		var roles []string
+		for _, userRole := range userRoles {
+			roles = append(roles, userRole.RoleName)
+		}
+		roles = append(roles, models.RoleUser)
+
+		permissions, err := s.store.Roles().Permissions(ctx, roles)
+		if err != nil {
+			return userAccess{}, err
+		}
+		return userAccess{Roles: roles, Permissions: permissions}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &access, nil
+}
+
+func (s *RBACService) invalidate(ctx context.Context, userUID string) {
+	if err := s.cache.Delete(rbacCacheKey(userUID)); err != nil {
+		slog.WarnContext(ctx, "Erro ao invalidar permissões do usuário no cache", "user_uid", userUID, "error", err)
+	}
+}
+
+func rbacCacheKey(userUID string) string {
+	return fmt.Sprintf("rbac:user:%s", userUID)
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	recommendationLimit           = 50
+	recommendationCacheTTL        = 6 * time.Hour
+	recommendationRefreshInterval = 30 * time.Minute
+	recommendationActiveWindow    = 24 * time.Hour
+	recommendationRecentWindow    = 14 * 24 * time.Hour
+	recommendationTrendingWindow  = 7 * 24 * time.Hour
+	recommendationSeedSongs       = 50
+	recommendationCandidates      = 200
+
+	coOccurrenceWeight  = 0.5
+	genreAffinityWeight = 0.3
+	trendingWeight      = 0.2
+)
+
+type Recommendation struct {
+	Song    models.Song `json:"song"`
+	Score   float64     `json:"score"`
+	Reasons []string    `json:"reasons"`
+}
+
+// RecommendationService combina histórico do usuário, afinidade por gênero e
+// co-ocorrência de músicas nas sessões de outros usuários. Sem histórico, cai
+// para as músicas em alta.
+type RecommendationService struct {
+	DB    *gorm.DB
+	cache *CacheService
+}
+
+type songScore struct {
+	SongID uuid.UUID
+	Score  float64
+}
+
+type scoredCandidate struct {
+	score   float64
+	reasons []string
+}
+
+func NewRecommendationService(db *gorm.DB, cache *CacheService) *RecommendationService {
+	return &RecommendationService{
+		DB:    db,
+		cache: cache,
+	}
+}
+
+// GetRecommendations lê do cache; no primeiro acesso do usuário calcula na
+// hora. Depois disso quem mantém o cache atualizado é o Run.
+func (s *RecommendationService) GetRecommendations(ctx context.Context, userUID string, limit int) ([]Recommendation, error) {
+	var recommendations []Recommendation
+
+	found, err := s.cache.Get(recommendationCacheKey(userUID), &recommendations)
+	if err != nil {
+		slog.WarnContext(ctx, "Erro no cache de recomendações do usuário", "user_uid", userUID, "error", err)
+	}
+	if !found {
+		recommendations, err = s.Refresh(ctx, userUID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if limit > 0 && len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+	return recommendations, nil
+}
+
+// Refresh recalcula as recomendações do usuário e grava no cache.
+func (s *RecommendationService) Refresh(ctx context.Context, userUID string) ([]Recommendation, error) {
+	recommendations, err := s.compute(ctx, userUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cache.Set(recommendationCacheKey(userUID), recommendations, recommendationCacheTTL); err != nil {
+		slog.WarnContext(ctx, "Erro ao salvar recomendações do usuário no cache", "user_uid", userUID, "error", err)
+	}
+	return recommendations, nil
+}
+
+// Run recalcula periodicamente as recomendações de quem cantou nas últimas
+// 24 horas, até ctx ser cancelado.
+func (s *RecommendationService) Run(ctx context.Context) {
+	ticker := time.NewTicker(recommendationRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refreshActiveUsers(ctx)
+		}
+	}
+}
+
+func (s *RecommendationService) refreshActiveUsers(ctx context.Context) {
+	var userUIDs []string
+	err := s.DB.WithContext(ctx).Model(&models.PlayEvent{}).
+		Where("played_at > ?", time.Now().Add(-recommendationActiveWindow)).
+		Distinct().
+		Pluck("user_uid", &userUIDs).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "Falha ao listar usuários ativos para recomendações", "error", err)
+		return
+	}
+
+	for _, userUID := range userUIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := s.Refresh(ctx, userUID); err != nil {
+			slog.ErrorContext(ctx, "Falha ao recalcular recomendações do usuário", "user_uid", userUID, "error", err)
+		}
+	}
+}
+
+func (s *RecommendationService) compute(ctx context.Context, userUID string) ([]Recommendation, error) {
+	db := s.DB.WithContext(ctx)
+
+	seeds, err := s.seedSongs(db, userUID)
+	if err != nil {
+		return nil, err
+	}
+	excluded, err := s.recentlySung(db, userUID)
+	if err != nil {
+		return nil, err
+	}
+	trending, err := s.trendingSongs(db)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[uuid.UUID]*scoredCandidate)
+	add := func(scores []songScore, weight float64, reason string) {
+		for _, score := range normalizeScores(scores) {
+			if excluded[score.SongID] {
+				continue
+			}
+			candidate, ok := candidates[score.SongID]
+			if !ok {
+				candidate = &scoredCandidate{}
+				candidates[score.SongID] = candidate
+			}
+			candidate.score += weight * score.Score
+			candidate.reasons = append(candidate.reasons, reason)
+		}
+	}
+
+	if len(seeds) > 0 {
+		coOccurring, err := s.coOccurringSongs(db, userUID, seeds)
+		if err != nil {
+			return nil, err
+		}
+		byGenre, err := s.genreAffinitySongs(db, userUID)
+		if err != nil {
+			return nil, err
+		}
+		add(coOccurring, coOccurrenceWeight, "co_occurrence")
+		add(byGenre, genreAffinityWeight, "genre_affinity")
+		add(trending, trendingWeight, "trending")
+	} else {
+		add(trending, 1, "trending")
+	}
+
+	ranked := make([]songScore, 0, len(candidates))
+	for songID, candidate := range candidates {
+		ranked = append(ranked, songScore{SongID: songID, Score: candidate.score})
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	if len(ranked) > recommendationLimit {
+		ranked = ranked[:recommendationLimit]
+	}
+
+	songIDs := make([]uuid.UUID, len(ranked))
+	for i, r := range ranked {
+		songIDs[i] = r.SongID
+	}
+	var songs []models.Song
+	if len(songIDs) > 0 {
+		if err := db.Preload("Artist").Preload("Genre").Where("id IN ?", songIDs).Find(&songs).Error; err != nil {
+			return nil, err
+		}
+	}
+	songsByID := make(map[uuid.UUID]models.Song, len(songs))
+	for _, song := range songs {
+		songsByID[song.ID] = song
+	}
+
+	recommendations := make([]Recommendation, 0, len(ranked))
+	for _, r := range ranked {
+		song, ok := songsByID[r.SongID]
+		if !ok {
+			continue
+		}
+		recommendations = append(recommendations, Recommendation{
+			Song:    song,
+			Score:   r.Score,
+			Reasons: candidates[r.SongID].reasons,
+		})
+	}
+	return recommendations, nil
+}
+
+// seedSongs são as músicas mais recentes do histórico do usuário, usadas para
+// buscar co-ocorrências.
+func (s *RecommendationService) seedSongs(db *gorm.DB, userUID string) ([]uuid.UUID, error) {
+	var seeds []uuid.UUID
+	err := db.Model(&models.PlayEvent{}).
+		Select("song_id").
+		Where("user_uid = ?", userUID).
+		Group("song_id").
+		Order("MAX(played_at) DESC").
+		Limit(recommendationSeedSongs).
+		Pluck("song_id", &seeds).Error
+	return seeds, err
+}
+
+func (s *RecommendationService) recentlySung(db *gorm.DB, userUID string) (map[uuid.UUID]bool, error) {
+	var songIDs []uuid.UUID
+	err := db.Model(&models.PlayEvent{}).
+		Where("user_uid = ? AND played_at > ?", userUID, time.Now().Add(-recommendationRecentWindow)).
+		Distinct().
+		Pluck("song_id", &songIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[uuid.UUID]bool, len(songIDs))
+	for _, songID := range songIDs {
+		excluded[songID] = true
+	}
+	return excluded, nil
+}
+
+// coOccurringSongs conta em quantas sessões de outros usuários cada música
+// aparece junto com alguma das músicas do usuário.
+func (s *RecommendationService) coOccurringSongs(db *gorm.DB, userUID string, seeds []uuid.UUID) ([]songScore, error) {
+	var scores []songScore
+	err := db.Table("play_events AS seed").
+		Select("other.song_id AS song_id, COUNT(DISTINCT other.session_id) AS score").
+		Joins("JOIN play_events AS other ON other.session_id = seed.session_id AND other.song_id <> seed.song_id").
+		Where("seed.song_id IN ? AND seed.session_id IS NOT NULL AND seed.user_uid <> ?", seeds, userUID).
+		Group("other.song_id").
+		Order("score DESC").
+		Limit(recommendationCandidates).
+		Scan(&scores).Error
+	return scores, err
+}
+
+// genreAffinitySongs pontua as músicas populares de cada gênero pelo peso do
+// gênero no histórico do usuário.
+func (s *RecommendationService) genreAffinitySongs(db *gorm.DB, userUID string) ([]songScore, error) {
+	var affinities []struct {
+		GenreID uuid.UUID
+		Plays   float64
+	}
+	err := db.Table("play_events").
+		Select("songs.genre_id AS genre_id, COUNT(*) AS plays").
+		Joins("JOIN songs ON songs.id = play_events.song_id").
+		Where("play_events.user_uid = ?", userUID).
+		Group("songs.genre_id").
+		Scan(&affinities).Error
+	if err != nil || len(affinities) == 0 {
+		return nil, err
+	}
+
+	var totalPlays float64
+	weights := make(map[uuid.UUID]float64, len(affinities))
+	genreIDs := make([]uuid.UUID, len(affinities))
+	for i, affinity := range affinities {
+		totalPlays += affinity.Plays
+		weights[affinity.GenreID] = affinity.Plays
+		genreIDs[i] = affinity.GenreID
+	}
+
+	var songs []struct {
+		ID        uuid.UUID
+		GenreID   uuid.UUID
+		PlayCount float64
+	}
+	err = db.Model(&models.Song{}).
+		Select("id, genre_id, play_count").
+		Where("genre_id IN ?", genreIDs).
+		Order("play_count DESC").
+		Limit(recommendationCandidates).
+		Scan(&songs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]songScore, len(songs))
+	for i, song := range songs {
+		// +1 para que músicas ainda sem plays também entrem no ranking.
+		scores[i] = songScore{SongID: song.ID, Score: (weights[song.GenreID] / totalPlays) * (song.PlayCount + 1)}
+	}
+	return scores, nil
+}
+
+// trendingSongs são as mais cantadas dos últimos 7 dias; sem plays recentes,
+// usa o play_count acumulado.
+func (s *RecommendationService) trendingSongs(db *gorm.DB) ([]songScore, error) {
+	var scores []songScore
+	err := db.Model(&models.PlayEvent{}).
+		Select("song_id, COUNT(*) AS score").
+		Where("played_at > ?", time.Now().Add(-recommendationTrendingWindow)).
+		Group("song_id").
+		Order("score DESC").
+		Limit(recommendationCandidates).
+		Scan(&scores).Error
+	if err != nil || len(scores) > 0 {
+		return scores, err
+	}
+
+	err = db.Model(&models.Song{}).
+		Select("id AS song_id, play_count + 1 AS score").
+		Order("play_count DESC").
+		Limit(recommendationCandidates).
+		Scan(&scores).Error
+	return scores, err
+}
+
+// normalizeScores divide pelo maior valor para que cada fonte fique em [0, 1]
+// antes de aplicar os pesos.
+func normalizeScores(scores []songScore) []songScore {
+	var max float64
+	for _, score := range scores {
+		if score.Score > max {
+			max = score.Score
+		}
+	}
+	if max == 0 {
+		return scores
+	}
+
+	normalized := make([]songScore, len(scores))
+	for i, score := range scores {
+		normalized[i] = songScore{SongID: score.SongID, Score: score.Score / max}
+	}
+	return normalized
+}
+
+func recommendationCacheKey(userUID string) string {
+	return fmt.Sprintf("recommendations:user:%s", userUID)
+}
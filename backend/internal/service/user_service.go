@@ -1,116 +1,150 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/josevitorrodriguess/any-song/backend/internal/models"
-	"gorm.io/gorm"
+	"github.com/josevitorrodriguess/any-song/backend/internal/repository"
 )
 
+type UserUpdate = repository.UserUpdate
+
+// O cadastro muda pouco e é lido em toda requisição autenticada. O cache
+// negativo cobre o primeiro login, que consulta antes de criar, e buscas
+// por e-mails que não existem; quem cria o usuário limpa as chaves.
+var userCacheOptions = CacheOptions{
+	TTL:         time.Hour,
+	Jitter:      0.1,
+	StaleFor:    5 * time.Minute,
+	NotFound:    repository.ErrNotFound,
+	NegativeTTL: time.Minute,
+	Lock:        true,
+}
+
 type UserService struct {
-	DB    *gorm.DB
+	store repository.Store
 	cache *CacheService
 }
 
-func NewUserService(db *gorm.DB, cache *CacheService) *UserService {
+func NewUserService(store repository.Store, cache *CacheService) *UserService {
 	return &UserService{
-		DB:    db,
+		store: store,
 		cache: cache,
 	}
 }
 
-func (s *UserService) CreateUser(user *models.User) error {
-	return s.DB.Create(user).Error
+func (s *UserService) CreateUser(ctx context.Context, user *models.User) error {
+	if err := s.store.Users().Create(ctx, user); err != nil {
+		return err
+	}
+	s.invalidate(ctx, user)
+	return nil
 }
 
-func (s *UserService) GetUserByEmail(email string) (*models.User, error) {
-	cacheKey := fmt.Sprintf("user:email:%s", email)
-	var user models.User
-
-	found, err := s.cache.Get(cacheKey, &user)
+func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	user, err := GetOrLoad(ctx, s.cache, userEmailCacheKey(email), userCacheOptions, func(ctx context.Context) (models.User, error) {
+		return findUser(s.store.Users().FindByEmail(ctx, email))
+	})
 	if err != nil {
-		log.Printf("AVISO: Erro no cache ao buscar por email %s: %v", email, err)
-	}
-	if found {
-		return &user, nil
-	}
-	if err := s.DB.Where("email = ?", email).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+		if errors.Is(err, repository.ErrNotFound) {
 			// Usuário não encontrado - retorna nil sem erro
 			return nil, nil
 		}
-		// Outros erros são retornados
 		return nil, err
 	}
-
-	s.cache.Set(cacheKey, user, 1*time.Hour)
 	return &user, nil
 }
 
-func (s *UserService) GetUserByName(name string) (*models.User, error) {
-	var user models.User
-	if err := s.DB.Where("name = ?", name).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+func (s *UserService) GetUserByName(ctx context.Context, name string) (*models.User, error) {
+	user, err := s.store.Users().FindByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			return nil, fmt.Errorf("usuário com nome '%s' não encontrado", name)
 		}
 		return nil, err
 	}
-	return &user, nil
+	return user, nil
 }
 
-func (s *UserService) GetUserByFirebaseUID(firebaseUID string) (*models.User, error) {
-	cacheKey := fmt.Sprintf("user:uid:%s", firebaseUID)
-	var user models.User
-
-	found, err := s.cache.Get(cacheKey, &user)
+func (s *UserService) GetUserByFirebaseUID(ctx context.Context, firebaseUID string) (*models.User, error) {
+	user, err := GetOrLoad(ctx, s.cache, userUIDCacheKey(firebaseUID), userCacheOptions, func(ctx context.Context) (models.User, error) {
+		return findUser(s.store.Users().FindByFirebaseUID(ctx, firebaseUID))
+	})
 	if err != nil {
-		log.Printf("AVISO: Erro no cache ao buscar por UID %s: %v", firebaseUID, err)
-	}
-	if found {
-		return &user, nil
-	}
-
-	if err := s.DB.Where("firebase_uid = ?", firebaseUID).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("usuário com Firebase UID '%s' não encontrado", firebaseUID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
-	s.cache.Set(cacheKey, user, 1*time.Hour)
 	return &user, nil
 }
 
-func (s *UserService) UpdateUser(user *models.User) error {
-	if err := s.DB.Save(user).Error; err != nil {
+func (s *UserService) UpdateUser(ctx context.Context, user *models.User) error {
+	if err := s.store.Users().Save(ctx, user); err != nil {
 		return err
 	}
 
-	uidKey := fmt.Sprintf("user:uid:%s", user.FirebaseUID)
-	emailKey := fmt.Sprintf("user:email:%s", user.Email)
-	s.cache.Delete(uidKey, emailKey)
+	s.invalidate(ctx, user)
 
 	return nil
 }
 
-func (s *UserService) DeleteUser(firebaseUID string) error {
-	user, err := s.GetUserByFirebaseUID(firebaseUID)
+// UpdateProfile altera só os campos informados em update, sem tocar nas
+// estatísticas nem na identidade do usuário.
+func (s *UserService) UpdateProfile(ctx context.Context, firebaseUID string, update UserUpdate) (*models.User, error) {
+	var user *models.User
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		var err error
+		user, err = tx.Users().FindByFirebaseUID(ctx, firebaseUID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		if update == (UserUpdate{}) {
+			return nil
+		}
+		before := *user
+		if err := tx.Users().Update(ctx, user, update); err != nil {
+			return err
+		}
+		return storeAudit(ctx, tx, models.AuditUserUpdate, "user", firebaseUID, before, user)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if user == nil {
-		return errors.New("usuário não encontrado para deletar")
+
+	s.invalidate(ctx, user)
+
+	return user, nil
+}
+
+// invalidate limpa o cache do usuário, inclusive as entradas negativas de
+// antes de ele existir.
+func (s *UserService) invalidate(ctx context.Context, user *models.User) {
+	err := s.cache.Delete(userUIDCacheKey(user.FirebaseUID), userEmailCacheKey(user.Email), authStateCacheKey(user.FirebaseUID))
+	if err != nil {
+		slog.WarnContext(ctx, "Erro ao invalidar usuário no cache", "user_uid", user.FirebaseUID, "error", err)
 	}
+}
 
-	if err := s.DB.Where("firebase_uid = ?", firebaseUID).Delete(&models.User{}).Error; err != nil {
-		return err
+// findUser desreferencia o resultado do repositório para caber no cache.
+func findUser(user *models.User, err error) (models.User, error) {
+	if err != nil {
+		return models.User{}, err
 	}
+	return *user, nil
+}
 
-	uidKey := fmt.Sprintf("user:uid:%s", user.FirebaseUID)
-	emailKey := fmt.Sprintf("user:email:%s", user.Email)
-	s.cache.Delete(uidKey, emailKey)
+func userUIDCacheKey(firebaseUID string) string {
+	return fmt.Sprintf("user:uid:%s", firebaseUID)
+}
 
-	return nil
+func userEmailCacheKey(email string) string {
+	return fmt.Sprintf("user:email:%s", email)
 }
@@ -3,17 +3,18 @@ package gcs
 import (
 	"context"
 	"fmt"
-	"os"
 
 	"cloud.google.com/go/storage"
+	"github.com/josevitorrodriguess/any-song/backend/internal/config"
+	"google.golang.org/api/option"
 )
 
-func ConnectGoogleCloudStorage() (*storage.Client, error) {
-	if os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") == "" {
+func ConnectGoogleCloudStorage(cfg config.GCSConfig) (*storage.Client, error) {
+	if cfg.CredentialsPath == "" {
 		return nil, fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS environment variable is not set")
 	}
 
-	client, err := storage.NewClient(context.Background())
+	client, err := storage.NewClient(context.Background(), option.WithCredentialsFile(cfg.CredentialsPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Google Cloud Storage client: %w", err)
 	}
@@ -1,90 +1,90 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"os"
-	"strconv"
-	"time"
+	"log/slog"
 
+	"github.com/josevitorrodriguess/any-song/backend/internal/config"
+	"github.com/josevitorrodriguess/any-song/backend/internal/logging"
 	"github.com/josevitorrodriguess/any-song/backend/internal/models"
+	"github.com/josevitorrodriguess/any-song/backend/internal/tracing"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
-}
-
-func loadDBConfig() *DatabaseConfig {
-	port, _ := strconv.Atoi(os.Getenv("DB_PORT"))
-
-	return &DatabaseConfig{
-		Host:     os.Getenv("DB_HOST"),
-		Port:     port,
-		User:     os.Getenv("DB_USER"),
-		Password: os.Getenv("DB_PASSWORD"),
-		DBName:   os.Getenv("DB_NAME"),
-		SSLMode:  os.Getenv("DB_SSL_MODE"),
-	}
-}
-
-func ConnectDatabase() *gorm.DB {
-	config := loadDBConfig()
-
+// ConnectDatabase abre o pool, roda as migrações e os seeds. Sem Postgres a
+// API não sobe, então quem chama encerra o processo com o erro.
+func ConnectDatabase(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode,
+		cfg.Host, cfg.Port, cfg.User, cfg.Password.Value(), cfg.Name, cfg.SSLMode,
 	)
 
 	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: logging.NewGormLogger(),
+		// Chave duplicada vira gorm.ErrDuplicatedKey, que o repository traduz
+		TranslateError: true,
 	}
 
 	db, err := gorm.Open(postgres.Open(dsn), gormConfig)
 	if err != nil {
-		log.Fatalf("Erro ao conectar com PostgreSQL: %v", err)
+		return nil, fmt.Errorf("erro ao conectar com PostgreSQL: %w", err)
+	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("erro ao registrar tracing no GORM: %w", err)
 	}
 
 	if err = runMigrations(db); err != nil {
-		log.Fatalf("Erro ao executar migrações: %v", err)
+		return nil, fmt.Errorf("erro ao executar migrações: %w", err)
 	}
 
 	seedGenres(db)
+	seedRoles(db)
 
 	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Erro ao obter database instance: %v", err)
+		return nil, fmt.Errorf("erro ao obter database instance: %w", err)
 	}
 
-	sqlDB.SetMaxOpenConns(25)
-	sqlDB.SetMaxIdleConns(5)
-	sqlDB.SetConnMaxLifetime(5 * time.Minute)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime.Duration)
 
-	log.Println("Conectado ao PostgreSQL com sucesso!")
-	return db
+	slog.Info("Conectado ao PostgreSQL", "host", cfg.Host, "database", cfg.Name)
+	return db, nil
 }
 
-func TestConnection(db *gorm.DB) error {
+func TestConnection(ctx context.Context, db *gorm.DB) error {
 	sqlDB, err := db.DB()
 	if err != nil {
 		return err
 	}
 
-	return sqlDB.Ping()
+	return sqlDB.PingContext(ctx)
 }
 
 func runMigrations(db *gorm.DB) error {
-	log.Println("Executando migrações...")
-
-	// Drop existing tables in reverse order to avoid foreign key constraints
-	if err := db.Migrator().DropTable(&models.Song{}, &models.Artist{}, &models.Genre{}, &models.User{}); err != nil {
+	slog.Info("Executando migrações")
+
+	// Drop existing tables in reverse order to avoid foreign key constraints.
+	// Usuários, sessões de login, papéis, API keys, o audit log e os pedidos
+	// de exclusão de conta ficam de fora: precisam sobreviver a um restart e
+	// só passam pelo AutoMigrate. Nenhuma tabela que fica pode ter chave
+	// estrangeira para uma dropada, senão o AutoMigrate recriaria a
+	// constraint sobre linhas órfãs.
+	if err := db.Migrator().DropTable(
+		&models.LyricPart{},
+		&models.SessionParticipant{},
+		&models.PlayEvent{},
+		&models.KaraokeSession{},
+		&models.Favorite{},
+		&models.PlaylistItem{},
+		&models.Playlist{},
+		&models.Song{},
+		&models.Artist{},
+		&models.Genre{},
+	); err != nil {
 		return fmt.Errorf("erro ao dropar tabelas: %v", err)
 	}
 
@@ -94,9 +94,40 @@ func runMigrations(db *gorm.DB) error {
 		&models.Genre{},
 		&models.Artist{},
 		&models.Song{},
+		&models.Playlist{},
+		&models.PlaylistItem{},
+		&models.Favorite{},
+		&models.KaraokeSession{},
+		&models.PlayEvent{},
+		&models.SessionParticipant{},
+		&models.LyricPart{},
+		&models.Permission{},
+		&models.Role{},
+		&models.UserRole{},
+		&models.AuthSession{},
+		&models.APIKey{},
+		&models.AuditLog{},
+		&models.AccountDeletion{},
 	); err != nil {
 		return fmt.Errorf("erro ao executar migrações: %v", err)
 	}
+
+	// O audit log é somente inserção. A tabela não é recriada no boot, então
+	// o trigger é recriado sem falhar se já existir.
+	if err := db.Exec(`
+		CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_logs é somente inserção';
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+		CREATE TRIGGER audit_logs_append_only
+			BEFORE UPDATE OR DELETE ON audit_logs
+			FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+	`).Error; err != nil {
+		return fmt.Errorf("erro ao criar trigger do audit log: %v", err)
+	}
 	return nil
 }
 
@@ -112,3 +143,29 @@ func seedGenres(db *gorm.DB) {
 		db.FirstOrCreate(&models.Genre{}, models.Genre{Name: name})
 	}
 }
+
+// seedRoles garante os papéis e permissões padrão. As permissões de cada
+// papel são sobrescritas para refletir models.DefaultRoles.
+func seedRoles(db *gorm.DB) {
+	for _, permission := range models.DefaultPermissions {
+		if err := db.Save(&permission).Error; err != nil {
+			slog.Error("Erro ao criar permissão", "permission", permission.Name, "error", err)
+		}
+	}
+
+	for name, permissionNames := range models.DefaultRoles {
+		role := models.Role{Name: name}
+		if err := db.FirstOrCreate(&role, models.Role{Name: name}).Error; err != nil {
+			slog.Error("Erro ao criar papel", "role", name, "error", err)
+			continue
+		}
+
+		permissions := make([]models.Permission, len(permissionNames))
+		for i, permissionName := range permissionNames {
+			permissions[i] = models.Permission{Name: permissionName}
+		}
+		if err := db.Model(&role).Association("Permissions").Replace(permissions); err != nil {
+			slog.Error("Erro ao associar permissões ao papel", "role", name, "error", err)
+		}
+	}
+}
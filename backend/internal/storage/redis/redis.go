@@ -2,27 +2,34 @@ package redis
 
 import (
 	"context"
-	"log"
-	"os"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/josevitorrodriguess/any-song/backend/internal/config"
+	"github.com/josevitorrodriguess/any-song/backend/internal/tracing"
 )
 
-func ConnectRedis() *redis.Client {
-	redisAddr := os.Getenv("REDIS_ADDR")
-	redisPassword := os.Getenv("REDIS_PASSWORD")
-
+// ConnectRedis abre o cliente e testa a conexão. Se o Redis não responder o
+// cliente é fechado e o erro volta para quem chamou decidir se segue sem ele.
+func ConnectRedis(cfg config.RedisConfig) (*redis.Client, error) {
 	rdb := redis.NewClient(&redis.Options{
-		Addr:     redisAddr,
-		Password: redisPassword,
-		DB:       0,
+		Addr:     cfg.Addr,
+		Password: cfg.Password.Value(),
+		DB:       cfg.DB,
+		PoolSize: cfg.PoolSize,
 	})
+	rdb.AddHook(tracing.RedisHook{})
 
 	// Testa a conexão
-	if _, err := rdb.Ping(context.Background()).Result(); err != nil {
-		log.Fatalf("Erro ao conectar com Redis: %v", err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := rdb.Ping(ctx).Result(); err != nil {
+		rdb.Close()
+		return nil, fmt.Errorf("erro ao conectar com Redis em %s: %w", cfg.Addr, err)
 	}
 
-	log.Println("Cliente Redis conectado com sucesso!")
-	return rdb
+	slog.Info("Conectado ao Redis", "addr", cfg.Addr)
+	return rdb, nil
 }
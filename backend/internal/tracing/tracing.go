@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/josevitorrodriguess/any-song/backend/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/josevitorrodriguess/any-song/backend"
+
+// Tracer é usado por toda a instrumentação do backend. Sem Setup (ou com
+// TRACING_EXPORTER=none) os spans não são gravados.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup registra o TracerProvider global e o propagador W3C (traceparent e
+// baggage). O shutdown devolvido envia os spans pendentes e precisa ser
+// chamado no desligamento.
+func Setup(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case config.TracingExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case config.TracingExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil && !errors.Is(err, resource.ErrSchemaURLConflict) {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// Respeita a decisão de quem chamou; a razão vale para traces novos
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Environ devolve TRACEPARENT e TRACESTATE do span em ctx, no formato das
+// variáveis de ambiente que os SDKs do OpenTelemetry leem nos subprocessos.
+func Environ(ctx context.Context) []string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	var env []string
+	for _, key := range []string{"traceparent", "tracestate", "baggage"} {
+		if value := carrier.Get(key); value != "" {
+			env = append(env, strings.ToUpper(key)+"="+value)
+		}
+	}
+	return env
+}
+
+// RecordSpan grava um span já terminado, com início e fim conhecidos. Serve
+// para etapas medidas fora do processo, como as do Whisper.
+func RecordSpan(ctx context.Context, name string, start, end time.Time, attrs ...attribute.KeyValue) {
+	_, span := Tracer().Start(ctx, name, trace.WithTimestamp(start), trace.WithAttributes(attrs...))
+	span.End(trace.WithTimestamp(end))
+}
+
+// EndSpan marca o span como erro quando err não é nil e o encerra.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}